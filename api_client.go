@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAPIRetries       = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 10 * time.Second
+	defaultAPIClientTimeout = 30 * time.Second
+	// maxRetryAfter caps how long a server-provided Retry-After may stall the CLI
+	maxRetryAfter = 2 * time.Minute
+)
+
+// apiClient is the single way lrc talks to the LiveReview API. It attaches
+// authentication, retries transient failures with jittered exponential backoff
+// and turns non-2xx responses into typed errors.
+type apiClient struct {
+	baseURL    string
+	header     http.Header // sent with every request, e.g. X-API-Key
+	httpClient *http.Client
+	// streamClient has no overall timeout; streams are bounded by their context instead
+	streamClient *http.Client
+
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	verbose    bool
+
+	// sleep waits between attempts; tests replace it to run without delays
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newAPIClient returns a client that authenticates with an lrc API key.
+func newAPIClient(apiURL, apiKey string, verbose bool) *apiClient {
+	c := newBaseAPIClient(apiURL, verbose)
+	c.header.Set("X-API-Key", apiKey)
+	return c
+}
+
+// newBearerAPIClient returns a client that authenticates with a session token, as
+// used while `lrc setup` provisions the user.
+func newBearerAPIClient(apiURL, token string, verbose bool) *apiClient {
+	c := newBaseAPIClient(apiURL, verbose)
+	c.header.Set("Authorization", "Bearer "+token)
+	return c
+}
+
+func newBaseAPIClient(apiURL string, verbose bool) *apiClient {
+	return &apiClient{
+		baseURL:      strings.TrimSuffix(apiURL, "/"),
+		header:       make(http.Header),
+		httpClient:   &http.Client{Timeout: defaultAPIClientTimeout},
+		streamClient: &http.Client{},
+		maxRetries:   defaultAPIRetries,
+		baseDelay:    defaultRetryBaseDelay,
+		maxDelay:     defaultRetryMaxDelay,
+		verbose:      verbose,
+		sleep:        sleepContext,
+	}
+}
+
+// apiRequest describes one API call.
+type apiRequest struct {
+	Method string
+	Path   string // joined to the base URL; may include a query string
+	Body   []byte
+	Header http.Header
+	// Idempotent requests are retried on network errors and 5xx responses.
+	// Others are only retried when the server says it did not process them (429/503).
+	Idempotent bool
+}
+
+// apiResponse is a fully read 2xx response.
+type apiResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// do sends the request, retrying according to the client's policy.
+func (c *apiClient) do(ctx context.Context, r apiRequest) (*apiResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(ctx, r)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= c.maxRetries || !retryable(err, r.Idempotent) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := c.backoff(attempt)
+		var rateErr *RateLimitError
+		var serverErr *ServerError
+		switch {
+		case errors.As(err, &rateErr) && rateErr.RetryAfter > 0:
+			delay = rateErr.RetryAfter
+		case errors.As(err, &serverErr) && serverErr.RetryAfter > 0:
+			delay = serverErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+
+		if c.verbose {
+			log.Printf("%s %s failed (%v); retrying in %s (attempt %d/%d)", r.Method, r.Path, err, delay.Truncate(time.Millisecond), attempt+1, c.maxRetries)
+		}
+		if sleepErr := c.sleep(ctx, delay); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *apiClient) attempt(ctx context.Context, r apiRequest) (*apiResponse, error) {
+	resp, err := c.send(ctx, c.httpClient, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp.StatusCode, resp.Header, body)
+	}
+	return &apiResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+func (c *apiClient) send(ctx context.Context, client *http.Client, r apiRequest) (*http.Response, error) {
+	var body io.Reader
+	if r.Body != nil {
+		body = bytes.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, c.baseURL+r.Path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	for key, values := range r.Header {
+		req.Header[key] = values
+	}
+	if r.Body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	return resp, nil
+}
+
+// stream opens a long-lived GET without retries or an overall timeout. The caller
+// owns the response body. Non-2xx responses are returned as typed errors.
+func (c *apiClient) stream(ctx context.Context, path string, header http.Header) (*http.Response, error) {
+	resp, err := c.send(ctx, c.streamClient, apiRequest{Method: "GET", Path: path, Header: header})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, newAPIError(resp.StatusCode, resp.Header, body)
+	}
+	return resp, nil
+}
+
+// getJSON performs an idempotent GET and decodes the JSON response into out.
+func (c *apiClient) getJSON(ctx context.Context, path string, out interface{}) error {
+	resp, err := c.do(ctx, apiRequest{Method: "GET", Path: path, Header: http.Header{"Accept": {"application/json"}}, Idempotent: true})
+	if err != nil {
+		return err
+	}
+	return decodeAPIResponse(resp, out)
+}
+
+// postJSON sends in as JSON and decodes the response into out, if out is non-nil.
+func (c *apiClient) postJSON(ctx context.Context, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = data
+	}
+	resp, err := c.do(ctx, apiRequest{Method: "POST", Path: path, Body: body})
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return decodeAPIResponse(resp, out)
+}
+
+func decodeAPIResponse(resp *apiResponse, out interface{}) error {
+	if err := json.Unmarshal(resp.Body, out); err != nil {
+		return formatJSONParseError(resp.Body, resp.Header.Get("Content-Type"), err)
+	}
+	return nil
+}
+
+// backoff returns the jittered delay before retry number attempt+1: a random
+// duration between half and all of baseDelay*2^attempt, capped at maxDelay.
+func (c *apiClient) backoff(attempt int) time.Duration {
+	d := c.baseDelay << uint(attempt)
+	if d <= 0 || d > c.maxDelay {
+		d = c.maxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable reports whether a failed attempt may be repeated. The caller checks
+// its own context separately; a per-attempt timeout is just another network error.
+func retryable(err error, idempotent bool) bool {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		// 503 means the request was not processed, so even a POST can be repeated;
+		// 501 will not change on a retry
+		if serverErr.StatusCode == http.StatusNotImplemented {
+			return false
+		}
+		return idempotent || serverErr.StatusCode == http.StatusServiceUnavailable
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return idempotent && apiErr.StatusCode == http.StatusRequestTimeout
+	}
+	// Network errors: the request may or may not have reached the server
+	return idempotent
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Typed API errors. Each wraps the underlying *APIError, so errors.As(err, &apiErr)
+// keeps working for callers that only care about the status code.
+
+// AuthError is returned for 401 and 403 responses.
+type AuthError struct{ *APIError }
+
+func (e *AuthError) Unwrap() error { return e.APIError }
+
+// RateLimitError is returned for 429 responses.
+type RateLimitError struct {
+	*APIError
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Unwrap() error { return e.APIError }
+
+// NotFoundError is returned for 404 responses.
+type NotFoundError struct{ *APIError }
+
+func (e *NotFoundError) Unwrap() error { return e.APIError }
+
+// ServerError is returned for 5xx responses.
+type ServerError struct {
+	*APIError
+	RetryAfter time.Duration
+}
+
+func (e *ServerError) Unwrap() error { return e.APIError }
+
+// newAPIError classifies a non-2xx response.
+func newAPIError(status int, header http.Header, body []byte) error {
+	base := &APIError{StatusCode: status, Body: string(body)}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{base}
+	case status == http.StatusNotFound:
+		return &NotFoundError{base}
+	case status == http.StatusTooManyRequests:
+		return &RateLimitError{APIError: base, RetryAfter: parseRetryAfter(header.Get("Retry-After"), time.Now())}
+	case status >= 500:
+		return &ServerError{APIError: base, RetryAfter: parseRetryAfter(header.Get("Retry-After"), time.Now())}
+	default:
+		return base
+	}
+}
+
+// parseRetryAfter reads a Retry-After header in either delay-seconds or HTTP-date form.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		d = at.Sub(now)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAPIClient returns a client for server that records retry delays instead of sleeping.
+func testAPIClient(server *httptest.Server, delays *[]time.Duration) *apiClient {
+	client := newAPIClient(server.URL, "key", false)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+	return client
+}
+
+// statusSequence serves the given status codes in order, then 200 with body.
+func statusSequence(calls *int32, body string, statuses ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(calls, 1))
+		if n <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "7")
+			}
+			http.Error(w, "try later", statuses[n-1])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
+
+func TestAPIClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		statuses   []int
+		wantCalls  int32
+		wantErr    interface{}
+		wantDelays []time.Duration // only checked when non-nil
+	}{
+		{name: "GET retries 502", method: "GET", statuses: []int{502, 502}, wantCalls: 3},
+		{name: "GET honours Retry-After", method: "GET", statuses: []int{429}, wantCalls: 2, wantDelays: []time.Duration{7 * time.Second}},
+		{name: "GET gives up after max retries", method: "GET", statuses: []int{500, 500, 500, 500}, wantCalls: 4, wantErr: &ServerError{}},
+		{name: "GET does not retry 404", method: "GET", statuses: []int{404}, wantCalls: 1, wantErr: &NotFoundError{}},
+		{name: "GET does not retry 401", method: "GET", statuses: []int{401}, wantCalls: 1, wantErr: &AuthError{}},
+		{name: "POST does not retry 502", method: "POST", statuses: []int{502}, wantCalls: 1, wantErr: &ServerError{}},
+		{name: "POST retries 503", method: "POST", statuses: []int{503}, wantCalls: 2},
+		{name: "POST retries 429", method: "POST", statuses: []int{429}, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(statusSequence(&calls, `{"status":"completed"}`, tt.statuses...))
+			defer server.Close()
+
+			var delays []time.Duration
+			client := testAPIClient(server, &delays)
+
+			var out diffReviewResponse
+			var err error
+			if tt.method == "GET" {
+				err = client.getJSON(context.Background(), "/x", &out)
+			} else {
+				err = client.postJSON(context.Background(), "/x", map[string]string{"a": "b"}, &out)
+			}
+
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil || out.Status != "completed" {
+					t.Errorf("expected success, got %v (status %q)", err, out.Status)
+				}
+			case *ServerError:
+				if !errors.As(err, &want) {
+					t.Errorf("expected ServerError, got %T: %v", err, err)
+				}
+			case *NotFoundError:
+				if !errors.As(err, &want) {
+					t.Errorf("expected NotFoundError, got %T: %v", err, err)
+				}
+			case *AuthError:
+				if !errors.As(err, &want) {
+					t.Errorf("expected AuthError, got %T: %v", err, err)
+				}
+			}
+			if tt.wantErr != nil {
+				var apiErr *APIError
+				if !errors.As(err, &apiErr) {
+					t.Errorf("typed error does not unwrap to *APIError: %v", err)
+				}
+			}
+			if tt.wantDelays != nil && (len(delays) != len(tt.wantDelays) || delays[0] != tt.wantDelays[0]) {
+				t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestAPIClientStopsAtDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(statusSequence(&calls, `{}`, 429, 429))
+	defer server.Close()
+
+	var delays []time.Duration
+	client := testAPIClient(server, &delays)
+
+	// Retry-After (7s) is longer than the remaining deadline, so there is no point waiting
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var rateErr *RateLimitError
+	if err := client.getJSON(ctx, "/x", &struct{}{}); !errors.As(err, &rateErr) || rateErr.RetryAfter != 7*time.Second {
+		t.Fatalf("expected RateLimitError with Retry-After, got %v", err)
+	}
+	if calls != 1 || len(delays) != 0 {
+		t.Errorf("expected a single attempt without waiting, got %d calls and delays %v", calls, delays)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"86400", maxRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAPIClientBackoffJitter(t *testing.T) {
+	client := newAPIClient("http://example.invalid", "key", false)
+	for attempt := 0; attempt < 10; attempt++ {
+		full := client.baseDelay << uint(attempt)
+		if full > client.maxDelay {
+			full = client.maxDelay
+		}
+		for i := 0; i < 20; i++ {
+			if d := client.backoff(attempt); d < full/2 || d > full {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", attempt, d, full/2, full)
+			}
+		}
+	}
+}
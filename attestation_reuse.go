@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// stagedPatchID returns the verbatim patch ID of what a staged review looks at: the
+// conflict resolution while a merge is being concluded, otherwise the staged diff.
+// Unlike the tree hash it survives a rebase or a switch to another branch, as long
+// as the change itself stays byte-identical. "" means nothing is staged.
+func stagedPatchID() (string, error) {
+	diff, err := collectMergeResolution(false)
+	if err != nil {
+		diff, err = runGitCommand("git", "diff", "--staged")
+		if err != nil {
+			return "", err
+		}
+	}
+	return patchID(diff)
+}
+
+// attestationForPatch finds an attestation recorded for a different tree whose
+// staged patch had the given ID. The most recently written one wins.
+func attestationForPatch(id string) (*attestationPayload, error) {
+	if id == "" {
+		return nil, nil
+	}
+	gitDir, err := resolveGitDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, _ := filepath.Glob(filepath.Join(gitDir, "lrc", "attestations", "*.json"))
+
+	var found *attestationPayload
+	var foundAt time.Time
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var payload attestationPayload
+		if json.Unmarshal(data, &payload) != nil || payload.PatchID != id || strings.TrimSpace(payload.Action) == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if found == nil || info.ModTime().After(foundAt) {
+			found, foundAt = &payload, info.ModTime()
+		}
+	}
+	return found, nil
+}
+
+// runAttestationReuse gives the current tree the attestation of an earlier tree
+// with the same staged patch, re-signed for the new tree when signing is on.
+// Called by the pre-commit and prepare-commit-msg hooks before they look for an
+// attestation file. Exits 1 when the tree has no attestation afterwards.
+func runAttestationReuse(c *cli.Context) error {
+	if current, err := readCurrentAttestation(); err == nil && current != nil {
+		return nil
+	}
+	id, err := stagedPatchID()
+	if err != nil {
+		return err
+	}
+	payload, err := attestationForPatch(id)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		return cli.Exit("", 1)
+	}
+
+	fromTree := payload.TreeHash
+	payload.Signature = nil // it covers the old tree
+	if _, err := writeAttestationFullForCurrentTree(*payload); err != nil {
+		return fmt.Errorf("failed to write attestation: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "LiveReview: same patch as reviewed tree %s, reusing its attestation (%s)\n",
+		shortHash(fromTree), formatAttestationTrailer(*payload))
+	return nil
+}
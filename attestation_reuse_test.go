@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAttestationFollowsPatch(t *testing.T) {
+	initTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	os.WriteFile("a.txt", []byte("one\n"), 0644)
+	runGit(t, "add", "a.txt")
+	runGit(t, "commit", "-q", "-m", "base")
+
+	// Review a change, then move it onto a different base
+	os.WriteFile("b.txt", []byte("reviewed\n"), 0644)
+	runGit(t, "add", "b.txt")
+	if _, err := writeAttestationFullForCurrentTree(attestationPayload{Action: "reviewed", Iterations: 2}); err != nil {
+		t.Fatal(err)
+	}
+	reviewedTree := runGit(t, "write-tree")
+	runGit(t, "stash", "-q")
+	os.WriteFile("c.txt", []byte("upstream\n"), 0644)
+	runGit(t, "add", "c.txt")
+	runGit(t, "commit", "-q", "-m", "upstream")
+	runGit(t, "stash", "pop", "-q", "--index")
+
+	if runGit(t, "write-tree") == reviewedTree {
+		t.Fatal("the new base should change the tree")
+	}
+	if action, err := existingAttestationAction(); err != nil || action != "reviewed" {
+		t.Errorf("same patch on a new base: existingAttestationAction = %q, %v", action, err)
+	}
+	id, _ := stagedPatchID()
+	if payload, _ := attestationForPatch(id); payload == nil || payload.TreeHash != reviewedTree || payload.Iterations != 2 {
+		t.Errorf("attestationForPatch = %+v", payload)
+	}
+
+	// Coverage counts the earlier review of the same patch, even from another branch
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	files := []attestationFileEntry{{FilePath: "b.txt", Hunks: []attestationHunkRange{{NewStartLine: 1, NewLineCount: 1}}}}
+	if err := insertReviewSession(db, reviewedTree, "topic", "reviewed", files, "r1", id, sessionMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	cov, err := computePriorCoverage(db, "main", runGit(t, "write-tree"), id, files)
+	if err != nil || cov.PriorAICovPct != 100 || cov.CoveredLines != 1 {
+		t.Errorf("same patch: computePriorCoverage = %+v, %v", cov, err)
+	}
+
+	// Re-indenting the change is a different patch too
+	os.WriteFile("b.txt", []byte("    reviewed\n"), 0644)
+	runGit(t, "add", "b.txt")
+	if action, _ := existingAttestationAction(); action != "" {
+		t.Errorf("re-indented patch still matched attestation %q", action)
+	}
+	if err := runAttestationReuse(nil); err == nil {
+		t.Error("re-indented patch reused the attestation")
+	}
+	reindented, _ := stagedPatchID()
+	if cov, _ := computePriorCoverage(db, "main", runGit(t, "write-tree"), reindented, files); cov.PriorAICovPct == 100 {
+		t.Error("re-indented patch counted as fully reviewed")
+	}
+
+	// Any change to the patch loses the match
+	os.WriteFile("b.txt", []byte("reviewed, then edited\n"), 0644)
+	runGit(t, "add", "b.txt")
+	if action, _ := existingAttestationAction(); action != "" {
+		t.Errorf("edited patch still matched attestation %q", action)
+	}
+	edited, _ := stagedPatchID()
+	if cov, _ := computePriorCoverage(db, "main", runGit(t, "write-tree"), edited, files); cov.PriorAICovPct != 0 {
+		t.Errorf("edited patch: coverage = %.0f%%, want 0%%", cov.PriorAICovPct)
+	}
+}
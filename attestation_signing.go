@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Attestations can be signed with the key git itself signs commits with
+// (gpg.format, user.signingkey, gpg.program). The signature covers the statement
+// returned by signedStatement, so changing the tree, action, review ID or coverage
+// numbers of a signed attestation invalidates it.
+const (
+	// attestationSigNamespace scopes SSH signatures so they cannot be replayed as commit signatures
+	attestationSigNamespace = "lrc-attestation"
+
+	// attestationTrailerKey carries the signed attestation when signature_storage is "trailer"
+	attestationTrailerKey = "LiveReview-Attestation"
+
+	signatureStorageTrailer = "trailer"
+	signatureStorageNote    = "note"
+)
+
+// attestationSignature is a detached signature over an attestation's statement.
+type attestationSignature struct {
+	Format    string `json:"format"` // "openpgp" or "ssh", as in git's gpg.format
+	Signature string `json:"signature"`
+}
+
+// errNoAllowedSigners means SSH signatures cannot be checked because git has no
+// gpg.ssh.allowedSignersFile to say whose keys are trusted.
+var errNoAllowedSigners = errors.New("gpg.ssh.allowedSignersFile is not set; cannot verify SSH signatures")
+
+// signedStatement is the exact text an attestation signature covers.
+func (p attestationPayload) signedStatement() []byte {
+	chunks := 0
+	if p.Chunked {
+		chunks = p.ChunkCount
+	}
+	return []byte(fmt.Sprintf("lrc-attestation v1\ntree %s\naction %s\nreview %s\niterations %d\ncoverage %.2f\nprior-reviews %d\nchunks %d\n",
+		p.TreeHash, strings.TrimSpace(p.Action), p.ReviewID, p.Iterations, p.PriorAICovPct, p.PriorReviewCount, chunks))
+}
+
+// signingSettings reads the sign_attestations and signature_storage config keys.
+func signingSettings() (enabled bool, storage string, err error) {
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		return false, "", err
+	}
+	raw, origin := cfg.Effective("sign_attestations")
+	if enabled, err = strconv.ParseBool(raw); err != nil {
+		return false, "", fmt.Errorf("invalid sign_attestations %q in %s: %w", raw, origin, err)
+	}
+	storage, origin = cfg.Effective("signature_storage")
+	switch storage {
+	case signatureStorageTrailer, signatureStorageNote:
+	default:
+		return false, "", fmt.Errorf("invalid signature_storage %q in %s (must be trailer or note)", storage, origin)
+	}
+	return enabled, storage, nil
+}
+
+// attestationNotesRef is the notes ref signed attestations are kept under when
+// signature_storage is "note"; it sits next to the review notes ref.
+func attestationNotesRef(notesRef string) string {
+	return notesRef + "-attestations"
+}
+
+// gitConfigValue returns a git config value, or "" when it is unset.
+func gitConfigValue(key string) string {
+	out, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// signingFormat returns git's gpg.format, limited to the formats lrc can use.
+func signingFormat() (string, error) {
+	format := gitConfigValue("gpg.format")
+	if format == "" {
+		format = "openpgp"
+	}
+	if format != "openpgp" && format != "ssh" {
+		return "", fmt.Errorf("gpg.format %q is not supported for attestations (use openpgp or ssh)", format)
+	}
+	return format, nil
+}
+
+// signingProgram mirrors git's lookup of gpg.<format>.program and gpg.program.
+func signingProgram(format string) string {
+	if program := gitConfigValue("gpg." + format + ".program"); program != "" {
+		return program
+	}
+	if format == "ssh" {
+		return "ssh-keygen"
+	}
+	if program := gitConfigValue("gpg.program"); program != "" {
+		return program
+	}
+	return "gpg"
+}
+
+// signAttestation signs the payload's statement with the user's git signing key.
+func signAttestation(p *attestationPayload) error {
+	format, err := signingFormat()
+	if err != nil {
+		return err
+	}
+	key := gitConfigValue("user.signingkey")
+	program := signingProgram(format)
+
+	var sig []byte
+	switch format {
+	case "ssh":
+		sig, err = sshSign(program, key, p.signedStatement())
+	default:
+		sig, err = gpgSign(program, key, p.signedStatement())
+	}
+	if err != nil {
+		return err
+	}
+	p.Signature = &attestationSignature{Format: format, Signature: string(sig)}
+	return nil
+}
+
+func gpgSign(program, key string, msg []byte) ([]byte, error) {
+	args := []string{"--status-fd=2", "-bsa"}
+	if key != "" {
+		args = append(args, "-u", key)
+	}
+	return runSigner(program, args, msg)
+}
+
+// sshSign signs with ssh-keygen. Like git, user.signingkey may be a key file or a
+// literal public key ("key::ssh-ed25519 ..." or "ssh-ed25519 ...") whose private
+// half lives in ssh-agent.
+func sshSign(program, key string, msg []byte) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("user.signingkey is not set; SSH signing needs a key")
+	}
+	args := []string{"-Y", "sign", "-n", attestationSigNamespace}
+	literal := strings.TrimPrefix(key, "key::")
+	if literal != key || strings.HasPrefix(key, "ssh-") || strings.HasPrefix(key, "ecdsa-") || strings.HasPrefix(key, "sk-") {
+		keyFile, err := writeTempFile("lrc-signing-key-*.pub", []byte(literal+"\n"))
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(keyFile)
+		args = append(args, "-U", "-f", keyFile)
+	} else {
+		if strings.HasPrefix(key, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				key = filepath.Join(home, key[2:])
+			}
+		}
+		args = append(args, "-f", key)
+	}
+	return runSigner(program, args, msg)
+}
+
+func runSigner(program string, args []string, msg []byte) ([]byte, error) {
+	cmd := exec.Command(program, args...)
+	cmd.Stdin = bytes.NewReader(msg)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed to sign attestation: %v: %s", program, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return nil, fmt.Errorf("%s produced no signature", program)
+	}
+	return stdout.Bytes(), nil
+}
+
+// verifyAttestationSignature checks the payload's signature and returns who signed it.
+func verifyAttestationSignature(p *attestationPayload) (string, error) {
+	if p.Signature == nil || p.Signature.Signature == "" {
+		return "", fmt.Errorf("attestation is not signed")
+	}
+	sigFile, err := writeTempFile("lrc-attestation-*.sig", []byte(p.Signature.Signature))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(sigFile)
+
+	switch p.Signature.Format {
+	case "ssh":
+		return sshVerify(signingProgram("ssh"), sigFile, p.signedStatement())
+	case "openpgp":
+		return gpgVerify(signingProgram("openpgp"), sigFile, p.signedStatement())
+	default:
+		return "", fmt.Errorf("unsupported signature format %q", p.Signature.Format)
+	}
+}
+
+func sshVerify(program, sigFile string, msg []byte) (string, error) {
+	allowed := gitConfigValue("gpg.ssh.allowedSignersFile")
+	if allowed == "" {
+		return "", errNoAllowedSigners
+	}
+	if strings.HasPrefix(allowed, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			allowed = filepath.Join(home, allowed[2:])
+		}
+	}
+
+	out, err := exec.Command(program, "-Y", "find-principals", "-f", allowed, "-s", sigFile).Output()
+	if err != nil {
+		return "", fmt.Errorf("signing key is not in %s", allowed)
+	}
+	principal := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+
+	cmd := exec.Command(program, "-Y", "verify", "-f", allowed, "-I", principal, "-n", attestationSigNamespace, "-s", sigFile)
+	cmd.Stdin = bytes.NewReader(msg)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("bad signature: %s", strings.TrimSpace(string(output)))
+	}
+	return principal, nil
+}
+
+// gpgTrustLevels are gpg's owner trust levels, lowest first, named as in git's
+// gpg.minTrustLevel.
+var gpgTrustLevels = []string{"undefined", "never", "marginal", "fully", "ultimate"}
+
+// gpgMinTrustLevel returns the index in gpgTrustLevels a GPG signing key must reach:
+// git's gpg.minTrustLevel, or "fully" when it is not set. A good signature from any
+// key in the keyring is not enough.
+func gpgMinTrustLevel() (int, error) {
+	level := strings.ToLower(gitConfigValue("gpg.minTrustLevel"))
+	if level == "" {
+		level = "fully"
+	}
+	for i, name := range gpgTrustLevels {
+		if name == level {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid gpg.minTrustLevel %q", level)
+}
+
+func gpgVerify(program, sigFile string, msg []byte) (string, error) {
+	minTrust, err := gpgMinTrustLevel()
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(program, "--status-fd=1", "--verify", sigFile, "-")
+	cmd.Stdin = bytes.NewReader(msg)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	runErr := cmd.Run()
+
+	var signer string
+	trust := 0 // undefined unless gpg reports otherwise
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "[GNUPG:] "))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "GOODSIG":
+			signer = strings.Join(fields[1:], " ")
+		case "BADSIG", "EXPKEYSIG", "REVKEYSIG", "ERRSIG":
+			return "", fmt.Errorf("bad signature (%s)", strings.ToLower(fields[0]))
+		}
+		if name, ok := strings.CutPrefix(fields[0], "TRUST_"); ok {
+			for i, level := range gpgTrustLevels {
+				if strings.EqualFold(name, level) {
+					trust = i
+				}
+			}
+		}
+	}
+	if runErr != nil || signer == "" {
+		return "", fmt.Errorf("bad signature")
+	}
+	if trust < minTrust {
+		return "", fmt.Errorf("signing key of %s is trusted %s, below gpg.minTrustLevel %s",
+			signer, gpgTrustLevels[trust], gpgTrustLevels[minTrust])
+	}
+	return signer, nil
+}
+
+func writeTempFile(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// encodeSignedAttestation packs a signed attestation into a single trailer value.
+func encodeSignedAttestation(p attestationPayload) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal attestation: %w", err)
+	}
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// decodeSignedAttestation reads a trailer value or note written by lrc. Notes hold
+// the JSON itself; trailers hold it base64-encoded.
+func decodeSignedAttestation(value string) (*attestationPayload, error) {
+	value = strings.TrimSpace(value)
+	data := []byte(value)
+	if !strings.HasPrefix(value, "{") {
+		decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+		if err != nil {
+			return nil, fmt.Errorf("malformed signed attestation: %w", err)
+		}
+		data = decoded
+	}
+	var p attestationPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("malformed signed attestation: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// initSigningRepo creates a repository whose git config signs with a fresh SSH key
+// that is also listed as an allowed signer.
+func initSigningRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+	dir := initTestRepo(t)
+
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "t@example.com", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v: %s", err, out)
+	}
+	pub, err := os.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := filepath.Join(dir, ".git", "allowed_signers")
+	if err := os.WriteFile(allowed, []byte("t@example.com "+string(pub)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range [][2]string{{"gpg.format", "ssh"}, {"user.signingkey", key}, {"gpg.ssh.allowedSignersFile", allowed}} {
+		runGit(t, "config", kv[0], kv[1])
+	}
+}
+
+func TestSignAndVerifyAttestation(t *testing.T) {
+	initSigningRepo(t)
+
+	p := attestationPayload{Action: "reviewed", TreeHash: "abc123", ReviewID: "r1", Iterations: 2, PriorAICovPct: 40}
+	if err := signAttestation(&p); err != nil {
+		t.Fatalf("signAttestation: %v", err)
+	}
+	if p.Signature == nil || p.Signature.Format != "ssh" {
+		t.Fatalf("expected an ssh signature, got %+v", p.Signature)
+	}
+	if signer, err := verifyAttestationSignature(&p); err != nil || signer != "t@example.com" {
+		t.Fatalf("verify = %q, %v", signer, err)
+	}
+
+	// Every signed field is covered
+	tampered := []func(*attestationPayload){
+		func(p *attestationPayload) { p.TreeHash = "def456" },
+		func(p *attestationPayload) { p.Action = "vouched" },
+		func(p *attestationPayload) { p.ReviewID = "r2" },
+		func(p *attestationPayload) { p.Iterations = 3 },
+		func(p *attestationPayload) { p.PriorAICovPct = 90 },
+	}
+	for i, tamper := range tampered {
+		q := p
+		tamper(&q)
+		if _, err := verifyAttestationSignature(&q); err == nil {
+			t.Errorf("tampered payload %d verified", i)
+		}
+	}
+
+	// Round trip through the trailer encoding
+	encoded, err := encodeSignedAttestation(p)
+	if err != nil || strings.ContainsAny(encoded, "\n ") {
+		t.Fatalf("encoded trailer value must be a single token: %q, %v", encoded, err)
+	}
+	decoded, err := decodeSignedAttestation(encoded)
+	if err != nil || decoded.ReviewID != "r1" || decoded.Signature.Signature != p.Signature.Signature {
+		t.Fatalf("decode = %+v, %v", decoded, err)
+	}
+}
+
+func TestVerifyCommit(t *testing.T) {
+	initSigningRepo(t)
+
+	commit := func(name, message string) string {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", name)
+		message = strings.ReplaceAll(message, "TREE", runGit(t, "write-tree"))
+		runGit(t, "commit", "-q", "-m", message)
+		return runGit(t, "rev-parse", "HEAD")
+	}
+	signedTrailers := func(tree string, p attestationPayload) string {
+		t.Helper()
+		p.TreeHash = tree
+		if err := signAttestation(&p); err != nil {
+			t.Fatalf("signAttestation: %v", err)
+		}
+		encoded, _ := encodeSignedAttestation(p)
+		return preCommitTrailerKey + ": " + formatAttestationTrailer(p) + "\n" + attestationTrailerKey + ": " + encoded
+	}
+	treeOf := func(files ...string) string {
+		t.Helper()
+		for _, f := range files {
+			os.WriteFile(f, []byte(f+"\n"), 0644)
+			runGit(t, "add", f)
+		}
+		return runGit(t, "write-tree")
+	}
+
+	reviewed := attestationPayload{Action: "reviewed", ReviewID: "r1", Iterations: 1, PriorAICovPct: 0}
+	good := commit("a.txt", "add a\n\n"+signedTrailers(treeOf("a.txt"), reviewed))
+	forged := commit("b.txt", "add b\n\n"+strings.Replace(signedTrailers(treeOf("b.txt"), attestationPayload{Action: "skipped"}), preCommitTrailerKey+": skipped", preCommitTrailerKey+": ran", 1))
+	// Signed for the tree before c.txt was added, so it does not describe this commit
+	staleTree := treeOf()
+	wrongTree := commit("c.txt", "add c\n\n"+signedTrailers(staleTree, reviewed))
+	unsigned := commit("d.txt", "add d\n\n"+preCommitTrailerKey+": ran")
+	missing := commit("e.txt", "add e")
+
+	tests := []struct {
+		commit, want string
+	}{
+		{good, verifyVerified},
+		{forged, verifyInvalid},
+		{wrongTree, verifyInvalid},
+		{unsigned, verifyUnsigned},
+		{missing, verifyMissing},
+	}
+	for _, tt := range tests {
+		v := verifyCommit(tt.commit, defaultNotesRef)
+		if v.Status != tt.want {
+			t.Errorf("%s: status %q (%s), want %q", v.Subject, v.Status, v.Detail, tt.want)
+		}
+	}
+	if v := verifyCommit(good, defaultNotesRef); v.Signer != "t@example.com" || v.Source != signatureStorageTrailer || v.ReviewID != "r1" {
+		t.Errorf("unexpected verification details: %+v", v)
+	}
+
+	commits, err := verifyRevisions([]string{good + "~0.." + "HEAD"})
+	if err != nil || len(commits) != 4 {
+		t.Errorf("verifyRevisions range = %v, %v; want 4 commits", commits, err)
+	}
+	if commits, err := verifyRevisions([]string{"HEAD"}); err != nil || len(commits) != 1 {
+		t.Errorf("verifyRevisions single = %v, %v", commits, err)
+	}
+
+	// A clean merge is left out; one with a conflict resolution is verified
+	tip := runGit(t, "rev-parse", "HEAD")
+	runGit(t, "checkout", "-q", "-b", "side", good)
+	os.WriteFile("a.txt", []byte("side\n"), 0644)
+	runGit(t, "commit", "-q", "-am", "side")
+	runGit(t, "checkout", "-q", "-")
+	runGit(t, "checkout", "-q", "-b", "clean", good)
+	commit("f.txt", "add f")
+	runGit(t, "merge", "-q", "--no-edit", "side")
+	clean := runGit(t, "rev-parse", "HEAD")
+	runGit(t, "checkout", "-q", tip)
+	runGit(t, "merge", "-q", "--no-ff", "--no-commit", "side")
+	os.WriteFile("a.txt", []byte("resolved\n"), 0644)
+	runGit(t, "commit", "-q", "-am", "merge side")
+	resolved := runGit(t, "rev-parse", "HEAD")
+	for _, tt := range []struct {
+		merge string
+		want  bool
+	}{{clean, false}, {resolved, true}} {
+		commits, err := verifyRevisions([]string{good + ".." + tt.merge})
+		if err != nil || slices.Contains(commits, tt.merge) != tt.want {
+			t.Errorf("verifyRevisions range to %s = %v, %v; want the merge included: %v", tt.merge, commits, err, tt.want)
+		}
+		named, err := verifyRevisions([]string{tt.merge})
+		if err != nil || slices.Contains(named, tt.merge) != tt.want {
+			t.Errorf("verifyRevisions %s = %v, %v; want the merge included: %v", tt.merge, named, err, tt.want)
+		}
+	}
+	if v := verifyCommit(resolved, defaultNotesRef); v.Status != verifyMissing {
+		t.Errorf("unattested merge resolution: status %q", v.Status)
+	}
+}
+
+func TestGPGVerifyRequiresTrust(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not available")
+	}
+	initTestRepo(t)
+	// Short home directories: gpg-agent's socket path has a length limit
+	newHome := func(uid string) string {
+		t.Helper()
+		home, err := os.MkdirTemp("", "lrc-gpg-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+			os.RemoveAll(home)
+		})
+		if out, err := exec.Command("gpg", "--homedir", home, "--batch", "--passphrase", "", "--quick-gen-key", uid, "ed25519", "sign", "never").CombinedOutput(); err != nil {
+			t.Skipf("gpg key generation failed: %v: %s", err, out)
+		}
+		return home
+	}
+	own, other := newHome("own <own@example.com>"), newHome("other <other@example.com>")
+	pub, err := exec.Command("gpg", "--homedir", other, "--export", "--armor").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	importCmd := exec.Command("gpg", "--homedir", own, "--batch", "--import")
+	importCmd.Stdin = strings.NewReader(string(pub))
+	if out, err := importCmd.CombinedOutput(); err != nil {
+		t.Fatalf("gpg --import: %v: %s", err, out)
+	}
+
+	msg := []byte("attestation statement\n")
+	sign := func(home string) string {
+		t.Helper()
+		cmd := exec.Command("gpg", "--homedir", home, "--batch", "--detach-sign", "--armor")
+		cmd.Stdin = strings.NewReader(string(msg))
+		sig, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("gpg --detach-sign: %v", err)
+		}
+		sigFile := filepath.Join(t.TempDir(), "sig")
+		os.WriteFile(sigFile, sig, 0644)
+		return sigFile
+	}
+	t.Setenv("GNUPGHOME", own)
+
+	// The verifier's own key is ultimately trusted
+	if signer, err := gpgVerify("gpg", sign(own), msg); err != nil || !strings.Contains(signer, "own@example.com") {
+		t.Errorf("own key: gpgVerify = %q, %v", signer, err)
+	}
+	// A key that is merely in the keyring is not
+	untrusted := sign(other)
+	if signer, err := gpgVerify("gpg", untrusted, msg); err == nil || !strings.Contains(err.Error(), "trusted undefined") {
+		t.Errorf("untrusted key: gpgVerify = %q, %v", signer, err)
+	}
+	runGit(t, "config", "gpg.minTrustLevel", "undefined")
+	if _, err := gpgVerify("gpg", untrusted, msg); err != nil {
+		t.Errorf("untrusted key with gpg.minTrustLevel undefined: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// preCommitTrailerKey is the trailer the commit-msg hook adds to every commit.
+const preCommitTrailerKey = "LiveReview Pre-Commit Check"
+
+// Verification outcomes for a single commit, from best to worst.
+const (
+	verifyVerified = "verified" // signed, signature good, trailer and tree match
+	verifyUnsigned = "unsigned" // trailer present but no signed attestation
+	verifyMissing  = "missing"  // no trailer and no signed attestation
+	verifyInvalid  = "invalid"  // bad signature, or the trailer or tree do not match it
+)
+
+// commitVerification is the result of checking one commit's attestation.
+type commitVerification struct {
+	Commit   string `json:"commit"`
+	Subject  string `json:"subject"`
+	Status   string `json:"status"`
+	Trailer  string `json:"trailer,omitempty"`
+	Action   string `json:"action,omitempty"`
+	ReviewID string `json:"review_id,omitempty"`
+	Signer   string `json:"signer,omitempty"`
+	Source   string `json:"source,omitempty"` // where the signed attestation was found: trailer or note
+	Detail   string `json:"detail,omitempty"`
+}
+
+// readAttestationForTree reads the attestation file recorded for a tree, if any.
+func readAttestationForTree(treeHash string) (*attestationPayload, error) {
+	gitDir, err := resolveGitDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "lrc", "attestations", treeHash+".json"))
+	if err != nil {
+		return nil, nil // not present
+	}
+	var payload attestationPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("malformed attestation JSON: %w", err)
+	}
+	return &payload, nil
+}
+
+// runAttestationNote stores the signed attestation of a just-created commit as a
+// git note. Called by the post-commit hook before the attestation file is removed;
+// it does nothing unless signature_storage is "note".
+func runAttestationNote(c *cli.Context) error {
+	_, storage, err := signingSettings()
+	if err != nil || storage != signatureStorageNote {
+		return err
+	}
+	commit := c.Args().First()
+	if commit == "" {
+		commit = "HEAD"
+	}
+	tree, err := runGitCommand("git", "rev-parse", "--verify", commit+"^{tree}")
+	if err != nil {
+		return fmt.Errorf("not a commit: %s", commit)
+	}
+	payload, err := readAttestationForTree(strings.TrimSpace(string(tree)))
+	if err != nil || payload == nil || payload.Signature == nil {
+		return err
+	}
+
+	_, ref, err := notesSettings(false)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal attestation: %w", err)
+	}
+	return addReviewNote(attestationNotesRef(ref), commit, string(data)+"\n")
+}
+
+// parseAttestationTrailers pulls the lrc trailers out of a commit message. The last
+// occurrence wins, as git does for repeated trailers.
+func parseAttestationTrailers(message string) (check, signed string) {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if v, ok := strings.CutPrefix(line, preCommitTrailerKey+":"); ok {
+			check = strings.TrimSpace(v)
+		}
+		if v, ok := strings.CutPrefix(line, attestationTrailerKey+":"); ok {
+			signed = strings.TrimSpace(v)
+		}
+	}
+	return check, signed
+}
+
+// verifyCommit checks a commit's trailer against its signed attestation, read from
+// the LiveReview-Attestation trailer or, failing that, from the attestation notes.
+func verifyCommit(commit, notesRef string) commitVerification {
+	return verifyCommitWithNote(commit, func() string {
+		note, err := exec.Command("git", "notes", "--ref", attestationNotesRef(notesRef), "show", commit).Output()
+		if err != nil {
+			return ""
+		}
+		return string(note)
+	})
+}
+
+// verifyCommitWithNote is verifyCommit with the attestation note supplied by the
+// caller; attestationNote is only called when the commit has no signed trailer.
+func verifyCommitWithNote(commit string, attestationNote func() string) commitVerification {
+	v := commitVerification{Commit: commit}
+	out, err := runGitCommand("git", "log", "-1", "--format=%T%n%s%n%B", commit)
+	if err != nil {
+		v.Status, v.Detail = verifyInvalid, "cannot read commit"
+		return v
+	}
+	parts := strings.SplitN(string(out), "\n", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	tree := parts[0]
+	v.Subject = parts[1]
+	trailer, encoded := parseAttestationTrailers(parts[2])
+	v.Trailer = trailer
+
+	if encoded != "" {
+		v.Source = signatureStorageTrailer
+	} else if note := attestationNote(); note != "" {
+		encoded = note
+		v.Source = signatureStorageNote
+	}
+
+	switch {
+	case encoded == "" && v.Trailer == "":
+		v.Status, v.Detail = verifyMissing, "no LiveReview trailer"
+		return v
+	case encoded == "":
+		v.Status, v.Detail = verifyUnsigned, "trailer has no signed attestation"
+		return v
+	}
+
+	payload, err := decodeSignedAttestation(encoded)
+	if err != nil {
+		v.Status, v.Detail = verifyInvalid, err.Error()
+		return v
+	}
+	v.Action, v.ReviewID = payload.Action, payload.ReviewID
+	if payload.Signature == nil {
+		v.Status, v.Detail = verifyUnsigned, "attestation carries no signature"
+		return v
+	}
+
+	signer, err := verifyAttestationSignature(payload)
+	if err != nil {
+		v.Status, v.Detail = verifyInvalid, err.Error()
+		return v
+	}
+	v.Signer = signer
+
+	switch {
+	case payload.TreeHash != tree:
+		v.Status, v.Detail = verifyInvalid, fmt.Sprintf("signed for tree %s but the commit records %s", shortHash(payload.TreeHash), shortHash(tree))
+	case v.Trailer != "" && v.Trailer != formatAttestationTrailer(*payload):
+		v.Status, v.Detail = verifyInvalid, fmt.Sprintf("trailer says %q but the signed attestation says %q", v.Trailer, formatAttestationTrailer(*payload))
+	default:
+		v.Status, v.Detail = verifyVerified, fmt.Sprintf("%s, signed by %s", formatAttestationTrailer(*payload), signer)
+	}
+	return v
+}
+
+// verifyRevisions lists the commits to check: every non-merge commit of a range
+// (A..B, A...B), or exactly the named commits otherwise. Merges are checked when they
+// change anything beyond what git merges on its own, as in lrc enforce: the hooks
+// attest those conflict resolutions.
+func verifyRevisions(args []string) ([]string, error) {
+	revs := args
+	isRange := false
+	for _, a := range args {
+		if strings.Contains(a, "..") || strings.HasPrefix(a, "^") {
+			isRange = true
+		}
+	}
+	if !isRange {
+		revs = append([]string{"--no-walk"}, args...)
+	}
+	out, err := runGitCommand("git", append([]string{"rev-list", "--no-merges"}, revs...)...)
+	if err != nil {
+		return nil, invalidInput("invalid revision range %q", strings.Join(args, " "))
+	}
+	commits := strings.Fields(string(out))
+	merges, err := collectMergeChanges(revs)
+	if err != nil {
+		return nil, invalidInput("invalid revision range %q", strings.Join(args, " "))
+	}
+	for _, m := range merges {
+		commits = append(commits, m.Commit)
+	}
+	return commits, nil
+}
+
+// runVerify implements `lrc verify <commit-range>`.
+func runVerify(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return reviewExitError(invalidInput("usage: lrc verify [flags] <commit-range>"))
+	}
+	commits, err := verifyRevisions(c.Args().Slice())
+	if err != nil {
+		return reviewExitError(err)
+	}
+	_, ref, err := notesSettings(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+
+	results := make([]commitVerification, 0, len(commits))
+	failed := 0
+	for _, commit := range commits {
+		v := verifyCommit(commit, ref)
+		switch v.Status {
+		case verifyInvalid:
+			failed++
+		case verifyUnsigned:
+			if !c.Bool("allow-unsigned") {
+				failed++
+			}
+		case verifyMissing:
+			if !c.Bool("allow-missing") {
+				failed++
+			}
+		}
+		results = append(results, v)
+	}
+
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, v := range results {
+			fmt.Printf("%-12s  %-8s  %-40s  %s\n", shortHash(v.Commit), v.Status, truncateField(v.Subject, 40), v.Detail)
+		}
+		fmt.Printf("\n%d commit(s) checked, %d failed\n", len(results), failed)
+	}
+
+	if failed > 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
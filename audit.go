@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Review statuses lrc audit assigns to commits from their LiveReview trailer, or
+// from git notes for commits without one.
+const (
+	auditReviewed = "reviewed" // trailer "ran"
+	auditVouched  = "vouched"
+	auditSkipped  = "skipped" // trailer "skipped" or "skipped manually"
+	auditNone     = "none"    // no LiveReview trailer or note at all
+)
+
+var auditStatuses = []string{auditReviewed, auditVouched, auditSkipped, auditNone}
+
+// defaultAuditDepth is how many directory levels the per-path totals group by.
+const defaultAuditDepth = 2
+
+func isAuditStatus(s string) bool {
+	for _, status := range auditStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// auditCommit is one audited commit.
+type auditCommit struct {
+	Commit     string   `json:"commit"`
+	Author     string   `json:"author"`
+	Email      string   `json:"email"`
+	Date       string   `json:"date"`
+	Subject    string   `json:"subject"`
+	Status     string   `json:"status"`
+	Trailer    string   `json:"trailer,omitempty"`
+	Iterations int      `json:"iterations,omitempty"`
+	Coverage   int      `json:"coverage,omitempty"`  // percent of the diff already AI-reviewed before the final iteration
+	Source     string   `json:"source,omitempty"`    // where the status came from: trailer or note
+	Signature  string   `json:"signature,omitempty"` // verifyCommit's status, filled in only when a rule needs it
+	Files      []string `json:"files"`
+}
+
+// auditTotals counts commits by status for one author, path or the whole range.
+type auditTotals struct {
+	Key      string `json:"key,omitempty"`
+	Commits  int    `json:"commits"`
+	Reviewed int    `json:"reviewed"`
+	Vouched  int    `json:"vouched"`
+	Skipped  int    `json:"skipped"`
+	None     int    `json:"none"`
+}
+
+func (t *auditTotals) add(status string) {
+	t.Commits++
+	switch status {
+	case auditReviewed:
+		t.Reviewed++
+	case auditVouched:
+		t.Vouched++
+	case auditSkipped:
+		t.Skipped++
+	default:
+		t.None++
+	}
+}
+
+// auditReport is everything lrc audit prints.
+type auditReport struct {
+	Range      string            `json:"range"`
+	Commits    []auditCommit     `json:"commits"`
+	Totals     auditTotals       `json:"totals"`
+	Authors    []auditTotals     `json:"authors"`
+	Paths      []auditTotals     `json:"paths"`
+	Policy     string            `json:"policy,omitempty"`
+	Violations []policyViolation `json:"violations"`
+}
+
+var trailerStatsPattern = regexp.MustCompile(`\(iter:(\d+), coverage:(\d+)%`)
+
+// parseCheckTrailer maps a "LiveReview Pre-Commit Check" value such as
+// "ran (iter:2, coverage:40%)" onto an audit status and its numbers.
+func parseCheckTrailer(value string) (status string, iterations, coverage int) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 {
+		return auditNone, 0, 0
+	}
+	switch fields[0] {
+	case "ran":
+		status = auditReviewed
+	case "vouched":
+		status = auditVouched
+	case "skipped":
+		status = auditSkipped
+	default:
+		status = auditNone
+	}
+	if m := trailerStatsPattern.FindStringSubmatch(value); m != nil {
+		iterations, _ = strconv.Atoi(m[1])
+		coverage, _ = strconv.Atoi(m[2])
+	}
+	return status, iterations, coverage
+}
+
+// auditStatusForAction maps an attestation action onto an audit status.
+func auditStatusForAction(action string) string {
+	switch strings.TrimSpace(action) {
+	case "reviewed":
+		return auditReviewed
+	case "vouched":
+		return auditVouched
+	case "skipped":
+		return auditSkipped
+	}
+	return auditNone
+}
+
+// applyNoteStatuses gives commits without a trailer the status recorded in git
+// notes: the action of a signed attestation note, or reviewed when the commit has
+// a review note.
+func applyNoteStatuses(commits []auditCommit, reviewNotes, attestationNotes notesIndex) {
+	for i := range commits {
+		c := &commits[i]
+		if c.Status != auditNone {
+			c.Source = signatureStorageTrailer
+			continue
+		}
+		if note := attestationNotes.read(c.Commit); note != "" {
+			if payload, err := decodeSignedAttestation(note); err == nil {
+				if status := auditStatusForAction(payload.Action); status != auditNone {
+					c.Status, c.Source = status, signatureStorageNote
+					c.Iterations, c.Coverage = payload.Iterations, int(payload.PriorAICovPct+0.5)
+					continue
+				}
+			}
+		}
+		if reviewNotes.has(c.Commit) {
+			c.Status, c.Source = auditReviewed, signatureStorageNote
+		}
+	}
+}
+
+// auditPathGroup returns the directory a file is totalled under: its first depth
+// directory components, or "." for files at the repository root.
+func auditPathGroup(file string, depth int) string {
+	dir := path.Dir(file)
+	if dir == "." {
+		return "."
+	}
+	parts := strings.Split(dir, "/")
+	if depth > 0 && len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/") + "/"
+}
+
+// defaultAuditBase picks the branch the current branch is audited against:
+// origin's default branch, then main or master.
+func defaultAuditBase() (string, error) {
+	if out, err := runGitCommand("git", "rev-parse", "--abbrev-ref", "origin/HEAD"); err == nil {
+		if base := strings.TrimSpace(string(out)); base != "" && base != "origin/HEAD" {
+			return base, nil
+		}
+	}
+	for _, candidate := range []string{"origin/main", "origin/master", "main", "master"} {
+		if _, err := runGitCommand("git", "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", invalidInput("cannot determine the base branch; pass --base or a commit range")
+}
+
+// collectAuditCommits reads the non-merge commits of a revision range together
+// with their LiveReview trailers and changed files.
+func collectAuditCommits(revs []string) ([]auditCommit, error) {
+	commits, err := logAuditCommits([]string{"--no-merges"}, revs)
+	if err != nil {
+		return nil, invalidInput("invalid revision range %q", strings.Join(revs, " "))
+	}
+	return commits, nil
+}
+
+// collectRangeCommits reads the commits of a revision range that need a review of
+// their own: every non-merge commit, and the merges that collectMergeChanges finds
+// changing more than git's automatic merge. lrc audit and lrc enforce both use it,
+// so they agree on a range.
+func collectRangeCommits(revs []string) ([]auditCommit, error) {
+	commits, err := collectAuditCommits(revs)
+	if err != nil {
+		return nil, err
+	}
+	merges, err := collectMergeChanges(revs)
+	if err != nil {
+		return nil, err
+	}
+	return append(commits, merges...), nil
+}
+
+// logAuditCommits runs git log with the given selection and diff options over revs
+// and parses each commit's trailers and changed files.
+func logAuditCommits(options, revs []string) ([]auditCommit, error) {
+	args := []string{"-c", "core.quotePath=false", "log", "--name-only",
+		"--format=%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1f%B%x1f"}
+	args = append(args, options...)
+	args = append(args, revs...)
+	args = append(args, "--")
+	out, err := runGitCommand("git", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []auditCommit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		parts := strings.SplitN(record, "\x1f", 7)
+		if len(parts) < 7 {
+			continue
+		}
+		check, _ := parseAttestationTrailers(parts[5])
+		status, iterations, coverage := parseCheckTrailer(check)
+		commit := auditCommit{
+			Commit:     parts[0],
+			Author:     parts[1],
+			Email:      parts[2],
+			Date:       parts[3],
+			Subject:    parts[4],
+			Status:     status,
+			Trailer:    check,
+			Iterations: iterations,
+			Coverage:   coverage,
+			Files:      []string{},
+		}
+		for _, line := range strings.Split(parts[6], "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				commit.Files = append(commit.Files, line)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// buildAuditReport totals the commits per author and path group and checks them
+// against the policy, if any.
+func buildAuditReport(revRange string, commits []auditCommit, depth int, policy *reviewPolicy) auditReport {
+	report := auditReport{Range: revRange, Commits: commits, Violations: []policyViolation{}}
+	if report.Commits == nil {
+		report.Commits = []auditCommit{}
+	}
+	authors := map[string]*auditTotals{}
+	paths := map[string]*auditTotals{}
+	for _, c := range commits {
+		report.Totals.add(c.Status)
+
+		author := fmt.Sprintf("%s <%s>", c.Author, c.Email)
+		if authors[author] == nil {
+			authors[author] = &auditTotals{Key: author}
+		}
+		authors[author].add(c.Status)
+
+		seen := map[string]bool{}
+		for _, f := range c.Files {
+			group := auditPathGroup(f, depth)
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+			if paths[group] == nil {
+				paths[group] = &auditTotals{Key: group}
+			}
+			paths[group].add(c.Status)
+		}
+
+		report.Violations = append(report.Violations, policy.check(c)...)
+	}
+	if policy != nil {
+		report.Policy = policy.Path
+	}
+	report.Authors = sortedAuditTotals(authors)
+	report.Paths = sortedAuditTotals(paths)
+	return report
+}
+
+// sortedAuditTotals orders totals by commit count, then key.
+func sortedAuditTotals(m map[string]*auditTotals) []auditTotals {
+	totals := make([]auditTotals, 0, len(m))
+	for _, t := range m {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Commits != totals[j].Commits {
+			return totals[i].Commits > totals[j].Commits
+		}
+		return totals[i].Key < totals[j].Key
+	})
+	return totals
+}
+
+// runAudit implements `lrc audit [<range>]`.
+func runAudit(c *cli.Context) error {
+	output := c.String("output")
+	switch output {
+	case "table", "json", "csv":
+	default:
+		return reviewExitError(invalidInput("invalid --output %q (must be table, json or csv)", output))
+	}
+	by := c.String("by")
+	switch by {
+	case "commit", "author", "path":
+	default:
+		return reviewExitError(invalidInput("invalid --by %q (must be commit, author or path)", by))
+	}
+	for _, arg := range c.Args().Slice() {
+		if strings.HasPrefix(arg, "-") {
+			return reviewExitError(invalidInput("flags must come before the range: lrc audit [flags] [<range>]"))
+		}
+	}
+
+	revs := c.Args().Slice()
+	if len(revs) == 0 {
+		base := c.String("base")
+		if base == "" {
+			var err error
+			if base, err = defaultAuditBase(); err != nil {
+				return reviewExitError(err)
+			}
+		}
+		revs = []string{base + ".." + c.String("branch")}
+	}
+	commits, err := collectRangeCommits(revs)
+	if err != nil {
+		return reviewExitError(err)
+	}
+
+	policyPath, explicit := c.String("policy"), c.IsSet("policy")
+	if !explicit {
+		if policyPath, err = repoPolicyPath(); err != nil {
+			return err
+		}
+	}
+	policy, err := loadReviewPolicy(policyPath, explicit)
+	if err != nil {
+		return reviewExitError(err)
+	}
+
+	_, notesRef, err := notesSettings(false)
+	if err != nil {
+		return err
+	}
+	applyNoteStatuses(commits, readNotesIndex(notesRef), readNotesIndex(attestationNotesRef(notesRef)))
+	if policy.requiresSignature() {
+		for i := range commits {
+			commits[i].Signature = verifyCommit(commits[i].Commit, notesRef).Status
+		}
+	}
+
+	report := buildAuditReport(strings.Join(revs, " "), commits, c.Int("depth"), policy)
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	case "csv":
+		if err := writeAuditCSV(report, by); err != nil {
+			return err
+		}
+	default:
+		printAuditTable(report)
+	}
+
+	if n := len(report.Violations); n > 0 {
+		return cli.Exit(fmt.Sprintf("%d policy violation(s) in %s", n, report.Policy), 1)
+	}
+	return nil
+}
+
+// writeAuditCSV writes one CSV table: the commits, or the per-author or per-path totals.
+func writeAuditCSV(report auditReport, by string) error {
+	w := csv.NewWriter(os.Stdout)
+	if by == "commit" {
+		w.Write([]string{"commit", "date", "author", "email", "status", "iterations", "coverage", "files", "subject"})
+		for _, c := range report.Commits {
+			w.Write([]string{c.Commit, c.Date, c.Author, c.Email, c.Status,
+				strconv.Itoa(c.Iterations), strconv.Itoa(c.Coverage), strconv.Itoa(len(c.Files)), c.Subject})
+		}
+	} else {
+		totals := report.Authors
+		if by == "path" {
+			totals = report.Paths
+		}
+		w.Write([]string{by, "commits", "reviewed", "vouched", "skipped", "none"})
+		for _, t := range totals {
+			w.Write([]string{t.Key, strconv.Itoa(t.Commits), strconv.Itoa(t.Reviewed),
+				strconv.Itoa(t.Vouched), strconv.Itoa(t.Skipped), strconv.Itoa(t.None)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printAuditTable(report auditReport) {
+	if len(report.Commits) == 0 {
+		fmt.Printf("No commits in %s.\n", report.Range)
+		return
+	}
+	fmt.Printf("%-12s  %-8s  %4s  %4s  %-20s  %s\n", "COMMIT", "STATUS", "ITER", "COV", "AUTHOR", "SUBJECT")
+	for _, c := range report.Commits {
+		iter, cov := "-", "-"
+		if c.Iterations > 0 {
+			iter, cov = strconv.Itoa(c.Iterations), fmt.Sprintf("%d%%", c.Coverage)
+		}
+		fmt.Printf("%-12s  %-8s  %4s  %4s  %-20s  %s\n", shortHash(c.Commit), c.Status, iter, cov, truncateField(c.Author, 20), c.Subject)
+	}
+
+	printTotals := func(title string, totals []auditTotals) {
+		fmt.Printf("\n%-40s  %7s  %8s  %7s  %7s  %4s\n", title, "COMMITS", "REVIEWED", "VOUCHED", "SKIPPED", "NONE")
+		for _, t := range totals {
+			fmt.Printf("%-40s  %7d  %8d  %7d  %7d  %4d\n", truncateField(t.Key, 40), t.Commits, t.Reviewed, t.Vouched, t.Skipped, t.None)
+		}
+	}
+	printTotals("AUTHOR", report.Authors)
+	printTotals("PATH", report.Paths)
+
+	t := report.Totals
+	fmt.Printf("\n%d commit(s): %d reviewed, %d vouched, %d skipped, %d without a trailer\n", t.Commits, t.Reviewed, t.Vouched, t.Skipped, t.None)
+	if len(report.Violations) > 0 {
+		fmt.Printf("\nPolicy violations (%s):\n", report.Policy)
+		for _, v := range report.Violations {
+			fmt.Printf("  %-20s  %-12s  %-8s  %s\n", truncateField(v.Rule, 20), shortHash(v.Commit), v.Status, strings.Join(v.Files, ", "))
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCheckTrailer(t *testing.T) {
+	tests := []struct {
+		value           string
+		status          string
+		iterations, cov int
+	}{
+		{"ran", auditReviewed, 0, 0},
+		{"ran (iter:3, coverage:67%)", auditReviewed, 3, 67},
+		{"ran (iter:2, coverage:40%, chunks:4)", auditReviewed, 2, 40},
+		{"vouched (iter:1, coverage:0%)", auditVouched, 1, 0},
+		{"skipped", auditSkipped, 0, 0},
+		{"skipped manually", auditSkipped, 0, 0},
+		{"", auditNone, 0, 0},
+		{"something else", auditNone, 0, 0},
+	}
+	for _, tt := range tests {
+		status, iterations, cov := parseCheckTrailer(tt.value)
+		if status != tt.status || iterations != tt.iterations || cov != tt.cov {
+			t.Errorf("parseCheckTrailer(%q) = %s, %d, %d; want %s, %d, %d", tt.value, status, iterations, cov, tt.status, tt.iterations, tt.cov)
+		}
+	}
+}
+
+func TestAuditPathGroup(t *testing.T) {
+	tests := []struct {
+		file  string
+		depth int
+		want  string
+	}{
+		{"README.md", 2, "."},
+		{"src/main.go", 2, "src/"},
+		{"src/payments/charge.go", 2, "src/payments/"},
+		{"src/payments/stripe/client.go", 2, "src/payments/"},
+		{"src/payments/stripe/client.go", 1, "src/"},
+		{"src/payments/stripe/client.go", 0, "src/payments/stripe/"},
+	}
+	for _, tt := range tests {
+		if got := auditPathGroup(tt.file, tt.depth); got != tt.want {
+			t.Errorf("auditPathGroup(%q, %d) = %q, want %q", tt.file, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestReviewPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, defaultPolicyFile)
+	policyText := `
+[[rule]]
+name = "payments"
+paths = ["src/payments/"]
+
+[[rule]]
+name = "everything"
+allow = ["reviewed", "vouched", "skipped"]
+`
+	if err := os.WriteFile(policyPath, []byte(policyText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := loadReviewPolicy(policyPath, true)
+	if err != nil {
+		t.Fatalf("loadReviewPolicy: %v", err)
+	}
+	if len(policy.Rules) != 2 || strings.Join(policy.Rules[0].Allow, ",") != auditReviewed {
+		t.Fatalf("unexpected rules: %+v", policy.Rules)
+	}
+
+	tests := []struct {
+		name   string
+		status string
+		files  []string
+		want   []string // violated rules
+	}{
+		{"reviewed payments change", auditReviewed, []string{"src/payments/charge.go"}, nil},
+		{"vouched payments change", auditVouched, []string{"src/payments/charge.go", "README.md"}, []string{"payments"}},
+		{"unreviewed elsewhere", auditSkipped, []string{"src/ui/app.go"}, nil},
+		{"no trailer elsewhere", auditNone, []string{"src/ui/app.go"}, []string{"everything"}},
+		{"no trailer in payments", auditNone, []string{"src/payments/charge.go"}, []string{"payments", "everything"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range policy.check(auditCommit{Commit: "abc", Status: tt.status, Files: tt.files}) {
+			got = append(got, v.Rule)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: violations %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if p, err := loadReviewPolicy(filepath.Join(dir, "missing.toml"), false); p != nil || err != nil {
+		t.Errorf("missing implicit policy = %v, %v; want nil, nil", p, err)
+	}
+	if _, err := loadReviewPolicy(filepath.Join(dir, "missing.toml"), true); err == nil {
+		t.Error("missing explicit policy should be an error")
+	}
+	os.WriteFile(policyPath, []byte("[[rule]]\nallow = [\"approved\"]\n"), 0644)
+	if _, err := loadReviewPolicy(policyPath, true); err == nil {
+		t.Error("unknown status should be rejected")
+	}
+}
+
+func TestAuditRange(t *testing.T) {
+	initTestRepo(t)
+	for _, kv := range [][2]string{{"GIT_AUTHOR_NAME", "Ann"}, {"GIT_AUTHOR_EMAIL", "ann@example.com"}, {"GIT_COMMITTER_NAME", "Ann"}, {"GIT_COMMITTER_EMAIL", "ann@example.com"}} {
+		t.Setenv(kv[0], kv[1])
+	}
+	commit := func(file, message string) {
+		t.Helper()
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+	}
+
+	commit("README.md", "initial")
+	runGit(t, "branch", "base")
+	commit("src/payments/charge.go", "charge\n\n"+preCommitTrailerKey+": ran (iter:2, coverage:50%)")
+	commit("src/payments/refund.go", "refund\n\n"+preCommitTrailerKey+": skipped manually")
+	commit("docs/my file.md", "docs")
+
+	commits, err := collectAuditCommits([]string{"base..HEAD"})
+	if err != nil {
+		t.Fatalf("collectAuditCommits: %v", err)
+	}
+	if len(commits) != 3 {
+		t.Fatalf("got %d commits, want 3", len(commits))
+	}
+	// git log lists newest first
+	if commits[0].Status != auditNone || commits[0].Files[0] != "docs/my file.md" {
+		t.Errorf("unexpected newest commit: %+v", commits[0])
+	}
+	if c := commits[2]; c.Status != auditReviewed || c.Iterations != 2 || c.Coverage != 50 || c.Subject != "charge" {
+		t.Errorf("unexpected reviewed commit: %+v", c)
+	}
+
+	policy := &reviewPolicy{Path: defaultPolicyFile, Rules: []policyRule{{Name: "payments", Paths: []string{"src/payments/"}, Allow: []string{auditReviewed}}}}
+	report := buildAuditReport("base..HEAD", commits, defaultAuditDepth, policy)
+	if tot := report.Totals; tot.Commits != 3 || tot.Reviewed != 1 || tot.Skipped != 1 || tot.None != 1 {
+		t.Errorf("unexpected totals: %+v", tot)
+	}
+	if len(report.Authors) != 1 || report.Authors[0].Key != "Ann <ann@example.com>" || report.Authors[0].Commits != 3 {
+		t.Errorf("unexpected author totals: %+v", report.Authors)
+	}
+	if len(report.Paths) != 2 || report.Paths[0].Key != "src/payments/" || report.Paths[0].Commits != 2 {
+		t.Errorf("unexpected path totals: %+v", report.Paths)
+	}
+	if len(report.Violations) != 1 || report.Violations[0].Commit != commits[1].Commit || report.Violations[0].Status != auditSkipped {
+		t.Errorf("unexpected violations: %+v", report.Violations)
+	}
+
+	if _, err := collectAuditCommits([]string{"nosuchbranch..HEAD"}); err == nil {
+		t.Error("invalid range should be an error")
+	}
+}
+
+func TestAuditRangeMerges(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, message string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message+"\n\n"+preCommitTrailerKey+": ran (iter:1, coverage:0%)")
+	}
+	commit("README.md", "initial")
+	runGit(t, "branch", "base")
+	runGit(t, "checkout", "-q", "-b", "side")
+	commit("a.txt", "side")
+	runGit(t, "checkout", "-q", "-")
+	commit("b.txt", "main")
+
+	// A clean merge is left out; a merge that adds code of its own is audited
+	runGit(t, "merge", "-q", "--no-ff", "--no-edit", "side")
+	runGit(t, "checkout", "-q", "side")
+	commit("c.txt", "more side")
+	runGit(t, "checkout", "-q", "-")
+	runGit(t, "merge", "-q", "--no-ff", "--no-commit", "side")
+	os.WriteFile("evil.txt", []byte("evil\n"), 0644)
+	runGit(t, "add", "evil.txt")
+	runGit(t, "commit", "-q", "--no-edit")
+	evil := runGit(t, "rev-parse", "HEAD")
+
+	commits, err := collectRangeCommits([]string{"base..HEAD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 4 {
+		t.Fatalf("got %d commits, want the 3 non-merge commits and the evil merge", len(commits))
+	}
+	if m := commits[3]; m.Commit != evil || m.Status != auditNone || strings.Join(m.Files, ",") != "evil.txt" {
+		t.Errorf("unexpected merge: %+v", m)
+	}
+	report := buildAuditReport("base..HEAD", commits, defaultAuditDepth, nil)
+	if tot := report.Totals; tot.Commits != 4 || tot.Reviewed != 3 || tot.None != 1 {
+		t.Errorf("unexpected totals: %+v", tot)
+	}
+}
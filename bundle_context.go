@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Limits for --context-files; each can be overridden via flag or config.
+const (
+	defaultContextMaxFileBytes = 256 * 1024
+	defaultContextMaxFiles     = 50
+	bundleManifestName         = "manifest.json"
+	bundleContextDir           = "files/"
+	bundleFormatVersion        = 1
+)
+
+// bundleManifest describes a context-enriched bundle. It is stored as manifest.json in the zip.
+type bundleManifest struct {
+	FormatVersion int                   `json:"format_version"`
+	RepoName      string                `json:"repo_name"`
+	Branch        string                `json:"branch,omitempty"`
+	HeadSHA       string                `json:"head_sha,omitempty"`
+	DiffSource    string                `json:"diff_source"`
+	Revision      string                `json:"revision,omitempty"`
+	LrcVersion    string                `json:"lrc_version"`
+	GeneratedAt   string                `json:"generated_at"`
+	Files         []bundleManifestEntry `json:"files"`
+	Skipped       []bundleManifestEntry `json:"skipped,omitempty"`
+}
+
+// bundleManifestEntry is one post-image file that was included in the bundle or skipped.
+type bundleManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int    `json:"size,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// bundleContextFile is the full new-side content of a changed file.
+type bundleContextFile struct {
+	Path    string
+	Content []byte
+}
+
+// bundleContext is the extra payload shipped next to diff.txt when --context-files is set.
+type bundleContext struct {
+	Manifest bundleManifest
+	Files    []bundleContextFile
+}
+
+// postImageSource reads the new side of a changed file for the current diff source.
+type postImageSource func(path string) ([]byte, error)
+
+// postImageSourceFor returns where post-images are read from, and the revision it
+// reads from for the manifest. It returns nil for diff sources without a new side.
+func postImageSourceFor(opts reviewOptions) (postImageSource, string) {
+	gitShow := func(rev string) postImageSource {
+		return func(path string) ([]byte, error) {
+			return runGitCommand("git", "show", rev+":"+path)
+		}
+	}
+
+	switch opts.diffSource {
+	case "staged":
+		return gitShow(""), "index"
+	case "working":
+		return func(path string) ([]byte, error) {
+			root, err := runGitCommand("git", "rev-parse", "--show-toplevel")
+			if err != nil {
+				return nil, err
+			}
+			return os.ReadFile(filepath.Join(strings.TrimSpace(string(root)), path))
+		}, "working tree"
+	case "commit":
+		rev := rangeNewSide(opts.commitVal)
+		return gitShow(rev), rev
+	case "range":
+		rev := rangeNewSide(opts.rangeVal)
+		return gitShow(rev), rev
+	default:
+		return nil, ""
+	}
+}
+
+// rangeNewSide returns the right-hand revision of "a..b" or "a...b", or the input itself.
+func rangeNewSide(rev string) string {
+	if idx := strings.LastIndex(rev, ".."); idx != -1 {
+		rev = strings.TrimPrefix(rev[idx+2:], ".")
+		if rev == "" {
+			return "HEAD"
+		}
+	}
+	return rev
+}
+
+// collectBundleContext reads the post-image of every file in the diff, subject to the
+// per-file size cap and the total file count cap.
+func collectBundleContext(opts reviewOptions, repoName string, diffContent []byte) (*bundleContext, error) {
+	ctx := &bundleContext{
+		Manifest: bundleManifest{
+			FormatVersion: bundleFormatVersion,
+			RepoName:      repoName,
+			DiffSource:    opts.diffSource,
+			LrcVersion:    version,
+			GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+			Files:         []bundleManifestEntry{},
+		},
+	}
+	if out, err := runGitCommand("git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		ctx.Manifest.Branch = strings.TrimSpace(string(out))
+	}
+	if out, err := runGitCommand("git", "rev-parse", "HEAD"); err == nil {
+		ctx.Manifest.HeadSHA = strings.TrimSpace(string(out))
+	}
+
+	readPostImage, revision := postImageSourceFor(opts)
+	ctx.Manifest.Revision = revision
+	if readPostImage == nil {
+		if opts.verbose {
+			log.Printf("Context files unavailable for diff source %q; bundling manifest only", opts.diffSource)
+		}
+		return ctx, nil
+	}
+
+	skip := func(path, reason string) {
+		ctx.Manifest.Skipped = append(ctx.Manifest.Skipped, bundleManifestEntry{Path: path, Reason: reason})
+		if opts.verbose {
+			log.Printf("Context file skipped: %s (%s)", path, reason)
+		}
+	}
+
+	for _, section := range splitDiffSections(diffContent) {
+		path := section.FilePath
+		switch {
+		case path == "":
+			continue
+		case bytes.Contains(section.Content, []byte("\n+++ /dev/null")):
+			continue // deleted; there is no post-image
+		case isBinaryDiffSection(section.Content):
+			skip(path, "binary")
+			continue
+		case len(ctx.Files) >= opts.contextMaxFiles:
+			skip(path, fmt.Sprintf("file count limit %d reached", opts.contextMaxFiles))
+			continue
+		}
+
+		content, err := readPostImage(path)
+		if err != nil {
+			skip(path, "could not read post-image")
+			continue
+		}
+		if len(content) > opts.contextMaxFileBytes {
+			skip(path, fmt.Sprintf("%d bytes exceeds limit %d", len(content), opts.contextMaxFileBytes))
+			continue
+		}
+
+		ctx.Files = append(ctx.Files, bundleContextFile{Path: path, Content: content})
+		ctx.Manifest.Files = append(ctx.Manifest.Files, bundleManifestEntry{Path: path, Size: len(content)})
+	}
+
+	if opts.verbose {
+		log.Printf("Context files: %d included, %d skipped", len(ctx.Files), len(ctx.Manifest.Skipped))
+	}
+	return ctx, nil
+}
+
+// forFiles returns a copy of the context restricted to paths, for chunked submissions.
+func (ctx *bundleContext) forFiles(paths []string) *bundleContext {
+	if ctx == nil {
+		return nil
+	}
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+
+	sub := &bundleContext{Manifest: ctx.Manifest}
+	sub.Manifest.Files = []bundleManifestEntry{}
+	sub.Manifest.Skipped = nil
+	for _, f := range ctx.Files {
+		if want[f.Path] {
+			sub.Files = append(sub.Files, f)
+			sub.Manifest.Files = append(sub.Manifest.Files, bundleManifestEntry{Path: f.Path, Size: len(f.Content)})
+		}
+	}
+	for _, s := range ctx.Manifest.Skipped {
+		if want[s.Path] {
+			sub.Manifest.Skipped = append(sub.Manifest.Skipped, s)
+		}
+	}
+	return sub
+}
+
+// entryNames lists the zip entries the context adds next to diff.txt.
+func (ctx *bundleContext) entryNames() []string {
+	if ctx == nil {
+		return nil
+	}
+	names := []string{bundleManifestName}
+	for _, f := range ctx.Files {
+		names = append(names, bundleContextDir+f.Path)
+	}
+	return names
+}
+
+// writeTo adds manifest.json and files/<path> entries to the zip.
+func (ctx *bundleContext) writeTo(zipWriter *zip.Writer) error {
+	manifest, err := json.MarshalIndent(ctx.Manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+	w, err := zipWriter.Create(bundleManifestName)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry %s: %w", bundleManifestName, err)
+	}
+	if _, err := w.Write(manifest); err != nil {
+		return fmt.Errorf("failed to write %s to zip: %w", bundleManifestName, err)
+	}
+
+	for _, f := range ctx.Files {
+		name := bundleContextDir + f.Path
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create zip entry %s: %w", name, err)
+		}
+		if _, err := w.Write(f.Content); err != nil {
+			return fmt.Errorf("failed to write %s to zip: %w", name, err)
+		}
+	}
+	return nil
+}
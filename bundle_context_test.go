@@ -0,0 +1,73 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestRangeNewSide(t *testing.T) {
+	tests := map[string]string{
+		"abc123":          "abc123",
+		"HEAD~3..HEAD":    "HEAD",
+		"main...feature":  "feature",
+		"origin/main..":   "HEAD",
+		"v1.0..v1.1":      "v1.1",
+		"HEAD~1..HEAD~0":  "HEAD~0",
+		"refs/tags/x..yy": "yy",
+	}
+	for in, want := range tests {
+		if got := rangeNewSide(in); got != want {
+			t.Errorf("rangeNewSide(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCreateZipArchiveWithContext(t *testing.T) {
+	ctx := &bundleContext{
+		Manifest: bundleManifest{FormatVersion: bundleFormatVersion, RepoName: "demo", DiffSource: "staged"},
+		Files: []bundleContextFile{
+			{Path: "a.go", Content: []byte("package a\n")},
+			{Path: "pkg/b.go", Content: []byte("package b\n")},
+		},
+	}
+
+	zipData, err := createZipArchive([]byte("diff --git a/a.go b/a.go\n"), ctx.forFiles([]string{"pkg/b.go"}))
+	if err != nil {
+		t.Fatalf("createZipArchive: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+
+	entries := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = data
+	}
+
+	for _, name := range []string{"diff.txt", "manifest.json", "files/pkg/b.go"} {
+		if _, ok := entries[name]; !ok {
+			t.Errorf("missing zip entry %s", name)
+		}
+	}
+	if _, ok := entries["files/a.go"]; ok {
+		t.Errorf("files/a.go should not be in a bundle restricted to pkg/b.go")
+	}
+
+	var manifest bundleManifest
+	if err := json.Unmarshal(entries["manifest.json"], &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if manifest.RepoName != "demo" || len(manifest.Files) != 1 || manifest.Files[0].Path != "pkg/b.go" {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+}
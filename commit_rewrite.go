@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Merges, amends and replayed commits (rebase, cherry-pick) are reviewed for what
+// they add themselves: a merge for its conflict resolution, an amend for what it
+// changes on top of the commit it replaces, and a replayed commit not at all when
+// its patch is unchanged.
+
+// errNoMergeInProgress means there is no MERGE_HEAD, so no merge is being concluded.
+var errNoMergeInProgress = errors.New("no merge in progress")
+
+// autoMergeTree returns the tree of git's automatic merge of MERGE_HEAD into HEAD,
+// conflict markers included. The difference between that tree and the index is the
+// conflict resolution. Octopus merges have no single automatic result.
+func autoMergeTree() (string, error) {
+	gitDir, err := resolveGitDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "MERGE_HEAD"))
+	if err != nil {
+		return "", errNoMergeInProgress
+	}
+	heads := strings.Fields(string(data))
+	if len(heads) != 1 {
+		return "", fmt.Errorf("octopus merge of %d heads has no single automatic result", len(heads))
+	}
+	out, err := exec.Command("git", "merge-tree", "--write-tree", "--no-messages", "HEAD", heads[0]).Output()
+	// Exit status 1 means the merge has conflicts; the tree is written all the same
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", fmt.Errorf("git merge-tree failed (needs git 2.38 or later): %w", err)
+	}
+	tree := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	if tree == "" {
+		return "", fmt.Errorf("git merge-tree returned no tree")
+	}
+	return tree, nil
+}
+
+// collectMergeResolution returns the staged conflict resolution of a merge in
+// progress, or errNoMergeInProgress.
+func collectMergeResolution(verbose bool) ([]byte, error) {
+	tree, err := autoMergeTree()
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		log.Printf("Merge in progress: collecting the conflict resolution against the automatic merge result %s", shortHash(tree))
+	}
+	return runGitCommand("git", "diff", "--staged", tree)
+}
+
+// runAutoMergeTree prints the automatic merge tree; the hooks use it to let merges
+// without a conflict resolution through.
+func runAutoMergeTree(c *cli.Context) error {
+	tree, err := autoMergeTree()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	fmt.Println(tree)
+	return nil
+}
+
+// amendBase is the commit an amend replaces and the attestation it was made with.
+type amendBase struct {
+	Commit string              // HEAD before the amend
+	Parent string              // its first parent, or the empty tree for a root commit
+	Prev   *attestationPayload // nil when HEAD has no LiveReview attestation
+	filter diffPathFilter
+}
+
+// loadAmendBase reads HEAD and its attestation ahead of an amend.
+func loadAmendBase(filter diffPathFilter) (*amendBase, error) {
+	head, err := runGitCommand("git", "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	if err != nil {
+		return nil, invalidInput("--amend needs a commit to amend")
+	}
+	base := &amendBase{Commit: strings.TrimSpace(string(head)), Parent: emptyTreeHash, filter: filter}
+	if parent, err := runGitCommand("git", "rev-parse", "--verify", "--quiet", base.Commit+"^1"); err == nil {
+		base.Parent = strings.TrimSpace(string(parent))
+	}
+	base.Prev = commitAttestation(base.Commit)
+	return base, nil
+}
+
+// commitAttestation recovers the attestation a commit was made with: the signed
+// attestation from its trailer or attestation note, else the action and numbers on
+// its LiveReview trailer. It returns nil for commits with neither.
+func commitAttestation(commit string) *attestationPayload {
+	message, err := runGitCommand("git", "log", "-1", "--format=%B", commit)
+	if err != nil {
+		return nil
+	}
+	check, encoded := parseAttestationTrailers(string(message))
+	if encoded == "" {
+		if _, ref, err := notesSettings(false); err == nil {
+			if note, err := runGitCommand("git", "notes", "--ref", attestationNotesRef(ref), "show", commit); err == nil {
+				encoded = string(note)
+			}
+		}
+	}
+	if encoded != "" {
+		if payload, err := decodeSignedAttestation(encoded); err == nil {
+			return payload
+		}
+	}
+	// Audit statuses are named after the attestation actions
+	status, iterations, coverage := parseCheckTrailer(check)
+	if status == auditNone {
+		return nil
+	}
+	return &attestationPayload{Action: status, Iterations: iterations, PriorAICovPct: float64(coverage)}
+}
+
+// changesCode reports whether the index differs from the commit being amended.
+func (a *amendBase) changesCode() bool {
+	_, err := runGitCommand("git", "diff", "--cached", "--quiet", a.Commit)
+	return err != nil
+}
+
+// keepAttestation records HEAD's attestation again for an amend that changes no
+// code, such as a reworded message. The tree is unchanged, so a signature stays valid.
+func (a *amendBase) keepAttestation(verbose bool, written *bool) error {
+	path, err := writeAttestationFullForCurrentTree(*a.Prev)
+	if err != nil {
+		return fmt.Errorf("failed to write attestation: %w", err)
+	}
+	*written = true
+	if verbose {
+		log.Printf("Attestation written: %s (kept from %s)", path, shortHash(a.Commit))
+	}
+	fmt.Printf("LiveReview: amend changes no code; keeping the attestation of %s (%s)\n", shortHash(a.Commit), formatAttestationTrailer(*a.Prev))
+	return nil
+}
+
+// carry folds the replaced commit's attestation into one computed for the amend's
+// delta: iterations add up, and lines the amend kept keep their coverage.
+// Attestations without coverage numbers, and any payload when a is nil, are left alone.
+func (a *amendBase) carry(p attestationPayload) attestationPayload {
+	if a == nil || p.Iterations == 0 {
+		return p
+	}
+	full, err := a.stagedEntries(a.Parent)
+	if err == nil {
+		var delta []attestationFileEntry
+		if delta, err = a.stagedEntries(a.Commit); err == nil {
+			var prev attestationPayload
+			if a.Prev != nil {
+				prev = *a.Prev
+			}
+			p.PriorAICovPct = amendCoverage(prev, full, delta, p.PriorAICovPct)
+			p.Iterations += prev.Iterations
+			p.PriorReviewCount += prev.PriorReviewCount
+			if prev.Action == "reviewed" {
+				p.PriorReviewCount++
+			}
+			return p
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: could not carry over the coverage of %s: %v\n", shortHash(a.Commit), err)
+	return p
+}
+
+// stagedEntries returns the line ranges of the staged changes against base, after
+// the review's path filters.
+func (a *amendBase) stagedEntries(base string) ([]attestationFileEntry, error) {
+	// No context lines: only changed lines count as kept or amended
+	diff, err := runGitCommand("git", "diff", "--cached", "-U0", base)
+	if err != nil {
+		return nil, err
+	}
+	filtered, _ := filterDiff(diff, a.filter)
+	if len(bytes.TrimSpace(filtered)) == 0 {
+		return nil, nil
+	}
+	files, err := parseDiffToFiles(filtered)
+	if err != nil {
+		return nil, err
+	}
+	return filesToEntries(files), nil
+}
+
+// amendCoverage returns the prior AI coverage of an amended commit. full is the
+// amended commit's diff against its parent and delta the amend's own changes,
+// covered deltaPct percent by earlier iterations of the amend. Lines of full outside
+// delta were kept from the replaced commit: all covered if it was reviewed, its
+// coverage share otherwise.
+func amendCoverage(prev attestationPayload, full, delta []attestationFileEntry, deltaPct float64) float64 {
+	total := countTotalNewLines(full)
+	if total == 0 {
+		return deltaPct
+	}
+	changed := make(map[string][]lineRange)
+	for _, f := range delta {
+		for _, h := range f.Hunks {
+			changed[f.FilePath] = append(changed[f.FilePath], lineRange{Start: h.NewStartLine, End: h.NewStartLine + h.NewLineCount - 1})
+		}
+	}
+	kept := 0
+	for _, f := range full {
+		for _, h := range f.Hunks {
+			for line := h.NewStartLine; line < h.NewStartLine+h.NewLineCount; line++ {
+				if !lineInRanges(line, changed[f.FilePath]) {
+					kept++
+				}
+			}
+		}
+	}
+
+	share := prev.PriorAICovPct / 100
+	if prev.Action == "reviewed" {
+		share = 1
+	}
+	covered := float64(kept)*share + float64(total-kept)*deltaPct/100
+	return min(covered/float64(total)*100, 100)
+}
+
+// rebaseReattestEnabled reads the rebase_reattest config key.
+func rebaseReattestEnabled() (bool, error) {
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		return false, err
+	}
+	raw, origin := cfg.Effective("rebase_reattest")
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid rebase_reattest %q in %s: %w", raw, origin, err)
+	}
+	return enabled, nil
+}
+
+// replayedCommit returns the commit a rebase or cherry-pick is replaying, or "".
+func replayedCommit() string {
+	gitDir, err := resolveGitDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"CHERRY_PICK_HEAD", "REBASE_HEAD"} {
+		if data, err := os.ReadFile(filepath.Join(gitDir, name)); err == nil {
+			if commit := strings.TrimSpace(string(data)); commit != "" {
+				return commit
+			}
+		}
+	}
+	return ""
+}
+
+// patchID returns git's verbatim patch ID of a diff, or "" for an empty diff. It
+// ignores line numbers but, unlike --stable, not whitespace: a re-indented change
+// is a different patch that nobody reviewed.
+func patchID(diff []byte) (string, error) {
+	cmd := exec.Command("git", "patch-id", "--verbatim")
+	cmd.Stdin = bytes.NewReader(diff)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git patch-id failed: %w", err)
+	}
+	if fields := strings.Fields(string(out)); len(fields) > 0 {
+		return fields[0], nil
+	}
+	return "", nil
+}
+
+// stagedPatchMatches reports whether the staged changes are the same patch as commit,
+// whitespace included, so a replay re-indented while resolving a conflict does not match.
+func stagedPatchMatches(commit string) (bool, error) {
+	original, err := runGitCommand("git", "show", "--format=", commit)
+	if err != nil {
+		return false, err
+	}
+	staged, err := runGitCommand("git", "diff", "--cached", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	want, err := patchID(original)
+	if err != nil {
+		return false, err
+	}
+	got, err := patchID(staged)
+	if err != nil {
+		return false, err
+	}
+	return want != "" && want == got, nil
+}
+
+// stripAttestationTrailers removes the LiveReview trailers from a commit message.
+func stripAttestationTrailers(message string) string {
+	var kept []string
+	for _, line := range strings.Split(message, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, preCommitTrailerKey+":") || strings.HasPrefix(trimmed, attestationTrailerKey+":") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n"
+}
+
+// runRebaseAttest implements `lrc rebase-attest <message-file>`, called by the
+// prepare-commit-msg hook for each commit a rebase or cherry-pick replays. It does
+// nothing unless rebase_reattest is on. A commit whose patch is unchanged then keeps
+// its attestation, re-signed for the new tree when it was signed; a commit whose
+// patch changed loses its LiveReview trailers, since its new version was never reviewed.
+func runRebaseAttest(c *cli.Context) error {
+	msgFile := c.Args().First()
+	if msgFile == "" {
+		return reviewExitError(invalidInput("usage: lrc rebase-attest <commit-message-file>"))
+	}
+	if enabled, err := rebaseReattestEnabled(); err != nil || !enabled {
+		return err
+	}
+	original := replayedCommit()
+	if original == "" {
+		return nil
+	}
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+	message := string(data)
+	if check, _ := parseAttestationTrailers(message); check == "" {
+		return nil
+	}
+
+	same, err := stagedPatchMatches(original)
+	if err != nil {
+		return err
+	}
+	if !same {
+		fmt.Fprintf(os.Stderr, "LiveReview: %s changed while being replayed; dropping its attestation\n", shortHash(original))
+		return os.WriteFile(msgFile, []byte(stripAttestationTrailers(message)), 0644)
+	}
+
+	payload := commitAttestation(original)
+	if payload == nil || payload.Signature == nil {
+		// An unsigned trailer names no tree and stays valid as it is
+		fmt.Fprintf(os.Stderr, "LiveReview: %s replayed unchanged; keeping its attestation\n", shortHash(original))
+		return nil
+	}
+	sign, storage, err := signingSettings()
+	if err != nil {
+		return err
+	}
+	trailers := preCommitTrailerKey + ": " + formatAttestationTrailer(*payload)
+	if !sign {
+		// The old signature names the old tree; without a key to sign the new one, keep the unsigned trailer
+		fmt.Fprintf(os.Stderr, "LiveReview: %s replayed unchanged; sign_attestations is off, so its attestation is kept unsigned\n", shortHash(original))
+		return os.WriteFile(msgFile, []byte(stripAttestationTrailers(message)+"\n"+trailers+"\n"), 0644)
+	}
+
+	// Sign for the new tree; with note storage the post-commit hook stores the note
+	if _, err := writeAttestationFullForCurrentTree(*payload); err != nil {
+		return fmt.Errorf("failed to write attestation: %w", err)
+	}
+	resigned, err := readCurrentAttestation()
+	if err != nil || resigned == nil || resigned.Signature == nil {
+		fmt.Fprintf(os.Stderr, "LiveReview: %s replayed unchanged, but its attestation could not be re-signed; keeping it unsigned\n", shortHash(original))
+		return os.WriteFile(msgFile, []byte(stripAttestationTrailers(message)+"\n"+trailers+"\n"), 0644)
+	}
+	if storage == signatureStorageTrailer {
+		encoded, err := encodeSignedAttestation(*resigned)
+		if err != nil {
+			return err
+		}
+		trailers += "\n" + attestationTrailerKey + ": " + encoded
+	}
+	fmt.Fprintf(os.Stderr, "LiveReview: %s replayed unchanged; attestation re-signed for the new tree\n", shortHash(original))
+	return os.WriteFile(msgFile, []byte(stripAttestationTrailers(message)+"\n"+trailers+"\n"), 0644)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestAmendCoverage(t *testing.T) {
+	hunk := func(start, count int) attestationHunkRange {
+		return attestationHunkRange{NewStartLine: start, NewLineCount: count}
+	}
+	// The amended commit adds lines 1-10 of a.go; the amend itself rewrote lines 9-10
+	full := []attestationFileEntry{{FilePath: "a.go", Hunks: []attestationHunkRange{hunk(1, 10)}}}
+	delta := []attestationFileEntry{{FilePath: "a.go", Hunks: []attestationHunkRange{hunk(9, 2)}}}
+
+	tests := []struct {
+		name     string
+		prev     attestationPayload
+		full     []attestationFileEntry
+		delta    []attestationFileEntry
+		deltaPct float64
+		want     float64
+	}{
+		{"reviewed commit covers the kept lines", attestationPayload{Action: "reviewed"}, full, delta, 0, 80},
+		{"vouched commit passes on its coverage", attestationPayload{Action: "vouched", PriorAICovPct: 50}, full, delta, 0, 40},
+		{"earlier amend iterations cover the delta", attestationPayload{Action: "reviewed"}, full, delta, 50, 90},
+		{"unattested commit covers nothing", attestationPayload{}, full, delta, 100, 20},
+		{"other files are not the delta", attestationPayload{Action: "reviewed"}, full, []attestationFileEntry{{FilePath: "b.go", Hunks: []attestationHunkRange{hunk(1, 10)}}}, 0, 100},
+		{"empty commit keeps the delta coverage", attestationPayload{Action: "reviewed"}, nil, delta, 30, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := amendCoverage(tt.prev, tt.full, tt.delta, tt.deltaPct); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("amendCoverage = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVouchedAmendCarriesAttestation(t *testing.T) {
+	initTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	os.WriteFile("a.txt", []byte("one\n"), 0644)
+	runGit(t, "add", "a.txt")
+	runGit(t, "commit", "-q", "-m", "add a\n\n"+preCommitTrailerKey+": vouched (iter:3, coverage:40%)")
+
+	os.WriteFile("b.txt", []byte("two\n"), 0644)
+	runGit(t, "add", "b.txt")
+	opts := reviewOptions{diffSource: "staged", vouch: true, amend: true, pathFilter: buildPathFilter(nil, nil, nil, false)}
+	if err := runReviewWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := readCurrentAttestation()
+	if err != nil || payload == nil || payload.Action != "vouched" || payload.Iterations != 4 {
+		t.Fatalf("amend attestation = %+v, %v; want the 3 earlier iterations carried over", payload, err)
+	}
+
+	// Without --amend nothing carries over
+	if err := deleteAttestationForCurrentTree(); err != nil {
+		t.Fatal(err)
+	}
+	opts.amend = false
+	if err := runReviewWithOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if payload, _ := readCurrentAttestation(); payload == nil || payload.Iterations == 4 {
+		t.Errorf("plain vouch attestation = %+v", payload)
+	}
+}
+
+func TestStripAttestationTrailers(t *testing.T) {
+	message := "Fix parser\n\nBody text.\n\n" + preCommitTrailerKey + ": ran (iter:1, coverage:0%)\n" + attestationTrailerKey + ": abc\n\n"
+	if got, want := stripAttestationTrailers(message), "Fix parser\n\nBody text.\n"; got != want {
+		t.Errorf("stripAttestationTrailers = %q, want %q", got, want)
+	}
+}
+
+func TestAutoMergeTree(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, content, message string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+	}
+
+	commit("a.txt", "one\n", "base")
+	base := runGit(t, "symbolic-ref", "--short", "HEAD")
+	runGit(t, "checkout", "-q", "-b", "feature")
+	commit("a.txt", "feature\n", "feature change")
+	commit("b.txt", "from feature\n", "feature file")
+	runGit(t, "checkout", "-q", base)
+	commit("a.txt", "main\n", "main change")
+
+	if _, err := autoMergeTree(); !errors.Is(err, errNoMergeInProgress) {
+		t.Fatalf("autoMergeTree without a merge = %v, want errNoMergeInProgress", err)
+	}
+	if err := exec.Command("git", "merge", "-q", "feature").Run(); err == nil {
+		t.Fatal("merge should stop on the conflict in a.txt")
+	}
+	os.WriteFile("a.txt", []byte("main and feature\n"), 0644)
+	runGit(t, "add", "a.txt")
+
+	diff, err := collectMergeResolution(false)
+	if err != nil {
+		t.Fatalf("collectMergeResolution: %v", err)
+	}
+	// b.txt merged cleanly and is not part of the resolution
+	if !strings.Contains(string(diff), "+main and feature") || strings.Contains(string(diff), "b.txt") {
+		t.Errorf("resolution diff should only cover a.txt:\n%s", diff)
+	}
+}
+
+func TestReplayedPatch(t *testing.T) {
+	initTestRepo(t)
+	os.WriteFile("a.txt", []byte("one\n"), 0644)
+	runGit(t, "add", "a.txt")
+	runGit(t, "commit", "-q", "-m", "base")
+	base := runGit(t, "rev-parse", "HEAD")
+	os.WriteFile("b.txt", []byte("reviewed\n"), 0644)
+	runGit(t, "add", "b.txt")
+	runGit(t, "commit", "-q", "-m", "add b\n\n"+preCommitTrailerKey+": vouched (iter:3, coverage:40%)")
+	reviewed := runGit(t, "rev-parse", "HEAD")
+
+	payload := commitAttestation(reviewed)
+	if payload == nil || payload.Action != "vouched" || payload.Iterations != 3 || payload.PriorAICovPct != 40 {
+		t.Fatalf("commitAttestation = %+v", payload)
+	}
+	if commitAttestation(base) != nil {
+		t.Error("a commit without a trailer has no attestation")
+	}
+
+	// Replay the commit onto a different base
+	runGit(t, "checkout", "-q", "--detach", base)
+	os.WriteFile("c.txt", []byte("elsewhere\n"), 0644)
+	runGit(t, "add", "c.txt")
+	runGit(t, "commit", "-q", "-m", "other base")
+	runGit(t, "cherry-pick", "-n", reviewed)
+	if same, err := stagedPatchMatches(reviewed); err != nil || !same {
+		t.Errorf("unchanged patch: stagedPatchMatches = %v, %v", same, err)
+	}
+	os.WriteFile("b.txt", []byte("  reviewed\n"), 0644)
+	runGit(t, "add", "b.txt")
+	if same, _ := stagedPatchMatches(reviewed); same {
+		t.Error("re-indented patch should not match")
+	}
+	os.WriteFile("b.txt", []byte("changed while replaying\n"), 0644)
+	runGit(t, "add", "b.txt")
+	if same, _ := stagedPatchMatches(reviewed); same {
+		t.Error("changed patch should not match")
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"github.com/urfave/cli/v2"
+)
+
+// Config file layers, from lowest to highest precedence:
+//
+//  1. ~/.lrc.toml                 (user-wide defaults, usually holds api_key)
+//  2. <repo root>/.lrc.toml       (tracked, shared with the team; api_key and api_url are ignored here)
+//  3. <git dir>/lrc/config.toml   (untracked, per-clone overrides)
+//
+// Environment variables and CLI flags override every file layer.
+const (
+	configFileName        = ".lrc.toml"
+	repoLocalConfigFile   = "config.toml"
+	configOriginDefault   = "default"
+	configOriginEnvPrefix = "env "
+)
+
+// configKeySpec describes a config key, the env var that overrides it and its built-in default.
+type configKeySpec struct {
+	Key     string
+	EnvVar  string
+	Default string
+	Secret  bool
+}
+
+// configKeys lists every key understood in .lrc.toml files, in display order.
+var configKeys = []configKeySpec{
+	{Key: "api_key", EnvVar: "LRC_API_KEY", Secret: true},
+	{Key: "api_url", EnvVar: "LRC_API_URL", Default: defaultAPIURL},
+	{Key: "diff_source", EnvVar: "LRC_DIFF_SOURCE", Default: "staged"},
+	{Key: "poll_interval", EnvVar: "LRC_POLL_INTERVAL", Default: defaultPollInterval.String()},
+	{Key: "timeout", EnvVar: "LRC_TIMEOUT", Default: defaultTimeout.String()},
+	{Key: "output", EnvVar: "LRC_OUTPUT", Default: defaultOutputFormat},
+	{Key: "port", EnvVar: "LRC_PORT", Default: "8000"},
+	{Key: "serve", EnvVar: "LRC_SERVE", Default: "false"},
+	{Key: "ui", EnvVar: "LRC_UI", Default: reviewUIWeb},
+	{Key: "include", EnvVar: "LRC_INCLUDE"},
+	{Key: "exclude", EnvVar: "LRC_EXCLUDE"},
+	{Key: "default_excludes", Default: "true"},
+	{Key: "chunk", EnvVar: "LRC_CHUNK", Default: "false"},
+	{Key: "chunk_bytes", EnvVar: "LRC_CHUNK_BYTES", Default: strconv.Itoa(defaultChunkBytes)},
+	{Key: "context_files", EnvVar: "LRC_CONTEXT_FILES", Default: "false"},
+	{Key: "review_submodules", EnvVar: "LRC_REVIEW_SUBMODULES", Default: "false"},
+	{Key: "context_max_file_bytes", EnvVar: "LRC_CONTEXT_MAX_FILE_BYTES", Default: strconv.Itoa(defaultContextMaxFileBytes)},
+	{Key: "context_max_files", EnvVar: "LRC_CONTEXT_MAX_FILES", Default: strconv.Itoa(defaultContextMaxFiles)},
+	{Key: "api_retries", EnvVar: "LRC_API_RETRIES", Default: strconv.Itoa(defaultAPIRetries)},
+	{Key: "notes", EnvVar: "LRC_NOTES", Default: "false"},
+	{Key: "notes_ref", EnvVar: "LRC_NOTES_REF", Default: defaultNotesRef},
+	{Key: "sign_attestations", EnvVar: "LRC_SIGN_ATTESTATIONS", Default: "false"},
+	{Key: "signature_storage", EnvVar: "LRC_SIGNATURE_STORAGE", Default: signatureStorageTrailer},
+	{Key: "pre_push_fail_on", EnvVar: "LRC_PRE_PUSH_FAIL_ON", Default: defaultPrePushFailOn},
+	{Key: "rebase_reattest", EnvVar: "LRC_REBASE_REATTEST", Default: "false"},
+	{Key: "db_max_age", EnvVar: "LRC_DB_MAX_AGE", Default: defaultDBMaxAge},
+	{Key: "db_max_sessions", EnvVar: "LRC_DB_MAX_SESSIONS", Default: strconv.Itoa(defaultDBMaxSessions)},
+	{Key: "db_max_reviews", EnvVar: "LRC_DB_MAX_REVIEWS", Default: strconv.Itoa(defaultDBMaxReviews)},
+}
+
+// repoIgnoredKeys are dropped from the tracked <repo root>/.lrc.toml layer. The API key
+// and the URL it is sent to must both come from files the user controls.
+var repoIgnoredKeys = []string{"api_key", "api_url"}
+
+// configLayer is a single config file that was found and parsed.
+type configLayer struct {
+	Name string // "home", "repo" or "git-dir"
+	Path string
+	k    *koanf.Koanf
+}
+
+// layeredConfig is the merged view of all config file layers, remembering
+// which file supplied each key.
+type layeredConfig struct {
+	layers  []configLayer
+	merged  *koanf.Koanf
+	origins map[string]string
+}
+
+// Config holds the CLI configuration
+type Config struct {
+	APIKey string
+	APIURL string
+}
+
+// configLayerPaths returns the candidate config files in precedence order (lowest first).
+// Repo layers are omitted when not inside a git repository.
+func configLayerPaths() []configLayer {
+	var layers []configLayer
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		layers = append(layers, configLayer{Name: "home", Path: filepath.Join(homeDir, configFileName)})
+	}
+
+	if out, err := runGitCommand("git", "rev-parse", "--show-toplevel"); err == nil {
+		if repoRoot := strings.TrimSpace(string(out)); repoRoot != "" {
+			layers = append(layers, configLayer{Name: "repo", Path: filepath.Join(repoRoot, configFileName)})
+		}
+	}
+
+	if gitDir, err := resolveGitCommonDir(); err == nil {
+		layers = append(layers, configLayer{Name: "git-dir", Path: filepath.Join(gitDir, "lrc", repoLocalConfigFile)})
+	}
+
+	return layers
+}
+
+// loadLayeredConfig reads every existing config layer and merges them.
+func loadLayeredConfig(verbose bool) (*layeredConfig, error) {
+	lc := &layeredConfig{
+		merged:  koanf.New("."),
+		origins: make(map[string]string),
+	}
+
+	seen := make(map[string]bool)
+	for _, layer := range configLayerPaths() {
+		// The home and repo layers coincide when running inside $HOME itself.
+		if seen[layer.Path] {
+			continue
+		}
+		seen[layer.Path] = true
+
+		if _, err := os.Stat(layer.Path); err != nil {
+			continue
+		}
+
+		layer.k = koanf.New(".")
+		if err := layer.k.Load(file.Provider(layer.Path), toml.Parser()); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", layer.Path, err)
+		}
+
+		// The repo-root file is meant to be committed; never let it carry credentials,
+		// nor pick the server that the user's credentials and diffs are sent to.
+		if layer.Name == "repo" {
+			for _, key := range repoIgnoredKeys {
+				if layer.k.Exists(key) {
+					fmt.Fprintf(os.Stderr, "Warning: ignoring %s in %s (a cloned repository must not choose the review server or hold secrets; use ~/.lrc.toml or .git/lrc/config.toml)\n", key, layer.Path)
+					layer.k.Delete(key)
+				}
+			}
+		}
+
+		if err := lc.merged.Merge(layer.k); err != nil {
+			return nil, fmt.Errorf("failed to merge config file %s: %w", layer.Path, err)
+		}
+		for _, key := range layer.k.Keys() {
+			lc.origins[key] = layer.Path
+		}
+		lc.layers = append(lc.layers, layer)
+
+		if verbose {
+			log.Printf("Loaded config from: %s", layer.Path)
+		}
+	}
+
+	return lc, nil
+}
+
+// Exists reports whether any file layer sets the key.
+func (lc *layeredConfig) Exists(key string) bool {
+	return lc != nil && lc.merged.Exists(key)
+}
+
+// String returns the merged string value of key.
+func (lc *layeredConfig) String(key string) string {
+	if lc == nil {
+		return ""
+	}
+	return lc.merged.String(key)
+}
+
+// Strings returns the merged string list value of key.
+func (lc *layeredConfig) Strings(key string) []string {
+	if lc == nil {
+		return nil
+	}
+	return lc.merged.Strings(key)
+}
+
+// Origin returns the path of the file that supplied key, or "" if unset.
+func (lc *layeredConfig) Origin(key string) string {
+	if lc == nil {
+		return ""
+	}
+	return lc.origins[key]
+}
+
+// Int parses key as an integer.
+func (lc *layeredConfig) Int(key string) (int, error) {
+	raw := lc.String(key)
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in %s: %w", key, raw, lc.Origin(key), err)
+	}
+	return n, nil
+}
+
+// Bool parses key as a boolean.
+func (lc *layeredConfig) Bool(key string) (bool, error) {
+	raw := lc.String(key)
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q in %s: %w", key, raw, lc.Origin(key), err)
+	}
+	return b, nil
+}
+
+// Effective returns the value of a key from configKeys after applying the file
+// layers and its env var to the built-in default, along with its origin.
+func (lc *layeredConfig) Effective(key string) (value, origin string) {
+	for _, spec := range configKeys {
+		if spec.Key != key {
+			continue
+		}
+		value, origin = spec.Default, configOriginDefault
+		if lc.Exists(key) {
+			value, origin = lc.String(key), lc.Origin(key)
+		}
+		if env := os.Getenv(spec.EnvVar); env != "" {
+			value, origin = env, configOriginEnvPrefix+spec.EnvVar
+		}
+		return value, origin
+	}
+	return lc.String(key), lc.Origin(key)
+}
+
+// EffectiveDuration parses the Effective value of key as a Go duration.
+func (lc *layeredConfig) EffectiveDuration(key string) (time.Duration, error) {
+	raw, origin := lc.Effective(key)
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q from %s: %w", key, raw, origin, err)
+	}
+	return d, nil
+}
+
+// applyConfigToOptions fills reviewOptions fields that were not given on the
+// command line (or via env) from the config file layers.
+func applyConfigToOptions(c *cli.Context, cfg *layeredConfig, opts *reviewOptions) error {
+	if !c.IsSet("output") && cfg.Exists("output") {
+		opts.output = cfg.String("output")
+	}
+	if !c.IsSet("port") && cfg.Exists("port") {
+		port, err := cfg.Int("port")
+		if err != nil {
+			return err
+		}
+		opts.port = port
+	}
+	if !c.IsSet("serve") && cfg.Exists("serve") {
+		serve, err := cfg.Bool("serve")
+		if err != nil {
+			return err
+		}
+		opts.serve = serve
+	}
+	if !c.IsSet("ui") && cfg.Exists("ui") {
+		opts.ui = cfg.String("ui")
+	}
+	if !c.IsSet("chunk") && cfg.Exists("chunk") {
+		chunk, err := cfg.Bool("chunk")
+		if err != nil {
+			return err
+		}
+		opts.chunk = chunk
+	}
+	if !c.IsSet("chunk-bytes") && cfg.Exists("chunk_bytes") {
+		n, err := cfg.Int("chunk_bytes")
+		if err != nil {
+			return err
+		}
+		opts.chunkBytes = n
+	}
+	if !c.IsSet("context-files") && cfg.Exists("context_files") {
+		enabled, err := cfg.Bool("context_files")
+		if err != nil {
+			return err
+		}
+		opts.contextFiles = enabled
+	}
+	if !c.IsSet("submodules") && cfg.Exists("review_submodules") {
+		enabled, err := cfg.Bool("review_submodules")
+		if err != nil {
+			return err
+		}
+		opts.submodules = enabled
+	}
+	if !c.IsSet("context-max-file-bytes") && cfg.Exists("context_max_file_bytes") {
+		n, err := cfg.Int("context_max_file_bytes")
+		if err != nil {
+			return err
+		}
+		opts.contextMaxFileBytes = n
+	}
+	if !c.IsSet("context-max-files") && cfg.Exists("context_max_files") {
+		n, err := cfg.Int("context_max_files")
+		if err != nil {
+			return err
+		}
+		opts.contextMaxFiles = n
+	}
+	if !c.IsSet("api-retries") && cfg.Exists("api_retries") {
+		n, err := cfg.Int("api_retries")
+		if err != nil {
+			return err
+		}
+		opts.apiRetries = n
+	}
+	// Only review-debug has --poll-interval and --timeout; lrc review still honors
+	// their env vars, as reported by lrc config show.
+	if !c.IsSet("poll-interval") {
+		d, err := cfg.EffectiveDuration("poll_interval")
+		if err != nil {
+			return err
+		}
+		opts.pollInterval = d
+	}
+	if !c.IsSet("timeout") {
+		d, err := cfg.EffectiveDuration("timeout")
+		if err != nil {
+			return err
+		}
+		opts.timeout = d
+	}
+	return nil
+}
+
+// loadConfigValues resolves the API key and URL from the config layers, then applies CLI/env overrides
+func loadConfigValues(apiKeyOverride, apiURLOverride string, verbose bool) (*Config, error) {
+	config := &Config{}
+
+	k, err := loadLayeredConfig(verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load API key: CLI/env overrides config file
+	if apiKeyOverride != "" {
+		config.APIKey = apiKeyOverride
+		if verbose {
+			log.Println("Using API key from CLI flag or environment variable")
+		}
+	} else if k.String("api_key") != "" {
+		config.APIKey = k.String("api_key")
+		if verbose {
+			log.Printf("Using API key from config file %s", k.Origin("api_key"))
+		}
+	} else {
+		return nil, fmt.Errorf("API key not provided. Set via --api-key flag, LRC_API_KEY environment variable, or api_key in ~/.lrc.toml or .git/lrc/config.toml")
+	}
+
+	// Load API URL: CLI/env overrides config file
+	if apiURLOverride != "" && apiURLOverride != defaultAPIURL {
+		config.APIURL = apiURLOverride
+		if verbose {
+			log.Println("Using API URL from CLI flag or environment variable")
+		}
+	} else if k.String("api_url") != "" {
+		config.APIURL = k.String("api_url")
+		if verbose {
+			log.Printf("Using API URL from config file %s", k.Origin("api_url"))
+		}
+	} else {
+		config.APIURL = defaultAPIURL
+		if verbose {
+			log.Printf("Using default API URL: %s", config.APIURL)
+		}
+	}
+
+	return config, nil
+}
+
+// maskSecret shortens a secret to a recognizable prefix for display.
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:6] + strings.Repeat("*", 6)
+}
+
+// runConfigShow prints the effective value of every config key, optionally with its origin.
+func runConfigShow(c *cli.Context) error {
+	cfg, err := loadLayeredConfig(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	showOrigin := c.Bool("origin")
+
+	if showOrigin {
+		fmt.Println("Config files (lowest precedence first):")
+		for _, layer := range configLayerPaths() {
+			status := "not found"
+			if fileExists(layer.Path) {
+				status = "loaded"
+			}
+			fmt.Printf("  %-8s %s (%s)\n", layer.Name, layer.Path, status)
+		}
+		fmt.Println()
+	}
+
+	known := make(map[string]bool, len(configKeys))
+	for _, spec := range configKeys {
+		known[spec.Key] = true
+		value, origin := cfg.Effective(spec.Key)
+		if spec.Secret && value != "" {
+			value = maskSecret(value)
+		}
+		printConfigEntry(spec.Key, value, origin, showOrigin)
+	}
+
+	// Surface keys we don't recognize so typos are easy to spot.
+	var unknown []string
+	if cfg.merged != nil {
+		for _, key := range cfg.merged.Keys() {
+			if !known[key] && !known[strings.SplitN(key, ".", 2)[0]] {
+				unknown = append(unknown, key)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		printConfigEntry(key, cfg.String(key), cfg.Origin(key)+" (unknown key)", showOrigin)
+	}
+
+	return nil
+}
+
+func printConfigEntry(key, value, origin string, showOrigin bool) {
+	if value == "" {
+		value = "(unset)"
+	}
+	if showOrigin {
+		fmt.Printf("%-22s = %-40s  # %s\n", key, value, origin)
+		return
+	}
+	fmt.Printf("%-22s = %s\n", key, value)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// writeConfigLayers points HOME at a temp dir inside a fresh repository and writes
+// the given files, keyed by layer name ("home", "repo" or "git-dir").
+func writeConfigLayers(t *testing.T, files map[string]string) {
+	t.Helper()
+	repo := initTestRepo(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	paths := map[string]string{
+		"home":    filepath.Join(home, configFileName),
+		"repo":    filepath.Join(repo, configFileName),
+		"git-dir": filepath.Join(repo, ".git", "lrc", repoLocalConfigFile),
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(paths[name]), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(paths[name], []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRepoConfigCannotRedirectAPI(t *testing.T) {
+	writeConfigLayers(t, map[string]string{
+		"home": `api_key = "home-key"`,
+		"repo": "api_key = \"repo-key\"\napi_url = \"https://evil.example\"\noutput = \"json\"",
+	})
+
+	config, err := loadConfigValues("", defaultAPIURL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.APIKey != "home-key" || config.APIURL != defaultAPIURL {
+		t.Errorf("config = %+v, want the home key sent to the default URL", config)
+	}
+
+	// Other keys from the tracked file still apply
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.String("output") != "json" {
+		t.Errorf("output = %q, want json from the repo layer", cfg.String("output"))
+	}
+}
+
+func TestConfigLayerPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		env        map[string]string
+		key        string
+		wantValue  string
+		wantOrigin string // layer name, "env <VAR>" or "default"
+	}{
+		{
+			name:       "default",
+			key:        "output",
+			wantValue:  defaultOutputFormat,
+			wantOrigin: configOriginDefault,
+		},
+		{
+			name:       "home",
+			files:      map[string]string{"home": `output = "json"`},
+			key:        "output",
+			wantValue:  "json",
+			wantOrigin: "home",
+		},
+		{
+			name:       "repo over home",
+			files:      map[string]string{"home": `output = "json"`, "repo": `output = "sarif"`},
+			key:        "output",
+			wantValue:  "sarif",
+			wantOrigin: "repo",
+		},
+		{
+			name:       "git-dir over repo",
+			files:      map[string]string{"home": `output = "json"`, "repo": `output = "sarif"`, "git-dir": `output = "junit"`},
+			key:        "output",
+			wantValue:  "junit",
+			wantOrigin: "git-dir",
+		},
+		{
+			name:       "env over every file",
+			files:      map[string]string{"repo": `timeout = "1m"`, "git-dir": `timeout = "2m"`},
+			env:        map[string]string{"LRC_TIMEOUT": "3m"},
+			key:        "timeout",
+			wantValue:  "3m",
+			wantOrigin: "env LRC_TIMEOUT",
+		},
+		{
+			name:       "api_key in the repo layer is ignored",
+			files:      map[string]string{"repo": `api_key = "repo-key"`},
+			key:        "api_key",
+			wantValue:  "",
+			wantOrigin: configOriginDefault,
+		},
+		{
+			name:       "api_url in the repo layer is ignored",
+			files:      map[string]string{"home": `api_url = "https://home.example"`, "repo": `api_url = "https://evil.example"`},
+			key:        "api_url",
+			wantValue:  "https://home.example",
+			wantOrigin: "home",
+		},
+		{
+			name:       "api_key in the git-dir layer",
+			files:      map[string]string{"home": `api_key = "home-key"`, "git-dir": `api_key = "clone-key"`},
+			key:        "api_key",
+			wantValue:  "clone-key",
+			wantOrigin: "git-dir",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, spec := range configKeys {
+				if spec.EnvVar != "" {
+					t.Setenv(spec.EnvVar, tt.env[spec.EnvVar])
+				}
+			}
+			writeConfigLayers(t, tt.files)
+			cfg, err := loadLayeredConfig(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, origin := cfg.Effective(tt.key)
+			if strings.HasPrefix(origin, string(os.PathSeparator)) {
+				for _, layer := range cfg.layers {
+					if layer.Path == origin {
+						origin = layer.Name
+					}
+				}
+			}
+			if value != tt.wantValue || origin != tt.wantOrigin {
+				t.Errorf("Effective(%q) = %q from %s, want %q from %s", tt.key, value, origin, tt.wantValue, tt.wantOrigin)
+			}
+		})
+	}
+}
+
+func TestReviewHonorsDebugEnvVars(t *testing.T) {
+	writeConfigLayers(t, map[string]string{"git-dir": `poll_interval = "5s"`})
+	t.Setenv("LRC_POLL_INTERVAL", "")
+	t.Setenv("LRC_TIMEOUT", "90s")
+
+	// lrc review has no --poll-interval or --timeout flags
+	c := cli.NewContext(cli.NewApp(), flag.NewFlagSet("review", flag.ContinueOnError), nil)
+	opts := reviewOptions{pollInterval: defaultPollInterval, timeout: defaultTimeout}
+	if err := applyConfigToOptions(c, nil, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.timeout != 90*time.Second || opts.pollInterval != defaultPollInterval {
+		t.Errorf("without config: timeout %s, poll interval %s", opts.timeout, opts.pollInterval)
+	}
+
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfigToOptions(c, cfg, &opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.timeout != 90*time.Second || opts.pollInterval != 5*time.Second {
+		t.Errorf("with config: timeout %s, poll interval %s", opts.timeout, opts.pollInterval)
+	}
+
+	t.Setenv("LRC_TIMEOUT", "soon")
+	if err := applyConfigToOptions(c, cfg, &opts); err == nil || !strings.Contains(err.Error(), "LRC_TIMEOUT") {
+		t.Errorf("invalid LRC_TIMEOUT: %v", err)
+	}
+}
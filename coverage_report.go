@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// coverageReport shows which added lines of a diff prior "reviewed" sessions cover.
+type coverageReport struct {
+	Source           string         `json:"source"` // "staged" or the range
+	Branch           string         `json:"branch"`
+	TreeHash         string         `json:"tree_hash"`
+	PriorReviewCount int            `json:"prior_review_count"`
+	CoveredLines     int            `json:"covered_lines"`
+	TotalLines       int            `json:"total_lines"`
+	CoveragePct      float64        `json:"coverage_pct"`
+	Files            []fileCoverage `json:"files"`
+
+	diffFiles []diffReviewFileResult // for the annotated hunks and the web UI
+}
+
+// fileCoverage is the coverage of one file's added lines.
+type fileCoverage struct {
+	FilePath     string         `json:"file_path"`
+	CoveredLines int            `json:"covered_lines"`
+	TotalLines   int            `json:"total_lines"`
+	Lines        []lineCoverage `json:"lines"`
+}
+
+type lineCoverage struct {
+	Line    int  `json:"line"`
+	Covered bool `json:"covered"`
+}
+
+// buildCoverageReport matches the added lines of files against the reviewed
+// sessions of branch, the same way the attestation's coverage is computed.
+func buildCoverageReport(db *sql.DB, branch, treeHash, patchID string, files []diffReviewFileResult) (coverageReport, error) {
+	report := coverageReport{Branch: branch, TreeHash: treeHash, diffFiles: files}
+	entries := filesToEntries(files)
+	result, covered, err := priorCoveredLines(db, branch, treeHash, patchID, entries)
+	if err != nil {
+		return report, err
+	}
+	report.PriorReviewCount = result.PriorReviewCount
+
+	for _, entry := range entries {
+		fc := fileCoverage{FilePath: entry.FilePath}
+		for line := range entry.LineHashes {
+			lc := lineCoverage{Line: line, Covered: covered[fmt.Sprintf("%s:%d", entry.FilePath, line)]}
+			fc.Lines = append(fc.Lines, lc)
+			if lc.Covered {
+				fc.CoveredLines++
+			}
+		}
+		sort.Slice(fc.Lines, func(i, j int) bool { return fc.Lines[i].Line < fc.Lines[j].Line })
+		fc.TotalLines = len(fc.Lines)
+		report.CoveredLines += fc.CoveredLines
+		report.TotalLines += fc.TotalLines
+		report.Files = append(report.Files, fc)
+	}
+	if report.TotalLines > 0 {
+		report.CoveragePct = float64(report.CoveredLines) / float64(report.TotalLines) * 100
+	}
+	return report, nil
+}
+
+// coverageDiff collects the diff to report on: the staged changes (the conflict
+// resolution during a merge) or a range, with the tree it leads to.
+func coverageDiff(rangeVal string) (diff []byte, treeHash string, err error) {
+	if rangeVal == "" {
+		if diff, err = collectMergeResolution(false); err != nil {
+			if diff, err = runGitCommand("git", "diff", "--staged"); err != nil {
+				return nil, "", err
+			}
+		}
+		treeHash, err = currentTreeHash()
+		return diff, treeHash, err
+	}
+
+	if !strings.Contains(rangeVal, "..") {
+		return nil, "", invalidInput("invalid range %q (expected <from>..<to>)", rangeVal)
+	}
+	end := rangeVal[strings.LastIndex(rangeVal, "..")+2:]
+	if end == "" {
+		end = "HEAD"
+	}
+	out, err := runGitCommand("git", "rev-parse", "--verify", end+"^{tree}")
+	if err != nil {
+		return nil, "", invalidInput("invalid range %q: %s is not a commit", rangeVal, end)
+	}
+	if diff, err = runGitCommand("git", "diff", rangeVal); err != nil {
+		return nil, "", invalidInput("invalid range %q: %v", rangeVal, err)
+	}
+	return diff, strings.TrimSpace(string(out)), nil
+}
+
+// coveragePatchID returns the patch ID to match reviewed sessions by. It is taken
+// before path filters, like the one sessions record, so a staged change reports the
+// same coverage as its attestation even when default excludes drop files.
+func coveragePatchID(rangeVal string, diff []byte) (string, error) {
+	if rangeVal == "" {
+		return stagedPatchID()
+	}
+	return patchID(diff)
+}
+
+// runCoverage implements `lrc coverage [<range>]`: the coverage of the staged
+// changes, or of a range, by the reviewed sessions in the review DB.
+func runCoverage(c *cli.Context) error {
+	output := c.String("output")
+	switch output {
+	case "table", "json", "lcov":
+	default:
+		return reviewExitError(invalidInput("invalid --output %q (must be table, json or lcov)", output))
+	}
+	if c.NArg() > 1 || strings.HasPrefix(c.Args().First(), "-") {
+		return reviewExitError(invalidInput("usage: lrc coverage [flags] [<range>]"))
+	}
+	rangeVal := c.Args().First()
+
+	cfg, err := loadLayeredConfig(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	diff, treeHash, err := coverageDiff(rangeVal)
+	if err != nil {
+		return reviewExitError(err)
+	}
+	id, err := coveragePatchID(rangeVal, diff)
+	if err != nil {
+		return err
+	}
+	diff, _ = filterDiff(diff, buildPathFilter(cfg, c.StringSlice("include"), c.StringSlice("exclude"), c.Bool("no-default-excludes")))
+
+	var files []diffReviewFileResult
+	if len(bytes.TrimSpace(diff)) > 0 {
+		if files, err = parseDiffToFiles(diff); err != nil {
+			return fmt.Errorf("could not parse diff: %w", err)
+		}
+	}
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	branch := c.String("branch")
+	if branch == "" {
+		branch = currentBranch()
+	}
+	report, err := buildCoverageReport(db, branch, treeHash, id, files)
+	if err != nil {
+		return err
+	}
+	report.Source = "staged"
+	if rangeVal != "" {
+		report.Source = rangeVal
+	}
+
+	if path := c.String("save-text"); path != "" {
+		if err := os.WriteFile(path, []byte(formatCoverageText(report)), 0644); err != nil {
+			return err
+		}
+		abs, _ := filepath.Abs(path)
+		fmt.Fprintf(os.Stderr, "Annotated coverage saved to: %s\n", abs)
+	}
+	if c.Bool("serve") {
+		return serveCoverage(report, c.Int("port"))
+	}
+
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "lcov":
+		return writeCoverageLCOV(os.Stdout, report)
+	}
+	printCoverageTable(report)
+	return nil
+}
+
+func printCoverageTable(report coverageReport) {
+	if report.TotalLines == 0 {
+		fmt.Printf("No added lines in %s.\n", report.Source)
+		return
+	}
+	fmt.Printf("%-60s  %7s  %5s  %4s\n", "FILE", "COVERED", "ADDED", "COV")
+	for _, f := range report.Files {
+		if f.TotalLines == 0 {
+			continue
+		}
+		fmt.Printf("%-60s  %7d  %5d  %3.0f%%\n", truncateField(f.FilePath, 60), f.CoveredLines, f.TotalLines,
+			float64(f.CoveredLines)/float64(f.TotalLines)*100)
+	}
+	fmt.Printf("\n%d of %d added line(s) in %s covered by %d prior review(s) on %s (%.0f%%)\n",
+		report.CoveredLines, report.TotalLines, report.Source, report.PriorReviewCount, report.Branch, report.CoveragePct)
+}
+
+// writeCoverageLCOV writes the report as an LCOV tracefile, with one DA record per
+// added line: hit count 1 when a prior review covered it, else 0.
+func writeCoverageLCOV(w io.Writer, report coverageReport) error {
+	var buf bytes.Buffer
+	buf.WriteString("TN:livereview\n")
+	for _, f := range report.Files {
+		if f.TotalLines == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "SF:%s\n", f.FilePath)
+		for _, l := range f.Lines {
+			hits := 0
+			if l.Covered {
+				hits = 1
+			}
+			fmt.Fprintf(&buf, "DA:%d,%d\n", l.Line, hits)
+		}
+		fmt.Fprintf(&buf, "LF:%d\nLH:%d\nend_of_record\n", f.TotalLines, f.CoveredLines)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatCoverageText renders the diff with each added line marked, like the
+// review's --save-text output: "✓" for lines a prior review covered, "✗" for
+// lines no review has seen.
+func formatCoverageText(report coverageReport) string {
+	var buf bytes.Buffer
+	const uncoveredMarker = "✗"
+
+	buf.WriteString("=" + strings.Repeat("=", 79) + "\n")
+	buf.WriteString("LIVEREVIEW COVERAGE - TEXT FORMAT\n")
+	buf.WriteString("=" + strings.Repeat("=", 79) + "\n")
+	buf.WriteString(fmt.Sprintf("Generated: %s\n", time.Now().Format(time.RFC3339)))
+	buf.WriteString(fmt.Sprintf("Diff: %s, branch %s, %d prior review(s)\n", report.Source, report.Branch, report.PriorReviewCount))
+	buf.WriteString(fmt.Sprintf("Covered: %d of %d added line(s) (%.0f%%)\n", report.CoveredLines, report.TotalLines, report.CoveragePct))
+	buf.WriteString("\nSearch for '" + uncoveredMarker + "' to jump between lines no review has covered\n")
+	buf.WriteString("=" + strings.Repeat("=", 79) + "\n")
+
+	for i, file := range report.diffFiles {
+		fc := report.Files[i] // one per diff file, in the same order
+		covered := make(map[int]bool)
+		for _, l := range fc.Lines {
+			covered[l.Line] = l.Covered
+		}
+		buf.WriteString("\n" + strings.Repeat("=", 80) + "\n")
+		buf.WriteString(fmt.Sprintf("FILE %d/%d: %s (%d of %d added line(s) covered)\n", i+1, len(report.diffFiles), file.FilePath, fc.CoveredLines, fc.TotalLines))
+		buf.WriteString(strings.Repeat("=", 80) + "\n")
+
+		for _, hunk := range file.Hunks {
+			buf.WriteString(strings.Repeat("-", 80) + "\n")
+			buf.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", hunk.OldStartLine, hunk.OldLineCount, hunk.NewStartLine, hunk.NewLineCount))
+			buf.WriteString(strings.Repeat("-", 80) + "\n")
+			oldLine, newLine := hunk.OldStartLine, hunk.NewStartLine
+			for _, line := range strings.Split(hunk.Content, "\n") {
+				if len(line) == 0 || strings.HasPrefix(line, "@@") {
+					continue
+				}
+				switch {
+				case strings.HasPrefix(line, "-"):
+					buf.WriteString(fmt.Sprintf("%4d |      |   %s\n", oldLine, line))
+					oldLine++
+				case strings.HasPrefix(line, "+"):
+					mark := uncoveredMarker
+					if covered[newLine] {
+						mark = "✓"
+					}
+					buf.WriteString(fmt.Sprintf("     | %4d | %s %s\n", newLine, mark, line))
+					newLine++
+				default:
+					buf.WriteString(fmt.Sprintf("%4d | %4d |   %s\n", oldLine, newLine, line))
+					oldLine++
+					newLine++
+				}
+			}
+		}
+	}
+	return buf.String()
+}
+
+// serveCoverage shows the diff in the web UI with the coverage overlay on.
+func serveCoverage(report coverageReport, port int) error {
+	state := NewReviewState("", report.diffFiles, false, true, "", "")
+	state.Status = "completed"
+	state.Summary = fmt.Sprintf("**Coverage of %s:** %d of %d added line(s) covered by %d prior review(s) on `%s` (%.0f%%).",
+		report.Source, report.CoveredLines, report.TotalLines, report.PriorReviewCount, report.Branch, report.CoveragePct)
+	state.Coverage = &report
+	return serveReadOnlyState(state, fmt.Sprintf("coverage of %s", report.Source), port)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildCoverageReport(t *testing.T) {
+	initTestRepo(t)
+	db := stageAndRecordReview(t, map[string]string{"a.go": "func a() {\n\treturn\n}\n"})
+	defer db.Close()
+
+	// One reviewed file gains a line, and a file nobody reviewed appears
+	tree, _ := stageFiles(t, map[string]string{
+		"a.go": "func a() {\n\tlog()\n\treturn\n}\n",
+		"b.go": "package b\n",
+	})
+	diff, err := runGitCommand("git", "diff", "--staged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := parseDiffToFiles(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := buildCoverageReport(db, "main", tree, "", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.CoveredLines != 3 || report.TotalLines != 5 || report.PriorReviewCount != 1 {
+		t.Fatalf("report = %d/%d lines over %d review(s), want 3/5 over 1", report.CoveredLines, report.TotalLines, report.PriorReviewCount)
+	}
+	a := report.Files[0]
+	if a.FilePath != "a.go" || len(a.Lines) != 4 || !a.Lines[0].Covered || a.Lines[1].Covered || !a.Lines[2].Covered {
+		t.Errorf("a.go lines = %+v, want only line 2 uncovered", a.Lines)
+	}
+
+	var lcov bytes.Buffer
+	if err := writeCoverageLCOV(&lcov, report); err != nil {
+		t.Fatal(err)
+	}
+	want := "TN:livereview\n" +
+		"SF:a.go\nDA:1,1\nDA:2,0\nDA:3,1\nDA:4,1\nLF:4\nLH:3\nend_of_record\n" +
+		"SF:b.go\nDA:1,0\nLF:1\nLH:0\nend_of_record\n"
+	if lcov.String() != want {
+		t.Errorf("LCOV output:\n%s\nwant:\n%s", lcov.String(), want)
+	}
+
+	text := formatCoverageText(report)
+	if !strings.Contains(text, "|    2 | ✗ +\tlog()") || !strings.Contains(text, "|    3 | ✓ +\treturn") {
+		t.Errorf("annotated hunks do not mark lines:\n%s", text)
+	}
+}
+
+func TestCoverageMatchesPatchDespiteExcludes(t *testing.T) {
+	initTestRepo(t)
+	runGit(t, "commit", "-q", "--allow-empty", "-m", "base")
+	tree, _ := stageFiles(t, map[string]string{"a.go": "package a\n", "go.sum": "a v1\n"})
+	id, err := stagedPatchID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// The session records no lines, so only its patch ID can cover the report
+	if err := insertReviewSession(db, tree, "topic", "reviewed", nil, "r1", id, sessionMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, _, err := coverageDiff("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportID, err := coveragePatchID("", diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered, excluded := filterDiff(diff, buildPathFilter(nil, nil, nil, false))
+	if len(excluded) != 1 {
+		t.Fatalf("excluded = %v, want go.sum", excluded)
+	}
+	files, err := parseDiffToFiles(filtered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := buildCoverageReport(db, "main", tree, reportID, files)
+	if err != nil || report.CoveragePct != 100 {
+		t.Errorf("coverage of the reviewed patch with go.sum excluded = %+v, %v; want 100%%", report, err)
+	}
+}
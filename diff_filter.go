@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// defaultExcludePatterns are applied unless disabled with --no-default-excludes
+// or default_excludes = false in config. Binary patches are excluded separately.
+var defaultExcludePatterns = []string{
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"go.sum",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"poetry.lock",
+	"Pipfile.lock",
+	"composer.lock",
+	"mix.lock",
+	"pubspec.lock",
+	"Podfile.lock",
+}
+
+// diffPathFilter decides which file sections of a diff are submitted for review.
+type diffPathFilter struct {
+	Include         []string // if non-empty, a file must match at least one pattern
+	Exclude         []string // user patterns from config and --exclude
+	DefaultExcludes bool     // apply defaultExcludePatterns and drop binary patches
+}
+
+// excludedDiffFile records a file section that was dropped from the diff and why.
+type excludedDiffFile struct {
+	FilePath string
+	Reason   string
+}
+
+// diffFileSection is one "diff --git" section of a unified diff.
+type diffFileSection struct {
+	FilePath string
+	Content  []byte
+}
+
+// buildPathFilter merges config include/exclude lists with the CLI flags.
+func buildPathFilter(cfg *layeredConfig, flagIncludes, flagExcludes []string, noDefaults bool) diffPathFilter {
+	filter := diffPathFilter{DefaultExcludes: true}
+	if cfg.Exists("default_excludes") {
+		if enabled, err := cfg.Bool("default_excludes"); err == nil {
+			filter.DefaultExcludes = enabled
+		}
+	}
+	if noDefaults {
+		filter.DefaultExcludes = false
+	}
+	filter.Include = append(filter.Include, cfg.Strings("include")...)
+	filter.Include = append(filter.Include, flagIncludes...)
+	filter.Exclude = append(filter.Exclude, cfg.Strings("exclude")...)
+	filter.Exclude = append(filter.Exclude, flagExcludes...)
+	return filter
+}
+
+// isEmpty reports whether the filter would keep every file.
+func (f diffPathFilter) isEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && !f.DefaultExcludes
+}
+
+// splitDiffSections splits raw diff output into per-file sections.
+// Any preamble before the first "diff --git" line is returned as a section with an empty path.
+func splitDiffSections(diffContent []byte) []diffFileSection {
+	var sections []diffFileSection
+	var current *diffFileSection
+
+	for _, line := range bytes.SplitAfter(diffContent, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("diff --git ")) {
+			if current != nil {
+				sections = append(sections, *current)
+			}
+			current = &diffFileSection{}
+		} else if current == nil {
+			current = &diffFileSection{}
+		}
+		current.Content = append(current.Content, line...)
+	}
+	if current != nil {
+		sections = append(sections, *current)
+	}
+
+	for i := range sections {
+		sections[i].FilePath = diffSectionPath(sections[i].Content)
+	}
+	return sections
+}
+
+// diffSectionPath extracts the file path of a diff section, preferring the
+// new-side path and falling back to the old side for deletions.
+func diffSectionPath(section []byte) string {
+	var header, oldPath string
+	for _, raw := range strings.Split(string(section), "\n") {
+		line := strings.TrimRight(raw, "\r")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			header = line
+		case strings.HasPrefix(line, "+++ b/"):
+			return strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "--- a/"):
+			oldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "@@"):
+			// Past the file header; no +++ line means a deletion or mode-only change.
+			if oldPath != "" {
+				return oldPath
+			}
+		}
+	}
+	if oldPath != "" {
+		return oldPath
+	}
+	if idx := strings.LastIndex(header, " b/"); idx != -1 {
+		return header[idx+len(" b/"):]
+	}
+	return ""
+}
+
+// isBinaryDiffSection reports whether a section is a binary patch.
+func isBinaryDiffSection(section []byte) bool {
+	return bytes.Contains(section, []byte("\nGIT binary patch\n")) ||
+		bytes.Contains(section, []byte("\nBinary files "))
+}
+
+// filterDiff drops file sections that don't pass the filter and returns the
+// remaining diff along with the list of excluded files.
+func filterDiff(diffContent []byte, filter diffPathFilter) ([]byte, []excludedDiffFile) {
+	if filter.isEmpty() {
+		return diffContent, nil
+	}
+
+	var kept bytes.Buffer
+	var excluded []excludedDiffFile
+	for _, section := range splitDiffSections(diffContent) {
+		if section.FilePath == "" {
+			kept.Write(section.Content)
+			continue
+		}
+		if reason := filter.exclusionReason(section); reason != "" {
+			excluded = append(excluded, excludedDiffFile{FilePath: section.FilePath, Reason: reason})
+			continue
+		}
+		kept.Write(section.Content)
+	}
+	return kept.Bytes(), excluded
+}
+
+// exclusionReason returns why a section should be dropped, or "" to keep it.
+func (f diffPathFilter) exclusionReason(section diffFileSection) string {
+	filePath := section.FilePath
+	if len(f.Include) > 0 && matchAnyGlob(f.Include, filePath) == "" {
+		return "not matched by include rules"
+	}
+	if pattern := matchAnyGlob(f.Exclude, filePath); pattern != "" {
+		return fmt.Sprintf("exclude %q", pattern)
+	}
+	if f.DefaultExcludes {
+		if isBinaryDiffSection(section.Content) {
+			return "binary patch (default)"
+		}
+		if pattern := matchAnyGlob(defaultExcludePatterns, filePath); pattern != "" {
+			return fmt.Sprintf("lockfile %q (default)", pattern)
+		}
+	}
+	return ""
+}
+
+// matchAnyGlob returns the first pattern matching filePath, or "".
+func matchAnyGlob(patterns []string, filePath string) string {
+	for _, pattern := range patterns {
+		if matchPathGlob(pattern, filePath) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// matchPathGlob matches a repo-relative path against a gitignore-style glob:
+//   - "*" and "?" never cross "/"; "**" matches any number of directories
+//   - a pattern without "/" matches the file's basename at any depth
+//   - a trailing "/" matches everything under that directory
+func matchPathGlob(pattern, filePath string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, err := path.Match(pattern, path.Base(filePath))
+		return err == nil && ok
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	re, err := regexp.Compile(globToRegexp(pattern))
+	if err != nil {
+		return false
+	}
+	return re.MatchString(filePath)
+}
+
+// globToRegexp translates a path glob into an anchored regular expression.
+func globToRegexp(pattern string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				// "**/" matches zero or more leading directories
+				i++
+				sb.WriteString("(?:.*/)?")
+			} else {
+				sb.WriteString(".*")
+			}
+		case ch == '*':
+			sb.WriteString("[^/]*")
+		case ch == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// allExcludedError means the diff had changes, but every changed file was excluded by
+// the path filter (e.g. a commit that only touches go.sum), so there is nothing to review.
+type allExcludedError struct {
+	excluded []excludedDiffFile
+}
+
+func (e *allExcludedError) Error() string {
+	return fmt.Sprintf("all %d changed file(s) were excluded by include/exclude rules", len(e.excluded))
+}
+
+// paths returns the excluded file paths.
+func (e *allExcludedError) paths() []string {
+	paths := make([]string, len(e.excluded))
+	for i, ex := range e.excluded {
+		paths[i] = ex.FilePath
+	}
+	return paths
+}
+
+// collectFilteredDiff collects the diff for opts and applies opts.pathFilter.
+// It returns an *allExcludedError when every changed file was excluded, so callers
+// never submit an empty review.
+func collectFilteredDiff(opts reviewOptions) ([]byte, []excludedDiffFile, error) {
+	diffContent, err := collectDiffWithOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filtered, excluded := filterDiff(diffContent, opts.pathFilter)
+	if opts.verbose {
+		for _, ex := range excluded {
+			log.Printf("Excluded from review: %s (%s)", ex.FilePath, ex.Reason)
+		}
+	}
+	if len(diffContent) > 0 && len(bytes.TrimSpace(filtered)) == 0 {
+		return nil, excluded, &allExcludedError{excluded: excluded}
+	}
+	return filtered, excluded, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"*.pb.go", "api/v1/service.go", false},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"vendor/", "internal/vendor.go", false},
+		{"**/testdata/**", "pkg/a/testdata/fixture.json", true},
+		{"**/testdata/**", "testdata/fixture.json", true},
+		{"static/*.min.js", "static/app.min.js", true},
+		{"static/*.min.js", "static/lib/app.min.js", false},
+		{"static/**/*.min.js", "static/lib/app.min.js", true},
+		{"/docs/*.md", "docs/readme.md", true},
+		{"go.sum", "tools/go.sum", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchPathGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/main.go b/main.go",
+		"index 1111111..2222222 100644",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,1 +1,2 @@",
+		" package main",
+		"+// hello",
+		"diff --git a/go.sum b/go.sum",
+		"index 1111111..2222222 100644",
+		"--- a/go.sum",
+		"+++ b/go.sum",
+		"@@ -1 +1 @@",
+		"-a v1",
+		"+a v2",
+		"diff --git a/gen/api.pb.go b/gen/api.pb.go",
+		"deleted file mode 100644",
+		"index 1111111..0000000",
+		"--- a/gen/api.pb.go",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-package gen",
+		"diff --git a/logo.png b/logo.png",
+		"index 1111111..2222222 100644",
+		"Binary files a/logo.png and b/logo.png differ",
+		"",
+	}, "\n")
+
+	filter := diffPathFilter{Exclude: []string{"*.pb.go"}, DefaultExcludes: true}
+	filtered, excluded := filterDiff([]byte(diff), filter)
+
+	if !strings.Contains(string(filtered), "+++ b/main.go") {
+		t.Errorf("expected main.go to be kept, got:\n%s", filtered)
+	}
+	want := map[string]bool{"go.sum": true, "gen/api.pb.go": true, "logo.png": true}
+	if len(excluded) != len(want) {
+		t.Fatalf("expected %d excluded files, got %d: %+v", len(want), len(excluded), excluded)
+	}
+	for _, ex := range excluded {
+		if !want[ex.FilePath] {
+			t.Errorf("unexpected excluded file %q (%s)", ex.FilePath, ex.Reason)
+		}
+		if strings.Contains(string(filtered), ex.FilePath) {
+			t.Errorf("excluded file %q still present in filtered diff", ex.FilePath)
+		}
+	}
+}
+
+func TestLockfileOnlyCommit(t *testing.T) {
+	initTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("LRC_API_KEY", "")
+	os.WriteFile("go.sum", []byte("a v1\n"), 0644)
+	runGit(t, "add", "go.sum")
+	runGit(t, "commit", "-q", "-m", "base")
+	os.WriteFile("go.sum", []byte("a v2\n"), 0644)
+	runGit(t, "add", "go.sum")
+
+	// Nothing is left to review, so no API key is needed and the commit may go ahead
+	base := reviewOptions{diffSource: "staged", pathFilter: buildPathFilter(nil, nil, nil, false)}
+	tests := []struct {
+		name       string
+		skip       bool
+		vouch      bool
+		wantAction string
+	}{
+		{"review", false, false, "skipped"},
+		{"vouch", false, true, "vouched"},
+		{"skip", true, false, "skipped"},
+	}
+	for _, tt := range tests {
+		opts := base
+		opts.skip, opts.vouch = tt.skip, tt.vouch
+		if err := runReviewWithOptions(opts); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		payload, err := readCurrentAttestation()
+		if err != nil || payload == nil {
+			t.Fatalf("%s: attestation = %v, %v", tt.name, payload, err)
+		}
+		if payload.Action != tt.wantAction || !reflect.DeepEqual(payload.ExcludedFiles, []string{"go.sum"}) {
+			t.Errorf("%s: attestation = %+v", tt.name, payload)
+		}
+		if err := deleteAttestationForCurrentTree(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// serverPolicyFile is the server-side policy of a (usually bare) repository,
+// relative to its git dir. It takes precedence over the committed policy file.
+const serverPolicyFile = "lrc/policy.toml"
+
+// refUpdate is one line of pre-receive input: a ref moving from Old to New.
+type refUpdate struct {
+	Old, New, Ref string
+}
+
+// isZeroOID reports whether a hash is git's all-zero "no object" id.
+func isZeroOID(oid string) bool {
+	return oid != "" && strings.Trim(oid, "0") == ""
+}
+
+// readRefUpdates parses pre-receive input: "<old> <new> <ref>" per line.
+func readRefUpdates(r io.Reader) ([]refUpdate, error) {
+	var updates []refUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, invalidInput("malformed ref update %q (expected <old> <new> <ref>)", scanner.Text())
+		}
+		updates = append(updates, refUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	return updates, scanner.Err()
+}
+
+// loadEnforcePolicy finds the policy for a server-side check: --policy, then
+// lrc/policy.toml in the git dir, then .lrc-policy.toml committed on HEAD.
+// The pushed commits never supply their own policy.
+func loadEnforcePolicy(c *cli.Context) (*reviewPolicy, error) {
+	if c.IsSet("policy") {
+		return loadReviewPolicy(c.String("policy"), true)
+	}
+	gitDir, err := resolveGitCommonDir()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := loadReviewPolicy(filepath.Join(gitDir, serverPolicyFile), false)
+	if policy != nil || err != nil {
+		return policy, err
+	}
+	data, err := runGitCommand("git", "show", "HEAD:"+defaultPolicyFile)
+	if err != nil {
+		return nil, nil
+	}
+	return parseReviewPolicy("HEAD:"+defaultPolicyFile, data)
+}
+
+// pushedCommits lists the commits a ref update to a branch with the given rules
+// introduces. For a new branch these are the commits not on any existing branch
+// that the same rules cover: those were checked when pushed there, while commits
+// from branches with laxer rules never were. Merges are included with the files
+// their conflict resolution changed.
+func pushedCommits(u refUpdate, rules *reviewPolicy) ([]auditCommit, error) {
+	revs := []string{u.Old + ".." + u.New}
+	if isZeroOID(u.Old) {
+		out, err := runGitCommand("git", "for-each-ref", "--format=%(refname)", "refs/heads/")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branches: %w", err)
+		}
+		revs = []string{u.New, "--not"}
+		for _, ref := range strings.Fields(string(out)) {
+			if rules.coversBranch(strings.TrimPrefix(ref, "refs/heads/")) {
+				revs = append(revs, ref)
+			}
+		}
+	}
+	return collectRangeCommits(revs)
+}
+
+// collectMergeChanges lists the merge commits of revs that change anything beyond
+// what git merges on its own. Their files are the --remerge-diff delta between the
+// automatic merge and the committed result, i.e. conflict resolutions and any code
+// slipped into the merge. Without --remerge-diff (git < 2.36) every file the merge
+// changes against its first parent counts, which is stricter but never lets an
+// edited merge through.
+func collectMergeChanges(revs []string) ([]auditCommit, error) {
+	merges, err := logAuditCommits([]string{"--merges", "--remerge-diff"}, revs)
+	if err != nil {
+		merges, err = logAuditCommits([]string{"--merges", "--diff-merges=first-parent"}, revs)
+	}
+	if err != nil {
+		return nil, invalidInput("invalid revision range %q", strings.Join(revs, " "))
+	}
+
+	changed := merges[:0]
+	for _, m := range merges {
+		if len(m.Files) > 0 {
+			changed = append(changed, m)
+		}
+	}
+	return changed, nil
+}
+
+// runEnforce implements `lrc enforce`, the server-side policy check. As a
+// pre-receive hook it reads ref updates from stdin; as an update hook it takes
+// <ref> <old> <new> arguments.
+func runEnforce(c *cli.Context) error {
+	var updates []refUpdate
+	switch c.NArg() {
+	case 0:
+		var err error
+		if updates, err = readRefUpdates(os.Stdin); err != nil {
+			return reviewExitError(err)
+		}
+	case 3:
+		updates = []refUpdate{{Ref: c.Args().Get(0), Old: c.Args().Get(1), New: c.Args().Get(2)}}
+	default:
+		return reviewExitError(invalidInput("usage: lrc enforce [flags] [<ref> <old> <new>] (ref updates are read from stdin without arguments)"))
+	}
+
+	policy, err := loadEnforcePolicy(c)
+	if err != nil {
+		return reviewExitError(err)
+	}
+	if policy == nil {
+		if c.Bool("verbose") {
+			fmt.Fprintln(os.Stderr, "lrc: no review policy configured; push accepted")
+		}
+		return nil
+	}
+	_, notesRef, err := notesSettings(false)
+	if err != nil {
+		return err
+	}
+	violations, err := checkRefUpdates(updates, policy, notesRef)
+	if err != nil {
+		return reviewExitError(err)
+	}
+
+	warnOnly := c.Bool("warn-only")
+	rejected := map[string]bool{}
+	for _, v := range violations {
+		label := "rejected"
+		if v.Action == policyActionWarn || warnOnly {
+			label = "warning"
+		} else {
+			rejected[v.Commit] = true
+		}
+		fmt.Fprintf(os.Stderr, "lrc: %s: %s %s %q: %s is %s (%s)\n",
+			label, v.Branch, shortHash(v.Commit), truncateField(v.Subject, 50), v.Rule, v.Status, strings.Join(v.Files, ", "))
+	}
+	if len(rejected) > 0 {
+		fmt.Fprintf(os.Stderr, "lrc: push rejected: %d commit(s) break the review policy in %s.\n", len(rejected), policy.Path)
+		fmt.Fprintln(os.Stderr, "lrc: review them with lrc (or vouch for them) and push again.")
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// pushViolation is a policy violation by a commit pushed to a branch.
+type pushViolation struct {
+	policyViolation
+	Branch  string
+	Subject string
+}
+
+// checkRefUpdates checks the commits each branch update introduces against the
+// rules for that branch. Commit status comes from the trailer, or from the review
+// and attestation notes, including notes pushed in the same push.
+func checkRefUpdates(updates []refUpdate, policy *reviewPolicy, notesRef string) ([]pushViolation, error) {
+	// Notes pushed together with the commits are not on their refs yet
+	reviewRev, attestationRev := notesRef, attestationNotesRef(notesRef)
+	for _, u := range updates {
+		switch {
+		case isZeroOID(u.New):
+		case u.Ref == notesRef:
+			reviewRev = u.New
+		case u.Ref == attestationNotesRef(notesRef):
+			attestationRev = u.New
+		}
+	}
+	reviewNotes, attestationNotes := readNotesIndex(reviewRev), readNotesIndex(attestationRev)
+
+	var violations []pushViolation
+	for _, u := range updates {
+		branch, ok := strings.CutPrefix(u.Ref, "refs/heads/")
+		if !ok || isZeroOID(u.New) {
+			continue
+		}
+		rules := policy.forBranch(branch)
+		if rules == nil {
+			continue
+		}
+		commits, err := pushedCommits(u, rules)
+		if err != nil {
+			return nil, err
+		}
+		applyNoteStatuses(commits, reviewNotes, attestationNotes)
+
+		for _, commit := range commits {
+			if rules.requiresSignature() {
+				commit.Signature = verifyCommitWithNote(commit.Commit, func() string { return attestationNotes.read(commit.Commit) }).Status
+			}
+			for _, v := range rules.check(commit) {
+				violations = append(violations, pushViolation{policyViolation: v, Branch: branch, Subject: commit.Subject})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// generatePreReceiveHook renders the server-side hook. lrc is called by the path
+// it was installed from, since hooks on a git server often run with a minimal PATH.
+func generatePreReceiveHook() string {
+	binary, _ := os.Executable()
+	return renderHookTemplate("hooks/pre-receive.sh", map[string]string{
+		hookMarkerBeginPlaceholder: lrcMarkerBegin,
+		hookMarkerEndPlaceholder:   lrcMarkerEnd,
+		hookVersionPlaceholder:     version,
+		hookBinaryPlaceholder:      binary,
+	})
+}
+
+// serverHooksPath returns the hooks directory of the current repository, bare or not.
+func serverHooksPath() (string, error) {
+	out, err := runGitCommand("git", "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository")
+	}
+	return filepath.Abs(strings.TrimSpace(string(out)))
+}
+
+// installServerHook adds the lrc section to the repository's pre-receive hook,
+// keeping any existing hook code after it.
+func installServerHook() error {
+	hooksPath, err := serverHooksPath()
+	if err != nil {
+		return err
+	}
+	backupDir := filepath.Join(hooksPath, ".lrc_backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := installHook(filepath.Join(hooksPath, "pre-receive"), generatePreReceiveHook(), "pre-receive", backupDir, true); err != nil {
+		return fmt.Errorf("failed to install pre-receive hook: %w", err)
+	}
+	_ = cleanOldBackups(backupDir, 5)
+	fmt.Printf("✅ LiveReview server hook installed in %s\n", hooksPath)
+	fmt.Println("Pushes are checked against lrc/policy.toml in the git dir, or .lrc-policy.toml on HEAD.")
+	return nil
+}
+
+// uninstallServerHook removes the lrc section from the pre-receive hook.
+func uninstallServerHook() error {
+	hooksPath, err := serverHooksPath()
+	if err != nil {
+		return err
+	}
+	if err := uninstallHook(filepath.Join(hooksPath, "pre-receive"), "pre-receive"); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(filepath.Join(hooksPath, ".lrc_backups"))
+	return nil
+}
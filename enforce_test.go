@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadRefUpdates(t *testing.T) {
+	input := "0000000000000000000000000000000000000000 1111111111111111111111111111111111111111 refs/heads/main\n\n" +
+		"2222222222222222222222222222222222222222 0000000000000000000000000000000000000000 refs/heads/old\n"
+	updates, err := readRefUpdates(strings.NewReader(input))
+	if err != nil || len(updates) != 2 {
+		t.Fatalf("readRefUpdates = %+v, %v", updates, err)
+	}
+	if !isZeroOID(updates[0].Old) || updates[0].Ref != "refs/heads/main" || !isZeroOID(updates[1].New) {
+		t.Errorf("unexpected updates: %+v", updates)
+	}
+	if _, err := readRefUpdates(strings.NewReader("abc refs/heads/main\n")); err == nil {
+		t.Error("malformed line should be an error")
+	}
+}
+
+func TestPolicyForBranch(t *testing.T) {
+	policy, err := parseReviewPolicy("test", []byte(`
+[[rule]]
+name = "all branches"
+allow = ["reviewed", "vouched", "skipped"]
+
+[[rule]]
+name = "release"
+branches = ["main", "release/*"]
+action = "warn"
+`))
+	if err != nil {
+		t.Fatalf("parseReviewPolicy: %v", err)
+	}
+	tests := []struct {
+		branch string
+		want   []string
+	}{
+		{"main", []string{"all branches", "release"}},
+		{"release/1.2", []string{"all branches", "release"}},
+		{"release/1.2/hotfix", []string{"all branches"}},
+		{"feature/x", []string{"all branches"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, rule := range policy.forBranch(tt.branch).Rules {
+			got = append(got, rule.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("forBranch(%q) = %v, want %v", tt.branch, got, tt.want)
+		}
+	}
+	if policy.Rules[0].Action != policyActionReject || policy.Rules[1].Action != policyActionWarn {
+		t.Errorf("unexpected actions: %+v", policy.Rules)
+	}
+
+	for _, bad := range []string{"[[rule]]\naction = \"block\"\n", "[[rule]]\nbranches = [\"[\"]\n"} {
+		if _, err := parseReviewPolicy("test", []byte(bad)); err == nil {
+			t.Errorf("policy %q should be rejected", bad)
+		}
+	}
+}
+
+func TestCheckRefUpdates(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, message string) string {
+		t.Helper()
+		os.MkdirAll("src/payments", 0755)
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+		return runGit(t, "rev-parse", "HEAD")
+	}
+
+	base := commit("README.md", "initial")
+	commit("src/payments/a.go", "reviewed\n\n"+preCommitTrailerKey+": ran (iter:1, coverage:0%)")
+	noted := commit("src/payments/b.go", "noted")
+	unreviewed := commit("src/payments/c.go", "unreviewed")
+	docs := commit("docs.md", "docs")
+	runGit(t, "notes", "--ref", defaultNotesRef, "add", "-m", "LiveReview: Calm River", noted)
+	// The server has not seen these commits yet
+	runGit(t, "update-ref", "refs/heads/main", base)
+
+	policy := &reviewPolicy{Path: "test", Rules: []policyRule{
+		{Name: "payments", Branches: []string{"main"}, Paths: []string{"src/payments/"}, Allow: []string{auditReviewed}, Action: policyActionReject},
+	}}
+	violations, err := checkRefUpdates([]refUpdate{{Old: base, New: docs, Ref: "refs/heads/main"}}, policy, defaultNotesRef)
+	if err != nil {
+		t.Fatalf("checkRefUpdates: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Commit != unreviewed || violations[0].Branch != "main" || violations[0].Status != auditNone {
+		t.Fatalf("unexpected violations: %+v", violations)
+	}
+
+	// Branches without rules are not checked
+	if violations, _ := checkRefUpdates([]refUpdate{{Old: base, New: docs, Ref: "refs/heads/feature"}}, policy, defaultNotesRef); len(violations) != 0 {
+		t.Errorf("feature branch should not be checked: %+v", violations)
+	}
+
+	// A note pushed in the same push counts before it lands on its ref
+	runGit(t, "notes", "--ref", defaultNotesRef, "add", "-f", "-m", "LiveReview: Quiet Hill", unreviewed)
+	pushedNotes := runGit(t, "rev-parse", defaultNotesRef)
+	runGit(t, "update-ref", "-d", defaultNotesRef)
+	updates := []refUpdate{
+		{Old: base, New: docs, Ref: "refs/heads/main"},
+		{Old: "0000000000000000000000000000000000000000", New: pushedNotes, Ref: defaultNotesRef},
+	}
+	if violations, _ := checkRefUpdates(updates, policy, defaultNotesRef); len(violations) != 0 {
+		t.Errorf("pushed notes should satisfy the policy: %+v", violations)
+	}
+}
+
+func TestCheckRefUpdatesNewBranch(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, message string) string {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+		return runGit(t, "rev-parse", "HEAD")
+	}
+	zero := "0000000000000000000000000000000000000000"
+	policy := &reviewPolicy{Path: "test", Rules: []policyRule{
+		{Name: "release", Branches: []string{"release/*"}, Allow: []string{auditReviewed}, Action: policyActionReject},
+	}}
+
+	released := commit("a.txt", "released\n\n"+preCommitTrailerKey+": ran (iter:1, coverage:0%)")
+	runGit(t, "branch", "release/1")
+	unreviewed := commit("b.txt", "unreviewed")
+	// The unreviewed commit is already on an unprotected branch
+	runGit(t, "branch", "feature")
+
+	// A new release branch from it still has the commit checked; the one already on release/1 is not
+	violations, err := checkRefUpdates([]refUpdate{{Old: zero, New: unreviewed, Ref: "refs/heads/release/2"}}, policy, defaultNotesRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 || violations[0].Commit != unreviewed {
+		t.Errorf("new release branch from an unprotected branch: violations %+v", violations)
+	}
+	if violations, _ := checkRefUpdates([]refUpdate{{Old: zero, New: released, Ref: "refs/heads/release/3"}}, policy, defaultNotesRef); len(violations) != 0 {
+		t.Errorf("new release branch from release/1: violations %+v", violations)
+	}
+}
+
+func TestCheckRefUpdatesMerges(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, message string) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+	}
+
+	os.MkdirAll("src/payments", 0755)
+	commit("src/payments/a.go", "initial")
+	base := runGit(t, "rev-parse", "HEAD")
+	runGit(t, "checkout", "-q", "-b", "side")
+	commit("docs.md", "docs")
+	runGit(t, "checkout", "-q", "-")
+	commit("README.md", "readme")
+
+	// A clean merge only brings in commits that are checked on their own
+	runGit(t, "merge", "-q", "--no-ff", "--no-edit", "side")
+	clean := runGit(t, "rev-parse", "HEAD")
+
+	// An evil merge adds code that is in neither parent
+	runGit(t, "checkout", "-q", "side")
+	commit("notes.md", "more docs")
+	runGit(t, "checkout", "-q", "-")
+	runGit(t, "merge", "-q", "--no-ff", "--no-commit", "side")
+	if err := os.WriteFile("src/payments/evil.go", []byte("package payments\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "src/payments/evil.go")
+	runGit(t, "commit", "-q", "--no-edit")
+	evil := runGit(t, "rev-parse", "HEAD")
+	runGit(t, "update-ref", "refs/heads/main", base)
+	runGit(t, "branch", "-D", "side")
+
+	policy := &reviewPolicy{Path: "test", Rules: []policyRule{
+		{Name: "payments", Branches: []string{"main"}, Paths: []string{"src/payments/"}, Allow: []string{auditReviewed}, Action: policyActionReject},
+	}}
+	if violations, err := checkRefUpdates([]refUpdate{{Old: base, New: clean, Ref: "refs/heads/main"}}, policy, defaultNotesRef); err != nil || len(violations) != 0 {
+		t.Errorf("clean merge: violations %+v, %v", violations, err)
+	}
+	violations, err := checkRefUpdates([]refUpdate{{Old: base, New: evil, Ref: "refs/heads/main"}}, policy, defaultNotesRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 1 || violations[0].Commit != evil || strings.Join(violations[0].Files, ",") != "src/payments/evil.go" {
+		t.Errorf("evil merge: violations %+v", violations)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Exit codes for review commands, kept clear of the hook decision codes (0-4)
+// so CI scripts can tell them apart.
+const (
+	exitFindings     = 10 // --fail-on/--fail-on-category matched at least one comment
+	exitAPIError     = 11 // the LiveReview API rejected a request, was unreachable, or the review failed
+	exitTimeout      = 12 // the review did not complete within --timeout
+	exitInvalidInput = 13 // bad flags, config values or diff source arguments
+)
+
+// errReviewTimeout is returned when polling gives up before the review completes.
+var errReviewTimeout = errors.New("timeout waiting for review completion")
+
+// errReviewFailed is returned when the API reports the review itself as failed.
+var errReviewFailed = errors.New("review failed")
+
+// severityRanks orders comment severities for --fail-on. Unknown or empty severities rank as info.
+var severityRanks = map[string]int{
+	"info":     0,
+	"warning":  1,
+	"error":    2,
+	"critical": 3,
+}
+
+// severityOrder is the display order for per-severity counts.
+var severityOrder = []string{"critical", "error", "warning", "info"}
+
+// invalidInputError marks errors caused by the user's flags, config or arguments.
+type invalidInputError struct {
+	err error
+}
+
+func (e *invalidInputError) Error() string { return e.err.Error() }
+func (e *invalidInputError) Unwrap() error { return e.err }
+
+// invalidInput formats an error that exits with exitInvalidInput.
+func invalidInput(format string, args ...interface{}) error {
+	return &invalidInputError{err: fmt.Errorf(format, args...)}
+}
+
+// reviewExitError maps a review error onto the documented exit codes.
+// Errors that already carry an exit code are returned unchanged, and anything
+// unclassified keeps the default exit code 1.
+func reviewExitError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(cli.ExitCoder); ok {
+		return err
+	}
+
+	var apiErr *APIError
+	var urlErr *url.Error
+	var inputErr *invalidInputError
+	switch {
+	case errors.As(err, &inputErr):
+		return cli.Exit(err.Error(), exitInvalidInput)
+	case errors.Is(err, errReviewTimeout):
+		return cli.Exit(err.Error(), exitTimeout)
+	case errors.As(err, &apiErr), errors.As(err, &urlErr), errors.Is(err, errReviewFailed):
+		return cli.Exit(err.Error(), exitAPIError)
+	}
+	return err
+}
+
+// normalizeSeverity lowercases a comment severity, treating empty as info.
+func normalizeSeverity(severity string) string {
+	s := strings.ToLower(strings.TrimSpace(severity))
+	if s == "" {
+		return "info"
+	}
+	return s
+}
+
+// severityRank returns the rank of a severity for threshold comparisons.
+func severityRank(severity string) int {
+	return severityRanks[normalizeSeverity(severity)]
+}
+
+// parseFailOn validates a --fail-on threshold.
+func parseFailOn(value string) (string, error) {
+	s := normalizeSeverity(value)
+	if _, ok := severityRanks[s]; !ok || strings.TrimSpace(value) == "" {
+		return "", invalidInput("invalid --fail-on %q (must be one of: critical, error, warning, info)", value)
+	}
+	return s, nil
+}
+
+// countSeverities tallies comments per normalized severity.
+func countSeverities(files []diffReviewFileResult) map[string]int {
+	counts := make(map[string]int)
+	for _, file := range files {
+		for _, comment := range file.Comments {
+			counts[normalizeSeverity(comment.Severity)]++
+		}
+	}
+	return counts
+}
+
+// formatSeveritySummary renders counts as "Findings: 1 critical, 0 error, 2 warning, 5 info".
+// Severities outside the known set are appended after the known ones.
+func formatSeveritySummary(counts map[string]int) string {
+	parts := make([]string, 0, len(severityOrder))
+	for _, s := range severityOrder {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+	}
+	var extra []string
+	for s, n := range counts {
+		if _, ok := severityRanks[s]; !ok {
+			extra = append(extra, fmt.Sprintf("%d %s", n, s))
+		}
+	}
+	sort.Strings(extra)
+	return "Findings: " + strings.Join(append(parts, extra...), ", ")
+}
+
+// failOnMatches counts comments that trip the gate. A comment matches when its
+// severity is at or above failOn (if set) and its category is listed (if any are).
+func failOnMatches(files []diffReviewFileResult, failOn string, categories []string) int {
+	if failOn == "" && len(categories) == 0 {
+		return 0
+	}
+	wantCategory := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		wantCategory[strings.ToLower(strings.TrimSpace(c))] = true
+	}
+
+	matches := 0
+	for _, file := range files {
+		for _, comment := range file.Comments {
+			if failOn != "" && severityRank(comment.Severity) < severityRanks[failOn] {
+				continue
+			}
+			if len(wantCategory) > 0 && !wantCategory[strings.ToLower(strings.TrimSpace(comment.Category))] {
+				continue
+			}
+			matches++
+		}
+	}
+	return matches
+}
+
+// enforceFailOn returns an exitFindings error when the gate configured in opts is
+// tripped. Pretty output already ends with the per-severity summary; for other
+// formats it is written to stderr so stdout stays machine-readable.
+func enforceFailOn(result *diffReviewResponse, opts reviewOptions) error {
+	if result == nil || !opts.gating() {
+		return nil
+	}
+
+	if opts.output != "pretty" {
+		fmt.Fprintln(os.Stderr, formatSeveritySummary(countSeverities(result.Files)))
+	}
+
+	matches := failOnMatches(result.Files, opts.failOn, opts.failOnCategories)
+	if matches == 0 {
+		return nil
+	}
+
+	var rule []string
+	if opts.failOn != "" {
+		rule = append(rule, "severity >= "+opts.failOn)
+	}
+	if len(opts.failOnCategories) > 0 {
+		rule = append(rule, "category in ["+strings.Join(opts.failOnCategories, ", ")+"]")
+	}
+	return cli.Exit(fmt.Sprintf("LiveReview: %d finding(s) matched %s", matches, strings.Join(rule, " and ")), exitFindings)
+}
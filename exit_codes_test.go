@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestFailOnMatches(t *testing.T) {
+	files := []diffReviewFileResult{
+		{FilePath: "a.go", Comments: []diffReviewComment{
+			{Severity: "critical", Category: "security"},
+			{Severity: "warning", Category: "style"},
+			{Severity: "", Category: "docs"},
+		}},
+		{FilePath: "b.go", Comments: []diffReviewComment{
+			{Severity: "ERROR", Category: "Bug"},
+		}},
+	}
+
+	tests := []struct {
+		failOn     string
+		categories []string
+		want       int
+	}{
+		{"", nil, 0},
+		{"critical", nil, 1},
+		{"error", nil, 2},
+		{"warning", nil, 3},
+		{"info", nil, 4},
+		{"", []string{"bug"}, 1},
+		{"warning", []string{"style", "docs"}, 1},
+		{"critical", []string{"bug"}, 0},
+	}
+	for _, tt := range tests {
+		if got := failOnMatches(files, tt.failOn, tt.categories); got != tt.want {
+			t.Errorf("failOnMatches(%q, %v) = %d, want %d", tt.failOn, tt.categories, got, tt.want)
+		}
+	}
+
+	want := "Findings: 1 critical, 1 error, 1 warning, 1 info"
+	if got := formatSeveritySummary(countSeverities(files)); got != want {
+		t.Errorf("formatSeveritySummary = %q, want %q", got, want)
+	}
+}
+
+func TestReviewExitError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{invalidInput("bad flag"), exitInvalidInput},
+		{fmt.Errorf("failed to poll review: %w", errReviewTimeout), exitTimeout},
+		{fmt.Errorf("failed to submit review: %w", &APIError{StatusCode: 500}), exitAPIError},
+		{&chunkedReviewError{message: "chunk 2/2", errs: []error{errReviewFailed}}, exitAPIError},
+		{cli.Exit("", decisionAbort), decisionAbort},
+		{errors.New("no diff content collected"), 1},
+	}
+	for _, tt := range tests {
+		got := 1
+		var coder cli.ExitCoder
+		if errors.As(reviewExitError(tt.err), &coder) {
+			got = coder.ExitCode()
+		}
+		if got != tt.want {
+			t.Errorf("reviewExitError(%v) exit code = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 )
 
-//go:embed hooks/prepare-commit-msg.sh hooks/commit-msg.sh hooks/post-commit.sh hooks/pre-commit.sh hooks/dispatcher.sh
+//go:embed hooks/prepare-commit-msg.sh hooks/commit-msg.sh hooks/post-commit.sh hooks/pre-commit.sh hooks/dispatcher.sh hooks/pre-receive.sh hooks/pre-push.sh
 var hookTemplatesFS embed.FS
 
 const (
@@ -16,6 +16,7 @@ const (
 	hookCommitMessageFilePlaceholder = "__LRC_COMMIT_MESSAGE_FILE__"
 	hookPushRequestFilePlaceholder   = "__LRC_PUSH_REQUEST_FILE__"
 	hookNamePlaceholder              = "__HOOK_NAME__"
+	hookBinaryPlaceholder            = "__LRC_BIN__"
 )
 
 func renderHookTemplate(path string, replacements map[string]string) string {
@@ -2,25 +2,36 @@ __LRC_MARKER_BEGIN__
 # lrc_version: __LRC_VERSION__
 # This section is managed by LiveReview CLI (lrc)
 # Manual changes within markers will be lost on hook updates
-STATE_FILE=".git/livereview_state"
-LOCK_DIR=".git/livereview_state.lock"
+# Attestations and commit state are per worktree; the disable marker is shared
+GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo "$GIT_DIR")"
+STATE_FILE="$GIT_DIR/livereview_state"
+LOCK_DIR="$GIT_DIR/livereview_state.lock"
 COMMIT_MSG_FILE="$1"
-COMMIT_MSG_OVERRIDE=".git/__LRC_COMMIT_MESSAGE_FILE__"
-LRC_DIR=".git/lrc"
-ATTEST_DIR="$LRC_DIR/attestations"
-DISABLED_FILE="$LRC_DIR/disabled"
+COMMIT_MSG_OVERRIDE="$GIT_DIR/__LRC_COMMIT_MESSAGE_FILE__"
+ATTEST_DIR="$GIT_DIR/lrc/attestations"
+DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
 
 if [ -f "$DISABLED_FILE" ]; then
 	exit 0
 fi
 
-# Skip during Git sequencer operations to avoid re-triggering on rebase/merge/cherry-pick
-GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
-if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/MERGE_HEAD" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
-	echo "LiveReview: skipping during rebase/merge/cherry-pick" >&2
+# Skip while a rebase or cherry-pick replays commits (prepare-commit-msg handles their attestations)
+if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
+	echo "LiveReview: skipping during rebase/cherry-pick" >&2
 	exit 0
 fi
 
+# Merges: only a conflict resolution needs a review (lrc reviews it against the automatic merge result).
+# Without an automatic merge result (old git, octopus merge, lrc not on PATH) the merge is checked like any commit.
+if [ -f "$GIT_DIR/MERGE_HEAD" ]; then
+	AUTO_MERGE_TREE="$(lrc auto-merge-tree 2>/dev/null || true)"
+	if [ -n "$AUTO_MERGE_TREE" ] && git diff --cached --quiet "$AUTO_MERGE_TREE" 2>/dev/null; then
+		echo "LiveReview: merge without conflict resolution; nothing to review" >&2
+		exit 0
+	fi
+fi
+
 # Non-interactive: require attestation for current staged tree before trailers
 if [ ! -t 1 ]; then
 	TREE_HASH="$(git write-tree 2>/dev/null || true)"
@@ -50,7 +61,18 @@ fi
 
 TRAILER_ADDED=0
 
+# Drop LiveReview trailers the message already has, e.g. from the commit an amend replaces
+strip_lrc_trailers() {
+	STRIPPED_MSG_FILE="$COMMIT_MSG_FILE.lrc.$$"
+	grep -v -e "^LiveReview Pre-Commit Check:" -e "^LiveReview-Attestation:" "$COMMIT_MSG_FILE" > "$STRIPPED_MSG_FILE"
+	cat "$STRIPPED_MSG_FILE" > "$COMMIT_MSG_FILE"
+	rm -f "$STRIPPED_MSG_FILE"
+}
+
 add_trailer() {
+	if [ $TRAILER_ADDED -eq 0 ]; then
+		strip_lrc_trailers
+	fi
 	echo "" >> "$COMMIT_MSG_FILE"
 	echo "$1" >> "$COMMIT_MSG_FILE"
 	TRAILER_ADDED=1
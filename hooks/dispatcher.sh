@@ -1,11 +1,36 @@
 __LRC_MARKER_BEGIN__
 # lrc_version: __LRC_VERSION__
 # LiveReview global dispatcher for __HOOK_NAME__
-SCRIPT_DIR="$(cd "$(dirname "$0")" && pwd)"
+SCRIPT_DIR="$(cd "$(dirname "$0")" && pwd -P)"
 LRC_DIR="$SCRIPT_DIR/lrc"
-LRC_DISABLED_FILE=".git/lrc/disabled"
+# Linked worktrees share the disable marker and the repo hooks with the main one
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo .git)"
+LRC_DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
 LRC_HOOK="$LRC_DIR/__HOOK_NAME__"
-LOCAL_HOOK=".git/hooks/__HOOK_NAME__"
+LOCAL_HOOK="$LRC_COMMON_DIR/hooks/__HOOK_NAME__"
+
+# With a repo-local install this dispatcher is the repo hook itself; never run it twice
+if [ "$(cd "$LRC_COMMON_DIR/hooks" 2>/dev/null && pwd -P)" = "$SCRIPT_DIR" ]; then
+	LOCAL_HOOK=""
+fi
+
+# Hooks like pre-push read refs from stdin; keep a copy so both hooks see it
+LRC_STDIN=""
+case "__HOOK_NAME__" in
+pre-push)
+	LRC_STDIN="$(mktemp "${TMPDIR:-/tmp}/lrc-hook.XXXXXX")" || exit 1
+	trap 'rm -f "$LRC_STDIN"' EXIT
+	cat >"$LRC_STDIN"
+	;;
+esac
+
+run_hook() {
+	if [ -n "$LRC_STDIN" ]; then
+		"$@" <"$LRC_STDIN"
+	else
+		"$@"
+	fi
+}
 
 if [ -f "$LRC_DISABLED_FILE" ]; then
 	LRC_DISABLED=1
@@ -14,7 +39,7 @@ else
 fi
 
 if [ $LRC_DISABLED -eq 0 ] && [ -x "$LRC_HOOK" ]; then
-	"$LRC_HOOK" "$@"
+	run_hook "$LRC_HOOK" "$@"
 	LRC_STATUS=$?
 else
 	LRC_STATUS=0
@@ -24,11 +49,16 @@ if [ $LRC_STATUS -ne 0 ]; then
 	exit $LRC_STATUS
 fi
 
-if [ -x "$LOCAL_HOOK" ]; then
-	"$LOCAL_HOOK" "$@"
+if [ -n "$LOCAL_HOOK" ] && [ -x "$LOCAL_HOOK" ]; then
+	run_hook "$LOCAL_HOOK" "$@"
 	LOCAL_STATUS=$?
 	if [ $LOCAL_STATUS -ne 0 ]; then
 		exit $LOCAL_STATUS
 	fi
 fi
+
+# Hand the saved stdin to any hook code after this section
+if [ -n "$LRC_STDIN" ]; then
+	exec <"$LRC_STDIN"
+fi
 __LRC_MARKER_END__
@@ -3,10 +3,12 @@ __LRC_MARKER_BEGIN__
 # This section is managed by LiveReview CLI (lrc)
 # Manual changes within markers will be lost on hook updates
 
-PUSH_FLAG=".git/__LRC_PUSH_REQUEST_FILE__"
-LRC_DIR=".git/lrc"
-ATTEST_DIR="$LRC_DIR/attestations"
-DISABLED_FILE="$LRC_DIR/disabled"
+# Attestations and the push request are per worktree; the disable marker is shared
+GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo "$GIT_DIR")"
+PUSH_FLAG="$GIT_DIR/__LRC_PUSH_REQUEST_FILE__"
+ATTEST_DIR="$GIT_DIR/lrc/attestations"
+DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
 UPSTREAM=""
 UPSTREAM_REMOTE=""
 UPSTREAM_BRANCH=""
@@ -15,13 +17,6 @@ if [ -f "$DISABLED_FILE" ]; then
 	exit 0
 fi
 
-# Skip during Git sequencer operations to avoid re-triggering on rebase/merge/cherry-pick
-GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
-if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/MERGE_HEAD" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
-	echo "LiveReview: skipping during rebase/merge/cherry-pick" >&2
-	exit 0
-fi
-
 cleanup_flag() {
 	rm -f "$PUSH_FLAG" 2>/dev/null || true
 }
@@ -34,11 +29,28 @@ cleanup_attestation() {
 	fi
 }
 
+# A rebase or cherry-pick replaying commits: store an attestation re-signed by
+# prepare-commit-msg as a note, and leave notes, session history and pushes alone
+if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
+	if command -v lrc >/dev/null 2>&1; then
+		lrc attestation-note HEAD 2>/dev/null || true
+	fi
+	cleanup_attestation
+	exit 0
+fi
+
+# Keep the signed attestation as a git note before it is cleared (only with signature_storage = "note")
+if command -v lrc >/dev/null 2>&1; then
+	lrc attestation-note HEAD 2>/dev/null || true
+fi
+
 # Always clear attestation for the committed tree
 cleanup_attestation
 
-# Clean up review session DB for this branch (best-effort)
 if command -v lrc >/dev/null 2>&1; then
+	# Attach the review of the committed tree as a git note (only when notes are enabled)
+	lrc notes add --if-enabled HEAD 2>/dev/null || true
+	# Archive this branch's review sessions under the new commit (best-effort)
 	lrc review-cleanup 2>/dev/null || true
 fi
 
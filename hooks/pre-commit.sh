@@ -3,18 +3,29 @@ __LRC_MARKER_BEGIN__
 # This section is managed by LiveReview CLI (lrc)
 # Manual changes within markers will be lost on hook updates
 
-DISABLED_FILE=".git/lrc/disabled"
+GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo "$GIT_DIR")"
+DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
 if [ -f "$DISABLED_FILE" ]; then
 	exit 0
 fi
 
-# Skip during Git sequencer operations to avoid re-triggering on rebase/merge/cherry-pick
-GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
-if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/MERGE_HEAD" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
-	echo "LiveReview: skipping during rebase/merge/cherry-pick" >&2
+# Skip while a rebase or cherry-pick replays commits (prepare-commit-msg handles their attestations)
+if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
+	echo "LiveReview: skipping during rebase/cherry-pick" >&2
 	exit 0
 fi
 
+# Merges: only a conflict resolution needs a review (lrc reviews it against the automatic merge result).
+# Without an automatic merge result (old git, octopus merge, lrc not on PATH) the merge is checked like any commit.
+if [ -f "$GIT_DIR/MERGE_HEAD" ]; then
+	AUTO_MERGE_TREE="$(lrc auto-merge-tree 2>/dev/null || true)"
+	if [ -n "$AUTO_MERGE_TREE" ] && git diff --cached --quiet "$AUTO_MERGE_TREE" 2>/dev/null; then
+		echo "LiveReview: merge without conflict resolution; nothing to review" >&2
+		exit 0
+	fi
+fi
+
 # Detect interactive terminal (stdout check; git redirects stdin)
 if [ -t 1 ]; then
 	echo "LiveReview pre-commit: interactive environment detected; no-op"
@@ -23,13 +34,18 @@ fi
 
 # Non-interactive: require attestation for current staged tree
 TREE_HASH="$(git write-tree 2>/dev/null || true)"
-ATTEST_FILE=".git/lrc/attestations/$TREE_HASH.json"
+ATTEST_FILE="$GIT_DIR/lrc/attestations/$TREE_HASH.json"
 
 if [ -z "$TREE_HASH" ]; then
 	echo "LiveReview pre-commit: failed to compute staged tree hash; run 'lrc review --staged' before committing"
 	exit 1
 fi
 
+# An attestation for the same patch on another tree (e.g. before a rebase) carries over
+if [ ! -f "$ATTEST_FILE" ] && command -v lrc >/dev/null 2>&1; then
+	lrc attestation-reuse || true
+fi
+
 if [ ! -f "$ATTEST_FILE" ]; then
 	printf "You are using LiveReview. You must run 'lrc review', 'lrc review --skip', or 'lrc review --vouch' to attest your changes before you commit." 
 	exit 1
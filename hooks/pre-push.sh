@@ -0,0 +1,19 @@
+__LRC_MARKER_BEGIN__
+# lrc_version: __LRC_VERSION__
+# This section is managed by LiveReview CLI (lrc)
+# Manual changes within markers will be lost on hook updates
+
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo .git)"
+DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
+if [ -f "$DISABLED_FILE" ]; then
+	exit 0
+fi
+
+if ! command -v lrc >/dev/null 2>&1; then
+	echo "LiveReview pre-push: lrc not found in PATH; skipping review" >&2
+	exit 0
+fi
+
+# git passes <remote> <url> as arguments and the pushed refs on stdin
+exec lrc pre-push "$@"
+__LRC_MARKER_END__
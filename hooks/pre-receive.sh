@@ -0,0 +1,25 @@
+__LRC_MARKER_BEGIN__
+# lrc_version: __LRC_VERSION__
+# LiveReview server-side review policy check
+# This section is managed by LiveReview CLI (lrc)
+# Manual changes within markers will be lost on hook updates
+
+LRC_BIN="__LRC_BIN__"
+if [ ! -x "$LRC_BIN" ]; then
+	LRC_BIN="$(command -v lrc 2>/dev/null || true)"
+fi
+if [ -z "$LRC_BIN" ]; then
+	echo "lrc: not found on the server; rejecting push because the review policy cannot be checked" >&2
+	exit 1
+fi
+
+# Keep the pushed ref list so any hook code after this section can still read it
+LRC_PUSH_INPUT="$(mktemp "${TMPDIR:-/tmp}/lrc-push.XXXXXX")" || exit 1
+cat >"$LRC_PUSH_INPUT"
+if ! "$LRC_BIN" enforce <"$LRC_PUSH_INPUT"; then
+	rm -f "$LRC_PUSH_INPUT"
+	exit 1
+fi
+exec <"$LRC_PUSH_INPUT"
+rm -f "$LRC_PUSH_INPUT"
+__LRC_MARKER_END__
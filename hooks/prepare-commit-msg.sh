@@ -4,27 +4,111 @@ __LRC_MARKER_BEGIN__
 # Manual changes within markers will be lost on hook updates
 
 COMMIT_MSG_FILE="$1"
+COMMIT_SOURCE="$2"
+COMMIT_SHA="$3"
 SKIP_REVIEW="${LRC_SKIP_REVIEW:-}" 
-LRC_DIR=".git/lrc"
-ATTEST_DIR="$LRC_DIR/attestations"
-DISABLED_FILE="$LRC_DIR/disabled"
+# Attestations and commit state are per worktree; the disable marker is shared
+GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
+LRC_COMMON_DIR="$(git rev-parse --git-common-dir 2>/dev/null || echo "$GIT_DIR")"
+ATTEST_DIR="$GIT_DIR/lrc/attestations"
+DISABLED_FILE="$LRC_COMMON_DIR/lrc/disabled"
 
 if [ -f "$DISABLED_FILE" ]; then
 	exit 0
 fi
 
-# Skip during Git sequencer operations to avoid re-triggering on rebase/merge/cherry-pick
-GIT_DIR="$(git rev-parse --git-dir 2>/dev/null || echo .git)"
-if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/MERGE_HEAD" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
-	echo "LiveReview: skipping during rebase/merge/cherry-pick" >&2
+# A rebase or cherry-pick replays commits with their messages: with rebase_reattest on,
+# lrc keeps the attestation of commits whose patch is unchanged and drops the others
+if [ -d "$GIT_DIR/rebase-apply" ] || [ -d "$GIT_DIR/rebase-merge" ] || [ -f "$GIT_DIR/CHERRY_PICK_HEAD" ]; then
+	if command -v lrc >/dev/null 2>&1; then
+		lrc rebase-attest "$COMMIT_MSG_FILE" || true
+	fi
 	exit 0
 fi
 
+# Merges: only a conflict resolution needs a review (lrc reviews it against the automatic merge result).
+# Without an automatic merge result (old git, octopus merge, lrc not on PATH) the merge is checked like any commit.
+if [ -f "$GIT_DIR/MERGE_HEAD" ]; then
+	AUTO_MERGE_TREE="$(lrc auto-merge-tree 2>/dev/null || true)"
+	if [ -n "$AUTO_MERGE_TREE" ] && git diff --cached --quiet "$AUTO_MERGE_TREE" 2>/dev/null; then
+		echo "LiveReview: merge without conflict resolution; nothing to review" >&2
+		exit 0
+	fi
+fi
+
 # Allow explicit bypass (analogous to --no-verify)
 if [ "$LRC_SKIP_REVIEW" = "1" ]; then
 	exit 0
 fi
 
+# `git commit --amend` passes "commit HEAD", but with -m, -F or -C it passes "message" like
+# any commit. Then:
+#  - a staged tree equal to HEAD's adds nothing on top of HEAD, which only a message-only
+#    amend (or --allow-empty) commits; this check needs nothing but git
+#  - otherwise the arguments of the git process are checked where `ps -o args= -p` works
+#    (not on Git for Windows or BusyBox). Only the nearest git process counts, and only
+#    when its subcommand is commit, so other ancestors never match.
+# An amend that stages changes and passes its message on the command line is missed where
+# ps is unusable, or when the message itself mentions --amend; it is then reviewed as a
+# new commit on top of HEAD.
+is_amend() {
+	if [ "$COMMIT_SOURCE" = "commit" ] && [ "$COMMIT_SHA" = "HEAD" ]; then
+		return 0
+	fi
+	if [ ! -f "$GIT_DIR/MERGE_HEAD" ] && git rev-parse --verify --quiet HEAD >/dev/null 2>&1 &&
+		git diff --cached --quiet HEAD 2>/dev/null; then
+		return 0
+	fi
+	case "$(uname -s 2>/dev/null)" in
+	MINGW* | MSYS* | CYGWIN*) return 1 ;;
+	esac
+	pid=$PPID
+	for _ in 1 2 3; do
+		args="$(ps -o args= -p "$pid" 2>/dev/null)" || return 1
+		set -- $args
+		case "${1##*/}" in
+		git | git.exe)
+			shift
+			while [ $# -gt 0 ]; do
+				case "$1" in
+				-C | -c | --git-dir | --work-tree | --namespace)
+					[ $# -ge 2 ] || return 1
+					shift 2
+					;;
+				-*) shift ;;
+				*) break ;;
+				esac
+			done
+			[ "$1" = "commit" ] || return 1
+			for arg in "$@"; do
+				case "$arg" in
+				--amend) break ;;
+				--) return 1 ;;
+				esac
+			done
+			[ "$arg" = "--amend" ] || return 1
+			# ps loses the quoting, so a -m message mentioning --amend looks the same
+			! grep -q -e '--amend' "$COMMIT_MSG_FILE" 2>/dev/null
+			return
+			;;
+		esac
+		pid="$(ps -o ppid= -p "$pid" 2>/dev/null | tr -d ' ')"
+		[ -n "$pid" ] || break
+	done
+	return 1
+}
+
+LRC_AMEND_FLAG=""
+if is_amend; then
+	LRC_AMEND_FLAG="--amend"
+fi
+
+# An attestation for the same patch on another tree (e.g. before a rebase) carries over
+TREE_HASH="$(git write-tree 2>/dev/null || true)"
+if [ -n "$TREE_HASH" ] && [ ! -f "$ATTEST_DIR/$TREE_HASH.json" ] && command -v lrc >/dev/null 2>&1; then
+	lrc attestation-reuse || true
+fi
+
 # Detect if running in TTY (check stdout, not stdin - Git redirects stdin)
 if [ -t 1 ]; then
 	LRC_INTERACTIVE=1
@@ -49,8 +133,8 @@ if [ "$LRC_INTERACTIVE" = "0" ]; then
 fi
 
 # State file for hook coordination
-STATE_FILE=".git/livereview_state"
-LOCK_DIR=".git/livereview_state.lock"
+STATE_FILE="$GIT_DIR/livereview_state"
+LOCK_DIR="$GIT_DIR/livereview_state.lock"
 
 # Cleanup function
 cleanup_lock() {
@@ -105,7 +189,7 @@ while ! mkdir "$LOCK_DIR" 2>/dev/null; do
 done
 
 # Capture current commit message (available in prepare-commit-msg)
-INITIAL_MSG_FILE=".git/livereview_initial_message.$$"
+INITIAL_MSG_FILE="$GIT_DIR/livereview_initial_message.$$"
 if [ -n "$COMMIT_MSG_FILE" ] && [ -f "$COMMIT_MSG_FILE" ]; then
 	cat "$COMMIT_MSG_FILE" > "$INITIAL_MSG_FILE" 2>/dev/null || true
 fi
@@ -114,10 +198,10 @@ fi
 if [ "$LRC_INTERACTIVE" = "1" ]; then
 	echo "Running LiveReview commit check..."
 	exec 2>&1
-	LRC_INITIAL_MESSAGE_FILE="$INITIAL_MSG_FILE" lrc review --staged --precommit
+	LRC_INITIAL_MESSAGE_FILE="$INITIAL_MSG_FILE" lrc review --staged --precommit $LRC_AMEND_FLAG
 	REVIEW_EXIT=$?
 else
-	LRC_INITIAL_MESSAGE_FILE="$INITIAL_MSG_FILE" lrc review --staged --output json >/dev/null 2>&1
+	LRC_INITIAL_MESSAGE_FILE="$INITIAL_MSG_FILE" lrc review --staged --output json $LRC_AMEND_FLAG >/dev/null 2>&1
 	REVIEW_EXIT=$?
 fi
 
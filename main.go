@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -25,9 +26,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/knadh/koanf/parsers/toml"
-	"github.com/knadh/koanf/providers/file"
-	"github.com/knadh/koanf/v2"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/term"
 )
@@ -177,7 +175,7 @@ var baseFlags = []cli.Flag{
 	&cli.StringFlag{
 		Name:    "output",
 		Value:   defaultOutputFormat,
-		Usage:   "output format: pretty or json",
+		Usage:   "output format: pretty, json, sarif, junit or codeclimate",
 		EnvVars: []string{"LRC_OUTPUT"},
 	},
 	&cli.StringFlag{
@@ -185,6 +183,11 @@ var baseFlags = []cli.Flag{
 		Usage:   "save formatted HTML output (GitHub-style review) to this file",
 		EnvVars: []string{"LRC_SAVE_HTML"},
 	},
+	&cli.StringFlag{
+		Name:    "save-sarif",
+		Usage:   "save the review as a SARIF 2.1.0 log to this file (for code-scanning dashboards and IDE viewers)",
+		EnvVars: []string{"LRC_SAVE_SARIF"},
+	},
 	&cli.BoolFlag{
 		Name:    "serve",
 		Usage:   "start HTTP server to serve the HTML output (auto-creates HTML when omitted)",
@@ -196,6 +199,12 @@ var baseFlags = []cli.Flag{
 		Value:   8000,
 		EnvVars: []string{"LRC_PORT"},
 	},
+	&cli.StringFlag{
+		Name:    "ui",
+		Value:   reviewUIWeb,
+		Usage:   "where interactive reviews are shown and decided: web (browser review page) or tui (full-screen terminal UI, e.g. over SSH)",
+		EnvVars: []string{"LRC_UI"},
+	},
 	&cli.BoolFlag{
 		Name:    "verbose",
 		Usage:   "enable verbose output",
@@ -217,11 +226,89 @@ var baseFlags = []cli.Flag{
 		Usage:   "force rerun by removing existing attestation/hash for current tree",
 		EnvVars: []string{"LRC_FORCE"},
 	},
+	&cli.BoolFlag{
+		Name:    "amend",
+		Usage:   "review staged changes for `git commit --amend`: only the delta against HEAD is reviewed, and HEAD's attestation carries over",
+		EnvVars: []string{"LRC_AMEND"},
+	},
 	&cli.BoolFlag{
 		Name:    "vouch",
 		Usage:   "vouch for changes manually without running AI review (records attestation with coverage stats from prior iterations)",
 		EnvVars: []string{"LRC_VOUCH"},
 	},
+	&cli.StringSliceFlag{
+		Name:    "include",
+		Usage:   "only review files matching this glob (repeatable; adds to include in config)",
+		EnvVars: []string{"LRC_INCLUDE"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "exclude",
+		Usage:   "drop files matching this glob from the diff before submitting (repeatable; adds to exclude in config)",
+		EnvVars: []string{"LRC_EXCLUDE"},
+	},
+	&cli.BoolFlag{
+		Name:    "chunk",
+		Usage:   "split diffs larger than --chunk-bytes into file-aligned chunks, each submitted as its own review",
+		EnvVars: []string{"LRC_CHUNK"},
+	},
+	&cli.IntFlag{
+		Name:    "chunk-bytes",
+		Usage:   "diff byte budget per chunk when --chunk is enabled",
+		Value:   defaultChunkBytes,
+		EnvVars: []string{"LRC_CHUNK_BYTES"},
+	},
+	&cli.BoolFlag{
+		Name:    "submodules",
+		Usage:   "with --diff-source staged, also review the staged changes inside checked-out submodules",
+		EnvVars: []string{"LRC_REVIEW_SUBMODULES"},
+	},
+	&cli.BoolFlag{
+		Name:    "context-files",
+		Usage:   "also bundle the full new-side contents of changed files and a manifest.json",
+		EnvVars: []string{"LRC_CONTEXT_FILES"},
+	},
+	&cli.IntFlag{
+		Name:    "context-max-file-bytes",
+		Usage:   "skip context files larger than this many bytes",
+		Value:   defaultContextMaxFileBytes,
+		EnvVars: []string{"LRC_CONTEXT_MAX_FILE_BYTES"},
+	},
+	&cli.IntFlag{
+		Name:    "context-max-files",
+		Usage:   "maximum number of context files per bundle",
+		Value:   defaultContextMaxFiles,
+		EnvVars: []string{"LRC_CONTEXT_MAX_FILES"},
+	},
+	&cli.StringFlag{
+		Name:    "fail-on",
+		Usage:   "exit with code 10 if any comment has this severity or higher: critical, error, warning, info (runs headless, for CI)",
+		EnvVars: []string{"LRC_FAIL_ON"},
+	},
+	&cli.StringSliceFlag{
+		Name:    "fail-on-category",
+		Usage:   "exit with code 10 if any comment has this category (repeatable; combined with --fail-on when both are set)",
+		EnvVars: []string{"LRC_FAIL_ON_CATEGORY"},
+	},
+	&cli.BoolFlag{
+		Name:    "no-stream",
+		Usage:   "poll review status instead of following the review event stream",
+		EnvVars: []string{"LRC_NO_STREAM"},
+	},
+	&cli.StringFlag{
+		Name:  "resume",
+		Usage: "reattach to a review submitted earlier instead of submitting a new one: a review ID, or \"last\" for the most recently interrupted run",
+	},
+	&cli.IntFlag{
+		Name:    "api-retries",
+		Usage:   "retries for transient LiveReview API failures (network errors, 429, 5xx); 0 disables retries",
+		Value:   defaultAPIRetries,
+		EnvVars: []string{"LRC_API_RETRIES"},
+	},
+	&cli.BoolFlag{
+		Name:    "no-default-excludes",
+		Usage:   "do not drop lockfiles and binary patches from the diff by default",
+		EnvVars: []string{"LRC_NO_DEFAULT_EXCLUDES"},
+	},
 }
 
 var debugFlags = []cli.Flag{
@@ -296,6 +383,14 @@ func main() {
 								Name:  "local",
 								Usage: "install into the current repo hooks path (respects core.hooksPath)",
 							},
+							&cli.BoolFlag{
+								Name:  "pre-push",
+								Usage: "also install the pre-push hook, which reviews the commits being pushed",
+							},
+							&cli.BoolFlag{
+								Name:  "server",
+								Usage: "install the pre-receive policy check into the current (bare) repository",
+							},
 						},
 						Action: runHooksInstall,
 					},
@@ -311,6 +406,10 @@ func main() {
 								Name:  "path",
 								Usage: "target a specific hooksPath directory for uninstall",
 							},
+							&cli.BoolFlag{
+								Name:  "server",
+								Usage: "remove the pre-receive policy check from the current (bare) repository",
+							},
 						},
 						Action: runHooksUninstall,
 					},
@@ -371,7 +470,7 @@ func main() {
 			},
 			{
 				Name:   "review-cleanup",
-				Usage:  "Clean up review session history for the current branch (called by post-commit hook)",
+				Usage:  "Archive the review sessions of the current branch under HEAD (called by post-commit hook)",
 				Hidden: true,
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
@@ -383,12 +482,400 @@ func main() {
 					return runReviewDBCleanup(c.Bool("verbose"))
 				},
 			},
+			{
+				Name:   "attestation-note",
+				Usage:  "Store the signed attestation of a commit as a git note (called by post-commit hook)",
+				Hidden: true,
+				Action: runAttestationNote,
+			},
+			{
+				Name:      "verify",
+				Usage:     "Check LiveReview trailers of commits against their signed attestations",
+				ArgsUsage: "<commit-range>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "allow-unsigned",
+						Usage: "do not fail on commits whose trailer has no signed attestation",
+					},
+					&cli.BoolFlag{
+						Name:  "allow-missing",
+						Usage: "do not fail on commits without a LiveReview trailer",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the results as JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "enable verbose output",
+					},
+				},
+				Action: runVerify,
+			},
+			{
+				Name:      "audit",
+				Usage:     "Report which commits of a range were reviewed, vouched or skipped",
+				ArgsUsage: "[<range>]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "branch to audit against when no range is given (default: origin's default branch, main or master)",
+					},
+					&cli.StringFlag{
+						Name:  "branch",
+						Value: "HEAD",
+						Usage: "branch to audit when no range is given",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Value: "table",
+						Usage: "output format: table, json or csv",
+					},
+					&cli.StringFlag{
+						Name:  "by",
+						Value: "commit",
+						Usage: "rows of the CSV output: commit, author or path",
+					},
+					&cli.IntFlag{
+						Name:  "depth",
+						Value: defaultAuditDepth,
+						Usage: "directory levels to group the per-path totals by",
+					},
+					&cli.StringFlag{
+						Name:  "policy",
+						Usage: "policy file to check commits against (default: " + defaultPolicyFile + " at the repository root)",
+					},
+				},
+				Action: runAudit,
+			},
+			{
+				Name:      "coverage",
+				Usage:     "Show which added lines of the staged changes or a range prior reviews cover",
+				ArgsUsage: "[<range>]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "output",
+						Value: "table",
+						Usage: "output format: table, json or lcov",
+					},
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "branch whose review sessions count (default: the current branch)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "include",
+						Usage: "only report on files matching this glob (repeatable)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "leave out files matching this glob (repeatable)",
+					},
+					&cli.BoolFlag{
+						Name:  "no-default-excludes",
+						Usage: "do not leave out lock files and binary patches",
+					},
+					&cli.StringFlag{
+						Name:  "save-text",
+						Usage: "write the diff with every added line marked covered or not to this path",
+					},
+					&cli.BoolFlag{
+						Name:  "serve",
+						Usage: "show the diff in the web UI with uncovered lines highlighted",
+					},
+					&cli.IntFlag{
+						Name:  "port",
+						Value: 8000,
+						Usage: "port for --serve",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "enable verbose output",
+					},
+				},
+				Action: runCoverage,
+			},
+			{
+				Name:      "pre-push",
+				Usage:     "Review the commits being pushed (called by the pre-push hook)",
+				ArgsUsage: "<remote> [<url>]",
+				Hidden:    true,
+				Flags:     baseFlags,
+				Action:    runPrePush,
+			},
+			{
+				Name:      "enforce",
+				Usage:     "Check pushed commits against the review policy (run by the server-side pre-receive hook)",
+				ArgsUsage: "[<ref> <old> <new>]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "policy",
+						Usage: "policy file (default: lrc/policy.toml in the git dir, then " + defaultPolicyFile + " on HEAD)",
+					},
+					&cli.BoolFlag{
+						Name:  "warn-only",
+						Usage: "report violations without rejecting the push",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "enable verbose output",
+					},
+				},
+				Action: runEnforce,
+			},
+			{
+				Name:   "auto-merge-tree",
+				Usage:  "Print the tree of the automatic merge being concluded (called by hooks)",
+				Hidden: true,
+				Action: runAutoMergeTree,
+			},
+			{
+				Name:      "rebase-attest",
+				Usage:     "Carry the attestation of a replayed commit over to its new version (called by prepare-commit-msg hook)",
+				ArgsUsage: "<commit-message-file>",
+				Hidden:    true,
+				Action:    runRebaseAttest,
+			},
+			{
+				Name:   "attestation-reuse",
+				Usage:  "Reuse the attestation of an earlier tree with the same staged patch (called by pre-commit hook)",
+				Hidden: true,
+				Action: runAttestationReuse,
+			},
 			{
 				Name:   "attestation-trailer",
 				Usage:  "Output the commit trailer for the current attestation (called by commit-msg hook)",
 				Hidden: true,
 				Action: runAttestationTrailer,
 			},
+			{
+				Name:  "config",
+				Usage: "Inspect the layered lrc configuration (~/.lrc.toml, <repo>/.lrc.toml, .git/lrc/config.toml)",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "show",
+						Usage: "Print the effective value of every config key",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "origin",
+								Usage: "also print the layer (file, env var or default) each value came from",
+							},
+							&cli.BoolFlag{
+								Name:  "verbose",
+								Usage: "enable verbose output",
+							},
+						},
+						Action: runConfigShow,
+					},
+				},
+			},
+			{
+				Name:  "db",
+				Usage: "Inspect and prune this repository's review DB (.git/lrc/reviews.db)",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "stats",
+						Usage: "Show how many sessions and reviews the review DB holds",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "json",
+								Usage: "print the stats as JSON",
+							},
+							&cli.BoolFlag{
+								Name:  "verbose",
+								Usage: "enable verbose output",
+							},
+						},
+						Action: runDBStats,
+					},
+					{
+						Name:  "prune",
+						Usage: "Delete archived review sessions and stored reviews beyond the retention limits (db_max_age, db_max_sessions, db_max_reviews)",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "max-age",
+								Usage: "prune archived sessions and stored reviews older than this age (e.g. 90d, 720h) or date; 0 for no limit",
+							},
+							&cli.IntFlag{
+								Name:  "max-sessions",
+								Usage: "keep at most this many archived sessions; 0 for no limit",
+							},
+							&cli.IntFlag{
+								Name:  "max-reviews",
+								Usage: "keep at most this many stored reviews; 0 for no limit",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "only report how many sessions and reviews would be pruned",
+							},
+							&cli.BoolFlag{
+								Name:  "verbose",
+								Usage: "enable verbose output",
+							},
+						},
+						Action: runDBPrune,
+					},
+				},
+			},
+			{
+				Name:  "stats",
+				Usage: "Show review habits from the review DB: action ratios, iterations, time to commit and comment trends",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "only count sessions since a date (YYYY-MM-DD) or age (e.g. 30d), or \"all\" (default: 90d)",
+					},
+					&cli.StringFlag{
+						Name:  "until",
+						Usage: "only count sessions before a date (YYYY-MM-DD) or age (e.g. 7d)",
+					},
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "only count sessions of this branch",
+					},
+					&cli.BoolFlag{
+						Name:  "current-branch",
+						Usage: "only count sessions of the current branch",
+					},
+					&cli.StringFlag{
+						Name:  "author",
+						Usage: "only count sessions whose author contains this text",
+					},
+					&cli.BoolFlag{
+						Name:  "mine",
+						Usage: "only count your own sessions (git user.name / user.email)",
+					},
+					&cli.StringFlag{
+						Name:  "bucket",
+						Value: "week",
+						Usage: "trend period: day, week or month",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the stats as JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "serve",
+						Usage: "open the stats page in the browser instead",
+					},
+					&cli.IntFlag{
+						Name:  "port",
+						Value: 8000,
+						Usage: "port for --serve",
+					},
+				},
+				Action: runStats,
+			},
+			{
+				Name:  "history",
+				Usage: "List completed reviews stored in this repository's review DB",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "only list reviews of this branch",
+					},
+					&cli.BoolFlag{
+						Name:  "current-branch",
+						Usage: "only list reviews of the current branch",
+					},
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "only list reviews since a date (YYYY-MM-DD) or age (e.g. 7d, 12h)",
+					},
+					&cli.StringFlag{
+						Name:  "until",
+						Usage: "only list reviews before a date (YYYY-MM-DD) or age (e.g. 7d, 12h)",
+					},
+					&cli.StringFlag{
+						Name:  "severity",
+						Usage: "only list reviews with a comment at or above this severity (critical, error, warning, info)",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Value: defaultHistoryLimit,
+						Usage: "maximum number of reviews to list (0 for all)",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the list as JSON",
+					},
+				},
+				Action: runHistory,
+			},
+			{
+				Name:      "show",
+				Usage:     "Re-render a stored review by ID or friendly name",
+				ArgsUsage: "<review-id|friendly-name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "output",
+						Value: defaultOutputFormat,
+						Usage: "output format: pretty, json, sarif, junit or codeclimate",
+					},
+					&cli.StringFlag{
+						Name:  "save-html",
+						Usage: "write the review as static HTML to this path",
+					},
+					&cli.BoolFlag{
+						Name:  "serve",
+						Usage: "serve the review in the web UI",
+					},
+					&cli.IntFlag{
+						Name:  "port",
+						Value: 8000,
+						Usage: "port for --serve",
+					},
+					&cli.BoolFlag{
+						Name:  "verbose",
+						Usage: "enable verbose output",
+					},
+				},
+				Action: runShow,
+			},
+			{
+				Name:  "notes",
+				Usage: "Attach reviews to commits as git notes and share them (see notes_ref)",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Attach the review of a commit's tree as a git note",
+						ArgsUsage: "[<commit>]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "review",
+								Usage: "attach this stored review (ID or friendly name) instead of looking it up by tree",
+							},
+							&cli.BoolFlag{
+								Name:   "if-enabled",
+								Usage:  "do nothing unless notes are enabled and the commit was reviewed (used by the post-commit hook)",
+								Hidden: true,
+							},
+							&cli.BoolFlag{
+								Name:  "verbose",
+								Usage: "enable verbose output",
+							},
+						},
+						Action: runNotesAdd,
+					},
+					{
+						Name:      "show",
+						Usage:     "Print the review note of a commit",
+						ArgsUsage: "[<commit>]",
+						Action:    runNotesShow,
+					},
+					{
+						Name:      "push",
+						Usage:     "Push review notes to a remote",
+						ArgsUsage: "[<remote>]",
+						Action:    runNotesPush,
+					},
+					{
+						Name:      "fetch",
+						Usage:     "Fetch review notes from a remote and merge them into the local notes",
+						ArgsUsage: "[<remote>]",
+						Action:    runNotesFetch,
+					},
+				},
+			},
 			{
 				Name:   "setup",
 				Usage:  "Guided onboarding — authenticate with Hexmos and configure LiveReview + AI",
@@ -404,44 +891,73 @@ func main() {
 }
 
 type reviewOptions struct {
-	repoName     string
-	diffSource   string
-	rangeVal     string
-	commitVal    string
-	diffFile     string
-	apiURL       string
-	apiKey       string
-	pollInterval time.Duration
-	timeout      time.Duration
-	output       string
-	saveBundle   string
-	saveJSON     string
-	saveText     string
-	saveHTML     string
-	serve        bool
-	port         int
-	verbose      bool
-	precommit    bool
-	skip         bool
-	force        bool
-	vouch        bool
-	initialMsg   string
+	repoName            string
+	diffSource          string
+	rangeVal            string
+	commitVal           string
+	diffFile            string
+	apiURL              string
+	apiKey              string
+	pollInterval        time.Duration
+	timeout             time.Duration
+	output              string
+	saveBundle          string
+	saveJSON            string
+	saveText            string
+	saveHTML            string
+	saveSARIF           string
+	serve               bool
+	port                int
+	ui                  string
+	verbose             bool
+	precommit           bool
+	skip                bool
+	force               bool
+	vouch               bool
+	initialMsg          string
+	pathFilter          diffPathFilter
+	chunk               bool
+	chunkBytes          int
+	contextFiles        bool
+	contextMaxFileBytes int
+	contextMaxFiles     int
+	failOn              string
+	failOnCategories    []string
+	noStream            bool
+	apiRetries          int
+	resume              string
+	amend               bool
+	submodules          bool
+}
+
+// gating reports whether the review gates on findings (--fail-on/--fail-on-category).
+func (o reviewOptions) gating() bool {
+	return o.failOn != "" || len(o.failOnCategories) > 0
 }
 
 func runReviewSimple(c *cli.Context) error {
 	opts, err := buildOptionsFromContext(c, false)
 	if err != nil {
-		return err
+		return reviewExitError(&invalidInputError{err: err})
 	}
-	return runReviewWithOptions(opts)
+	return finishReview(opts, runReviewWithOptions(opts))
 }
 
 func runReviewDebug(c *cli.Context) error {
 	opts, err := buildOptionsFromContext(c, true)
 	if err != nil {
+		return reviewExitError(&invalidInputError{err: err})
+	}
+	return finishReview(opts, runReviewWithOptions(opts))
+}
+
+// finishReview maps review errors onto the documented exit codes. Pre-commit runs keep
+// plain errors (exit 1) because the commit flow treats any failure as an abort.
+func finishReview(opts reviewOptions, err error) error {
+	if opts.precommit {
 		return err
 	}
-	return runReviewWithOptions(opts)
+	return reviewExitError(err)
 }
 
 func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions, error) {
@@ -456,24 +972,58 @@ func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions,
 	}
 
 	opts := reviewOptions{
-		repoName:   c.String("repo-name"),
-		rangeVal:   c.String("range"),
-		commitVal:  c.String("commit"),
-		diffFile:   c.String("diff-file"),
-		apiURL:     c.String("api-url"),
-		apiKey:     c.String("api-key"),
-		output:     c.String("output"),
-		saveHTML:   c.String("save-html"),
-		serve:      c.Bool("serve"),
-		port:       c.Int("port"),
-		verbose:    c.Bool("verbose"),
-		precommit:  c.Bool("precommit"),
-		skip:       c.Bool("skip"),
-		force:      c.Bool("force"),
-		vouch:      c.Bool("vouch"),
-		saveJSON:   c.String("save-json"),
-		saveText:   c.String("save-text"),
-		initialMsg: initialMsg,
+		repoName:            c.String("repo-name"),
+		rangeVal:            c.String("range"),
+		commitVal:           c.String("commit"),
+		diffFile:            c.String("diff-file"),
+		apiURL:              c.String("api-url"),
+		apiKey:              c.String("api-key"),
+		output:              c.String("output"),
+		saveHTML:            c.String("save-html"),
+		saveSARIF:           c.String("save-sarif"),
+		noStream:            c.Bool("no-stream"),
+		apiRetries:          c.Int("api-retries"),
+		resume:              c.String("resume"),
+		serve:               c.Bool("serve"),
+		port:                c.Int("port"),
+		ui:                  c.String("ui"),
+		verbose:             c.Bool("verbose"),
+		precommit:           c.Bool("precommit"),
+		skip:                c.Bool("skip"),
+		force:               c.Bool("force"),
+		vouch:               c.Bool("vouch"),
+		amend:               c.Bool("amend"),
+		saveJSON:            c.String("save-json"),
+		saveText:            c.String("save-text"),
+		chunk:               c.Bool("chunk"),
+		chunkBytes:          c.Int("chunk-bytes"),
+		contextFiles:        c.Bool("context-files"),
+		contextMaxFileBytes: c.Int("context-max-file-bytes"),
+		contextMaxFiles:     c.Int("context-max-files"),
+		submodules:          c.Bool("submodules"),
+		initialMsg:          initialMsg,
+	}
+
+	if includeDebug {
+		opts.pollInterval = c.Duration("poll-interval")
+		opts.timeout = c.Duration("timeout")
+		opts.saveBundle = c.String("save-bundle")
+	} else {
+		opts.pollInterval = defaultPollInterval
+		opts.timeout = defaultTimeout
+	}
+
+	// Fill anything not given on the command line from ~/.lrc.toml and the repo config layers
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		return reviewOptions{}, err
+	}
+	if err := applyConfigToOptions(c, cfg, &opts); err != nil {
+		return reviewOptions{}, err
+	}
+	opts.pathFilter = buildPathFilter(cfg, c.StringSlice("include"), c.StringSlice("exclude"), c.Bool("no-default-excludes"))
+	if opts.ui != reviewUIWeb && opts.ui != reviewUITUI {
+		return reviewOptions{}, fmt.Errorf("invalid --ui %q (must be web or tui)", opts.ui)
 	}
 
 	if opts.skip || opts.vouch {
@@ -483,7 +1033,47 @@ func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions,
 		return reviewOptions{}, fmt.Errorf("cannot use --skip and --vouch together")
 	}
 
-	staged := c.Bool("staged")
+	if failOn := c.String("fail-on"); failOn != "" {
+		if opts.failOn, err = parseFailOn(failOn); err != nil {
+			return reviewOptions{}, err
+		}
+	}
+	opts.failOnCategories = c.StringSlice("fail-on-category")
+	if opts.gating() {
+		if opts.skip || opts.vouch {
+			return reviewOptions{}, fmt.Errorf("--fail-on/--fail-on-category cannot be combined with --skip or --vouch")
+		}
+		if c.IsSet("serve") && opts.serve {
+			return reviewOptions{}, fmt.Errorf("--fail-on/--fail-on-category run headless and cannot be combined with --serve")
+		}
+		// Gated runs are for CI: no browser UI, no commit prompts
+		opts.serve = false
+		opts.precommit = false
+	}
+
+	if opts.resume != "" {
+		if opts.skip || opts.vouch || opts.precommit {
+			return reviewOptions{}, fmt.Errorf("--resume cannot be combined with --skip, --vouch or --precommit")
+		}
+		for _, name := range []string{"commit", "range", "diff-file"} {
+			if c.IsSet(name) {
+				return reviewOptions{}, fmt.Errorf("--resume reattaches to an existing review and cannot be combined with --%s", name)
+			}
+		}
+	}
+
+	if opts.amend {
+		if opts.gating() || opts.resume != "" {
+			return reviewOptions{}, fmt.Errorf("--amend cannot be combined with --fail-on, --fail-on-category or --resume")
+		}
+		for _, name := range []string{"commit", "range", "diff-file"} {
+			if c.IsSet(name) {
+				return reviewOptions{}, fmt.Errorf("--amend reviews staged changes and cannot be combined with --%s", name)
+			}
+		}
+	}
+
+	staged := c.Bool("staged") || opts.amend
 	diffSource := c.String("diff-source")
 
 	if opts.diffFile != "" {
@@ -494,8 +1084,8 @@ func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions,
 		opts.precommit = false
 		opts.skip = false
 		// Auto-enable serve mode for post-commit reviews (user can view in browser)
-		// Only if not explicitly set by user via flags
-		if !c.IsSet("serve") && !c.IsSet("save-html") {
+		// Only if not explicitly set by user via flags or config
+		if !c.IsSet("serve") && !cfg.Exists("serve") && !c.IsSet("save-html") && !opts.gating() && (opts.output == "" || opts.output == defaultOutputFormat) {
 			opts.serve = true
 		}
 	} else if opts.rangeVal != "" {
@@ -505,20 +1095,12 @@ func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions,
 	}
 
 	if diffSource == "" {
-		diffSource = "staged"
+		// LRC_DIFF_SOURCE and the diff_source key, for lrc review as well as review-debug
+		diffSource, _ = cfg.Effective("diff_source")
 	}
 
 	opts.diffSource = diffSource
 
-	if includeDebug {
-		opts.pollInterval = c.Duration("poll-interval")
-		opts.timeout = c.Duration("timeout")
-		opts.saveBundle = c.String("save-bundle")
-	} else {
-		opts.pollInterval = defaultPollInterval
-		opts.timeout = defaultTimeout
-	}
-
 	if opts.apiURL == "" {
 		opts.apiURL = defaultAPIURL
 	}
@@ -526,6 +1108,14 @@ func buildOptionsFromContext(c *cli.Context, includeDebug bool) (reviewOptions,
 	if opts.output == "" {
 		opts.output = defaultOutputFormat
 	}
+	switch opts.output {
+	case "pretty", "json", "sarif", "junit", "codeclimate":
+	default:
+		return reviewOptions{}, fmt.Errorf("invalid output format: %s (must be pretty, json, sarif, junit or codeclimate)", opts.output)
+	}
+	if opts.apiRetries < 0 {
+		return reviewOptions{}, fmt.Errorf("invalid --api-retries: %d (must be 0 or more)", opts.apiRetries)
+	}
 
 	return opts, nil
 }
@@ -612,17 +1202,42 @@ func runReviewWithOptions(opts reviewOptions) error {
 	// When --commit flag is used, we're always reviewing historical commits (read-only mode)
 	isPostCommitReview := opts.diffSource == "commit"
 
+	// Resumed reviews poll an existing review ID; they never submit or offer commit actions
+	isResume := opts.resume != ""
+
 	// Interactive flow (Web UI with commit actions) is the default when --serve is enabled
 	// BUT: disable interactive actions when reviewing historical commits (isPostCommitReview)
 	// Skip interactive mode if explicitly using --skip, not serving, or reviewing history
-	useInteractive := !opts.skip && opts.serve && !isPostCommitReview
+	useInteractive := !opts.skip && opts.serve && !isPostCommitReview && !isResume
+
+	// Gated (--fail-on) runs are CI checks: like post-commit reviews they are read-only
+	// and never require or write an attestation for the staged tree
+	recordsAttestation := !isPostCommitReview && !opts.gating() && !isResume
+
+	// Amends are reviewed for their delta against HEAD, and HEAD's attestation carries over
+	// into the attestations written below
+	var amend *amendBase
+	if opts.amend && recordsAttestation {
+		base, err := loadAmendBase(opts.pathFilter)
+		if err != nil {
+			return err
+		}
+		if !base.changesCode() && base.Prev != nil {
+			return base.keepAttestation(verbose, &attestationWritten)
+		}
+		amend = base
+	}
 
 	// Short-circuit skip: collect diff for coverage tracking, write attestation, exit
 	if opts.skip {
 		attestationAction = "skipped"
 		var cov coverageResult
 		// Collect diff to record in DB for coverage tracking (best-effort)
-		diffContent, diffErr := collectDiffWithOptions(opts)
+		diffContent, _, diffErr := collectFilteredDiff(opts)
+		var allExcluded *allExcludedError
+		if errors.As(diffErr, &allExcluded) {
+			return attestNothingToReview(attestationAction, allExcluded, amend, true, verbose, &attestationWritten)
+		}
 		if diffErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: could not collect diff for coverage tracking: %v\n", diffErr)
 		} else if len(diffContent) > 0 {
@@ -631,7 +1246,7 @@ func runReviewWithOptions(opts reviewOptions) error {
 				fmt.Fprintf(os.Stderr, "Warning: could not parse diff for coverage tracking: %v\n", parseErr)
 			} else {
 				var covErr error
-				cov, covErr = recordAndComputeCoverage("skipped", parsedFiles, "", verbose)
+				cov, covErr = recordAndComputeCoverage("skipped", parsedFiles, "", noReviewMetadata(), verbose)
 				if covErr != nil {
 					fmt.Fprintf(os.Stderr, "Warning: coverage computation failed: %v\n", covErr)
 				}
@@ -640,12 +1255,12 @@ func runReviewWithOptions(opts reviewOptions) error {
 		if cov.Iterations == 0 {
 			cov.Iterations = 1
 		}
-		if err := ensureAttestationFull(attestationPayload{
+		if err := ensureAttestationFull(amend.carry(attestationPayload{
 			Action:           attestationAction,
 			Iterations:       cov.Iterations,
 			PriorAICovPct:    cov.PriorAICovPct,
 			PriorReviewCount: cov.PriorReviewCount,
-		}, verbose, &attestationWritten); err != nil {
+		}), verbose, &attestationWritten); err != nil {
 			return err
 		}
 		if verbose {
@@ -659,7 +1274,11 @@ func runReviewWithOptions(opts reviewOptions) error {
 	// Short-circuit vouch: collect diff, compute coverage, write attestation, exit
 	if opts.vouch {
 		attestationAction = "vouched"
-		diffContent, diffErr := collectDiffWithOptions(opts)
+		diffContent, _, diffErr := collectFilteredDiff(opts)
+		var allExcluded *allExcludedError
+		if errors.As(diffErr, &allExcluded) {
+			return attestNothingToReview(attestationAction, allExcluded, amend, true, verbose, &attestationWritten)
+		}
 		if diffErr != nil {
 			return fmt.Errorf("failed to collect diff for vouch: %w", diffErr)
 		}
@@ -670,16 +1289,16 @@ func runReviewWithOptions(opts reviewOptions) error {
 		if parseErr != nil {
 			return fmt.Errorf("failed to parse diff for vouch: %w", parseErr)
 		}
-		cov, _ := recordAndComputeCoverage("vouched", parsedFiles, "", verbose)
+		cov, _ := recordAndComputeCoverage("vouched", parsedFiles, "", noReviewMetadata(), verbose)
 		if cov.Iterations == 0 {
 			cov.Iterations = 1
 		}
-		if err := ensureAttestationFull(attestationPayload{
+		if err := ensureAttestationFull(amend.carry(attestationPayload{
 			Action:           attestationAction,
 			Iterations:       cov.Iterations,
 			PriorAICovPct:    cov.PriorAICovPct,
 			PriorReviewCount: cov.PriorReviewCount,
-		}, verbose, &attestationWritten); err != nil {
+		}), verbose, &attestationWritten); err != nil {
 			return err
 		}
 		if verbose {
@@ -700,8 +1319,8 @@ func runReviewWithOptions(opts reviewOptions) error {
 	}
 
 	// Handle --force: delete existing attestation if present
-	// Skip attestation logic for post-commit reviews
-	if !isPostCommitReview {
+	// Skip attestation logic for post-commit and gated reviews
+	if recordsAttestation {
 		if opts.force {
 			if existing, err := existingAttestationAction(); err == nil && existing != "" {
 				if err := deleteAttestationForCurrentTree(); err != nil {
@@ -720,11 +1339,30 @@ func runReviewWithOptions(opts reviewOptions) error {
 		}
 	}
 
+	// Collect the diff, dropping files excluded by path filters, before contacting the API:
+	// there is nothing to review when every changed file was excluded. Nothing was
+	// reviewed either, so the attestation records the change as skipped.
+	var collectedDiff []byte
+	var excludedFiles []excludedDiffFile
+	if !isResume {
+		var diffErr error
+		collectedDiff, excludedFiles, diffErr = collectFilteredDiff(opts)
+		var allExcluded *allExcludedError
+		if errors.As(diffErr, &allExcluded) {
+			return attestNothingToReview("skipped", allExcluded, amend, recordsAttestation, verbose, &attestationWritten)
+		}
+		if diffErr != nil {
+			return fmt.Errorf("failed to collect diff: %w", diffErr)
+		}
+	}
+
 	// Load configuration from config file or overrides
 	config, err := loadConfigValues(opts.apiKey, opts.apiURL, verbose)
 	if err != nil {
 		return err
 	}
+	client := newAPIClient(config.APIURL, config.APIKey, verbose)
+	client.maxRetries = opts.apiRetries
 
 	// Determine repo name
 	repoName := opts.repoName
@@ -743,78 +1381,79 @@ func runReviewWithOptions(opts reviewOptions) error {
 
 	var result *diffReviewResponse
 
-	// Collect diff
-	diffContent, err := collectDiffWithOptions(opts)
-	if err != nil {
-		return fmt.Errorf("failed to collect diff: %w", err)
-	}
-
-	if len(diffContent) == 0 {
-		return fmt.Errorf("no diff content collected")
-	}
-
-	if verbose {
-		log.Printf("Collected %d bytes of diff content", len(diffContent))
-	}
-
-	// Create ZIP archive
-	zipData, err := createZipArchive(diffContent)
-	if err != nil {
-		return fmt.Errorf("failed to create zip archive: %w", err)
-	}
-
-	if verbose {
-		log.Printf("Created ZIP archive: %d bytes", len(zipData))
+	// Submit the diff, or reattach to a review submitted by an earlier run
+	var sub *submittedReview
+	var resumed *resumedReview
+	submittedAt := time.Now()
+	if isResume {
+		resumed, err = loadResumedReview(opts.resume, verbose)
+		if err != nil {
+			return err
+		}
+		if resumed.APIURL != "" && resumed.APIURL != config.APIURL {
+			fmt.Printf("Review was submitted to %s; resuming there instead of %s\n", resumed.APIURL, config.APIURL)
+			config.APIURL = resumed.APIURL
+			client = newAPIClient(config.APIURL, config.APIKey, verbose)
+			client.maxRetries = opts.apiRetries
+		}
+		submittedAt = resumed.SubmittedAt
+		sub = &submittedReview{
+			diffContent: resumed.diffContent,
+			resp:        diffReviewCreateResponse{ReviewID: resumed.ReviewIDs[0], FriendlyName: resumed.FriendlyName},
+			reviewIDs:   resumed.ReviewIDs,
+		}
+		// Resumed reviews are read-only, except that a staged review whose tree is still
+		// staged can complete the attestation the interrupted run would have written
+		recordsAttestation = !opts.gating() && resumed.canAttest()
+	} else {
+		sub, err = submitDiffForReview(opts, client, repoName, collectedDiff, excludedFiles, &attestationWritten)
+		if errors.Is(err, errReviewSkippedTooLarge) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
+	diffContent, submitResp, reviewIDs := sub.diffContent, sub.resp, sub.reviewIDs
 
-	// Base64 encode
-	base64Diff := base64.StdEncoding.EncodeToString(zipData)
+	reviewID := submitResp.ReviewID
+	reviewURL := buildReviewURL(config.APIURL, reviewID)
 
-	// Save bundle if requested
-	if bundlePath := opts.saveBundle; bundlePath != "" {
-		if err := saveBundleForInspection(bundlePath, diffContent, zipData, base64Diff, verbose); err != nil {
-			return fmt.Errorf("failed to save bundle: %w", err)
+	// sessionMeta describes the review for the session its attestation records
+	var reviewDuration time.Duration
+	sessionMeta := func() sessionMetadata {
+		meta := sessionMetadata{Duration: reviewDuration, CommentCount: -1, APIURL: config.APIURL}
+		if result != nil && result.Status == "completed" {
+			meta.CommentCount = countTotalComments(result.Files)
 		}
+		return meta
 	}
 
-	// Submit review
-	submitResp, err := submitReview(config.APIURL, config.APIKey, base64Diff, repoName, verbose)
-	if err != nil {
-		// Handle 413 Request Entity Too Large - prompt user to skip if interactive
-		var apiErr *APIError
-		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestEntityTooLarge {
-			isInteractive := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
-			if isInteractive {
-				fmt.Printf("\n⚠️  Review submission failed: The diff is too large for the API (Status 413).\n")
-				fmt.Print("Do you want to skip the review and proceed with the commit? [y/N]: ")
-
-				reader := bufio.NewReader(os.Stdin)
-				response, rErr := reader.ReadString('\n')
-				if rErr != nil {
-					// Fallback to error if we can't read input
-					return fmt.Errorf("failed to read input during 413 handling: %w (original error: %v)", rErr, err)
-				}
-				response = strings.ToLower(strings.TrimSpace(response))
-
-				if response == "y" || response == "yes" {
-					fmt.Println("Proceeding with skipped review...")
-					attestationAction = "skipped"
-					if err := ensureAttestation(attestationAction, verbose, &attestationWritten); err != nil {
-						return err
-					}
-					// Return nil to indicate success (review skipped, but process continues)
-					return nil
-				}
-				// User declined to skip, return specific error without body
-				return fmt.Errorf("review submission aborted by user (diff too large)")
-			}
+	// keepInHistory stores a completed result for `lrc history` and `lrc show`
+	keepInHistory := func(r *diffReviewResponse) {
+		reviewDuration = time.Since(submittedAt)
+		meta := reviewResultMeta{
+			ReviewIDs:    reviewIDs,
+			FriendlyName: r.FriendlyName,
+			RepoName:     repoName,
+			DiffSource:   opts.diffSource,
+			TreeHash:     reviewedTreeHash(opts),
+			Diff:         diffContent,
+		}
+		if isResume && resumed.TreeHash != "" {
+			meta.TreeHash = resumed.TreeHash
 		}
-		return fmt.Errorf("failed to submit review: %w", err)
+		if meta.FriendlyName == "" {
+			meta.FriendlyName = submitResp.FriendlyName
+		}
+		reviewStateMu.Lock()
+		if meta.FriendlyName == "" && currentReviewState != nil {
+			meta.FriendlyName = currentReviewState.FriendlyName
+		}
+		reviewStateMu.Unlock()
+		storeReviewResult(r, meta, verbose)
 	}
 
-	reviewID := submitResp.ReviewID
-	reviewURL := buildReviewURL(config.APIURL, reviewID)
-
 	// Track whether progressive loading mode is active
 	progressiveLoadingActive := false
 
@@ -828,7 +1467,25 @@ func runReviewWithOptions(opts reviewOptions) error {
 	var progressiveDecide func(code int, message string, push bool)
 	var progressiveDecideOnce sync.Once
 
-	fmt.Printf("Review submitted, ID: %s\n", reviewID)
+	switch {
+	case isResume && len(reviewIDs) > 1:
+		fmt.Printf("Resuming chunked review, IDs: %s\n", strings.Join(reviewIDs, ", "))
+	case isResume:
+		fmt.Printf("Resuming review, ID: %s\n", reviewID)
+	case len(reviewIDs) > 1:
+		fmt.Printf("Review submitted as %d chunks, IDs: %s\n", len(reviewIDs), strings.Join(reviewIDs, ", "))
+	default:
+		fmt.Printf("Review submitted, ID: %s\n", reviewID)
+	}
+	if isResume && len(diffContent) == 0 && len(resumed.files) == 0 {
+		fmt.Println("Note: the reviewed diff is not available locally; comments are shown without code context.")
+	}
+	if !isResume {
+		// Remember the review until its result arrives, so an interrupted run can be resumed
+		if err := savePendingReview(newPendingReview(opts, config.APIURL, repoName, submitResp, reviewIDs), diffContent); err != nil && verbose {
+			log.Printf("Warning: could not record pending review: %v", err)
+		}
+	}
 	if submitResp.UserEmail != "" {
 		fmt.Printf("Account: %s\n", submitResp.UserEmail)
 	}
@@ -847,11 +1504,12 @@ func runReviewWithOptions(opts reviewOptions) error {
 	}
 
 	// Track CLI usage (best-effort, non-blocking)
-	go trackCLIUsage(config.APIURL, config.APIKey, verbose)
+	go trackCLIUsage(client, verbose)
 
 	// Generate and serve skeleton HTML immediately if --serve is enabled
 	// Auto-enable serve when no HTML path specified and not in post-commit mode
-	autoServeEnabled := !opts.serve && opts.saveHTML == "" && !isPostCommitReview
+	// Machine-readable output formats (json, sarif, junit, codeclimate) go to stdout instead of the browser
+	autoServeEnabled := !opts.serve && opts.saveHTML == "" && !isPostCommitReview && !opts.gating() && opts.output == defaultOutputFormat
 	if autoServeEnabled {
 		opts.serve = true
 	}
@@ -859,7 +1517,15 @@ func runReviewWithOptions(opts reviewOptions) error {
 	// Recalculate useInteractive now that opts.serve may have been auto-enabled
 	// This is critical for Case 1 (hook-based terminal invocation) where serve is auto-enabled
 	// and we need the interactive flow with commit/push/skip options
-	useInteractive = !opts.skip && opts.serve && !isPostCommitReview
+	useInteractive = !opts.skip && opts.serve && !isPostCommitReview && !isResume
+
+	// --ui tui shows the review and takes the decision in the terminal instead of the browser
+	useTUI := useInteractive && opts.ui == reviewUITUI
+	var tuiOutput *tuiOutputGate
+	if useTUI && !terminalAvailable() {
+		fmt.Fprintln(os.Stderr, "Warning: no terminal for --ui tui; using the web UI instead")
+		useTUI = false
+	}
 
 	if opts.serve {
 		// Parse the diff content to generate file structures for immediate display
@@ -867,6 +1533,9 @@ func runReviewWithOptions(opts reviewOptions) error {
 		if parseErr != nil && verbose {
 			log.Printf("Warning: failed to parse diff for skeleton HTML: %v", parseErr)
 		}
+		if len(filesFromDiff) == 0 && resumed != nil {
+			filesFromDiff = resumed.files
+		}
 
 		// Initialize global review state for API-based UI
 		reviewStateMu.Lock()
@@ -874,21 +1543,26 @@ func runReviewWithOptions(opts reviewOptions) error {
 		reviewStateMu.Unlock()
 
 		// Start serving immediately in background
-		serveListener, selectedPort, err := pickServePort(opts.port, 10)
-		if err != nil {
-			return fmt.Errorf("failed to find available port: %w", err)
-		}
-		if selectedPort != opts.port {
-			fmt.Printf("Port %d is busy; serving on %d instead.\n", opts.port, selectedPort)
-			opts.port = selectedPort
-		}
+		var serveListener net.Listener
+		if !useTUI {
+			var selectedPort int
+			var err error
+			serveListener, selectedPort, err = pickServePort(opts.port, 10)
+			if err != nil {
+				return fmt.Errorf("failed to find available port: %w", err)
+			}
+			if selectedPort != opts.port {
+				fmt.Printf("Port %d is busy; serving on %d instead.\n", opts.port, selectedPort)
+				opts.port = selectedPort
+			}
 
-		serveURL := fmt.Sprintf("http://localhost:%d", opts.port)
-		fmt.Printf("\n🌐 Review available at: %s\n", highlightURL(serveURL))
-		fmt.Printf("   Comments will appear progressively as review runs\n\n")
+			serveURL := fmt.Sprintf("http://localhost:%d", opts.port)
+			fmt.Printf("\n🌐 Review available at: %s\n", highlightURL(serveURL))
+			fmt.Printf("   Comments will appear progressively as review runs\n\n")
 
-		// Auto-open the review in the default browser
-		openURL(serveURL)
+			// Auto-open the review in the default browser
+			openURL(serveURL)
+		}
 
 		// Mark that progressive loading is active
 		progressiveLoadingActive = true
@@ -901,140 +1575,172 @@ func runReviewWithOptions(opts reviewOptions) error {
 			})
 		}
 
-		// Start server in background
-		go func() {
-			mux := http.NewServeMux()
-			// Serve static assets (JS, CSS) from embedded filesystem
-			mux.Handle("/static/", http.StripPrefix("/static/", getStaticHandler()))
-
-			// Serve index.html from embedded filesystem (no file on disk needed)
-			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-				if r.URL.Path != "/" {
-					http.NotFound(w, r)
-					return
-				}
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				htmlBytes, err := staticFiles.ReadFile("static/index.html")
+		if useTUI {
+			// The terminal UI replaces the page and its buttons; its decisions take the same path.
+			// Its output gate goes in before the UI and the polling start writing.
+			gate, err := installTUIOutputGate()
+			if err != nil {
+				return fmt.Errorf("failed to set up the terminal UI: %w", err)
+			}
+			tuiOutput = gate
+			defer tuiOutput.close()
+			state := currentReviewState
+			go func() {
+				decision, err := runReviewTUI(state, initialMsg, gate)
 				if err != nil {
-					http.Error(w, "Failed to load page", http.StatusInternalServerError)
+					fmt.Fprintf(os.Stderr, "Error: terminal UI: %v\n", err)
+					progressiveDecide(decisionAbort, "", false)
 					return
 				}
-				w.Write(htmlBytes)
-			})
+				progressiveDecide(decision.code, decision.message, decision.push)
+			}()
+		} else {
+			// Start server in background
+			go func() {
+				mux := http.NewServeMux()
+				// Serve static assets (JS, CSS) from embedded filesystem
+				mux.Handle("/static/", http.StripPrefix("/static/", getStaticHandler()))
+
+				// Serve index.html from embedded filesystem (no file on disk needed)
+				mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Header().Set("Content-Type", "text/html; charset=utf-8")
+					htmlBytes, err := staticFiles.ReadFile("static/index.html")
+					if err != nil {
+						http.Error(w, "Failed to load page", http.StatusInternalServerError)
+						return
+					}
+					w.Write(htmlBytes)
+				})
 
-			// API endpoint for review state - frontend polls this
-			mux.HandleFunc("/api/review", func(w http.ResponseWriter, r *http.Request) {
-				reviewStateMu.RLock()
-				state := currentReviewState
-				reviewStateMu.RUnlock()
+				registerStatsRoutes(mux, statsFilter{})
 
-				if state == nil {
-					http.Error(w, "No review in progress", http.StatusNotFound)
-					return
-				}
-				state.ServeHTTP(w, r)
-			})
+				// API endpoint for review state - frontend polls this
+				mux.HandleFunc("/api/review", func(w http.ResponseWriter, r *http.Request) {
+					reviewStateMu.RLock()
+					state := currentReviewState
+					reviewStateMu.RUnlock()
 
-			// Functional commit handlers that work with the decision channel
-			mux.HandleFunc("/commit", func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					w.WriteHeader(http.StatusMethodNotAllowed)
-					return
-				}
-				msg := readCommitMessageFromRequest(r)
-				progressiveDecide(decisionCommit, msg, false)
-				w.WriteHeader(http.StatusOK)
-				_, _ = w.Write([]byte("ok"))
-			})
-			mux.HandleFunc("/commit-push", func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					w.WriteHeader(http.StatusMethodNotAllowed)
-					return
-				}
-				msg := readCommitMessageFromRequest(r)
-				progressiveDecide(decisionCommit, msg, true)
-				w.WriteHeader(http.StatusOK)
-				_, _ = w.Write([]byte("ok"))
-			})
-			mux.HandleFunc("/skip", func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost {
-					w.WriteHeader(http.StatusMethodNotAllowed)
-					return
-				}
-				progressiveDecide(decisionSkipWeb, "", false)
-				w.WriteHeader(http.StatusOK)
-				_, _ = w.Write([]byte("ok"))
-			})
-			// Proxy endpoint for review-events API to avoid CORS
-			mux.HandleFunc("/api/v1/diff-review/", func(w http.ResponseWriter, r *http.Request) {
-				// Forward request to backend API with authentication
-				backendURL := config.APIURL + r.URL.Path
-				if r.URL.RawQuery != "" {
-					backendURL += "?" + r.URL.RawQuery
-				}
-
-				if verbose {
-					log.Printf("Proxying %s request to: %s", r.Method, backendURL)
-					log.Printf("Using API key: %s...", config.APIKey[:min(10, len(config.APIKey))])
-				}
+					if state == nil {
+						http.Error(w, "No review in progress", http.StatusNotFound)
+						return
+					}
+					state.ServeHTTP(w, r)
+				})
 
-				// Forward the actual HTTP method (GET, POST, PUT, etc)
-				req, err := http.NewRequest(r.Method, backendURL, r.Body)
-				if err != nil {
-					http.Error(w, "Failed to create request", http.StatusInternalServerError)
-					return
-				}
-				req.Header.Set("X-API-Key", config.APIKey)
+				// Functional commit handlers that work with the decision channel
+				mux.HandleFunc("/commit", func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodPost {
+						w.WriteHeader(http.StatusMethodNotAllowed)
+						return
+					}
+					msg := readCommitMessageFromRequest(r)
+					progressiveDecide(decisionCommit, msg, false)
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte("ok"))
+				})
+				mux.HandleFunc("/commit-push", func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodPost {
+						w.WriteHeader(http.StatusMethodNotAllowed)
+						return
+					}
+					msg := readCommitMessageFromRequest(r)
+					progressiveDecide(decisionCommit, msg, true)
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte("ok"))
+				})
+				mux.HandleFunc("/skip", func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodPost {
+						w.WriteHeader(http.StatusMethodNotAllowed)
+						return
+					}
+					progressiveDecide(decisionSkipWeb, "", false)
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte("ok"))
+				})
+				// Proxy endpoint for review-events API to avoid CORS
+				mux.HandleFunc("/api/v1/diff-review/", func(w http.ResponseWriter, r *http.Request) {
+					// Forward request to backend API with authentication
+					backendURL := config.APIURL + r.URL.Path
+					if r.URL.RawQuery != "" {
+						backendURL += "?" + r.URL.RawQuery
+					}
 
-				client := &http.Client{Timeout: 10 * time.Second}
-				resp, err := client.Do(req)
-				if err != nil {
 					if verbose {
-						log.Printf("Proxy error: %v", err)
+						log.Printf("Proxying %s request to: %s", r.Method, backendURL)
+						log.Printf("Using API key: %s...", config.APIKey[:min(10, len(config.APIKey))])
 					}
-					http.Error(w, "Failed to fetch events", http.StatusBadGateway)
-					return
-				}
-				defer resp.Body.Close()
 
-				if verbose {
-					log.Printf("Backend response status: %d", resp.StatusCode)
-				}
+					// Forward the actual HTTP method (GET, POST, PUT, etc) without retries;
+					// the browser polls again on its own
+					body, err := io.ReadAll(r.Body)
+					if err != nil {
+						http.Error(w, "Failed to read request", http.StatusBadRequest)
+						return
+					}
+					forward := apiRequest{Method: r.Method, Path: r.URL.RequestURI()}
+					if len(body) > 0 {
+						forward.Body = body
+					}
+					ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+					defer cancel()
+					resp, err := client.send(ctx, client.httpClient, forward)
+					if err != nil {
+						if verbose {
+							log.Printf("Proxy error: %v", err)
+						}
+						http.Error(w, "Failed to fetch events", http.StatusBadGateway)
+						return
+					}
+					defer resp.Body.Close()
 
-				// Copy response headers
-				for key, values := range resp.Header {
-					for _, value := range values {
-						w.Header().Add(key, value)
+					if verbose {
+						log.Printf("Backend response status: %d", resp.StatusCode)
 					}
-				}
-				w.WriteHeader(resp.StatusCode)
 
-				// Copy response body
-				bodyBytes, err := io.ReadAll(resp.Body)
-				if err != nil && verbose {
-					log.Printf("Error reading response: %v", err)
-				}
-				if verbose && resp.StatusCode != 200 {
-					log.Printf("Error response body: %s", string(bodyBytes))
+					// Copy response headers
+					for key, values := range resp.Header {
+						for _, value := range values {
+							w.Header().Add(key, value)
+						}
+					}
+					w.WriteHeader(resp.StatusCode)
+
+					// Copy response body
+					bodyBytes, err := io.ReadAll(resp.Body)
+					if err != nil && verbose {
+						log.Printf("Error reading response: %v", err)
+					}
+					if verbose && resp.StatusCode != 200 {
+						log.Printf("Error response body: %s", string(bodyBytes))
+					}
+					w.Write(bodyBytes)
+				})
+				server := &http.Server{
+					Handler: mux,
 				}
-				w.Write(bodyBytes)
-			})
-			server := &http.Server{
-				Handler: mux,
-			}
-			if err := server.Serve(serveListener); err != nil && err != http.ErrServerClosed {
-				if verbose {
-					log.Printf("Background server error: %v", err)
+				if err := server.Serve(serveListener); err != nil && err != http.ErrServerClosed {
+					if verbose {
+						log.Printf("Background server error: %v", err)
+					}
 				}
-			}
-		}()
-		time.Sleep(100 * time.Millisecond) // Give server time to start
+			}()
+			time.Sleep(100 * time.Millisecond) // Give server time to start
+		}
 	}
 
-	// For post-commit reviews, just poll and get results without interactive flow
-	if isPostCommitReview {
+	// For post-commit and headless reviews, just poll and get results without interactive flow
+	if isPostCommitReview || !useInteractive {
 		var pollErr error
-		result, pollErr = pollReview(config.APIURL, config.APIKey, reviewID, opts.pollInterval, opts.timeout, verbose)
+		result, pollErr = pollSubmittedReviews(client, reviewIDs, opts.pollInterval, opts.timeout, !opts.noStream, verbose)
+		if pollFinishedReview(pollErr) {
+			_ = clearPendingReview(reviewID)
+		} else {
+			fmt.Fprintf(os.Stderr, "The review keeps running on the server; reattach with: lrc review --resume %s\n", reviewID)
+		}
 		if pollErr != nil {
 			// If progressive loading is active, don't crash - keep server running to show error
 			if progressiveLoadingActive {
@@ -1066,8 +1772,15 @@ func runReviewWithOptions(opts reviewOptions) error {
 				currentReviewState.UpdateFromResult(result)
 			}
 			reviewStateMu.Unlock()
+			keepInHistory(result)
 		}
 		// No attestation for post-commit reviews
+		if recordsAttestation && pollErr == nil {
+			attestationAction = "reviewed"
+			if err := recordCoverageAndAttest("reviewed", diffContent, reviewIDs, sessionMeta(), amend, verbose, &attestationWritten); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
 	}
 
 	// Interactive path (default): set up decision channels for Ctrl-C / Ctrl-S and poll
@@ -1087,24 +1800,40 @@ func runReviewWithOptions(opts reviewOptions) error {
 			decisionChan <- decisionAbort
 		}()
 
-		// Ctrl-S -> skip review but still commit; Ctrl-C captured in raw mode fallback
-		go func() {
-			code, err := handleCtrlKeyWithCancel(stopCtrlS)
-			if err == nil && code != 0 {
-				decisionChan <- code
-			}
-		}()
+		if useTUI {
+			// Skip, vouch and abort from the terminal UI decide right away; a commit
+			// waits for the review like one from the web UI
+			go func() {
+				select {
+				case d := <-progressiveDecisionChan:
+					if d.code == decisionCommit {
+						progressiveDecisionChan <- d
+						return
+					}
+					decisionChan <- d.code
+				case <-stopCtrlS:
+				}
+			}()
+		} else {
+			// Ctrl-S -> skip review but still commit; Ctrl-C captured in raw mode fallback
+			go func() {
+				code, err := handleCtrlKeyWithCancel(stopCtrlS)
+				if err == nil && code != 0 {
+					decisionChan <- code
+				}
+			}()
 
-		fmt.Println("💡 Press Ctrl-C to abort, Ctrl-S to skip, or Ctrl-V to vouch and commit")
-		fmt.Println("")
-		os.Stdout.Sync()
+			fmt.Println("💡 Press Ctrl-C to abort, Ctrl-S to skip, or Ctrl-V to vouch and commit")
+			fmt.Println("")
+			os.Stdout.Sync()
+		}
 
 		// Poll concurrently and race with decisions
 		var pollResult *diffReviewResponse
 		var pollErr error
 		pollDone := make(chan struct{})
 		go func() {
-			pollResult, pollErr = pollReview(config.APIURL, config.APIKey, reviewID, opts.pollInterval, opts.timeout, verbose)
+			pollResult, pollErr = pollSubmittedReviews(client, reviewIDs, opts.pollInterval, opts.timeout, !opts.noStream, verbose)
 			close(pollDone)
 		}()
 
@@ -1116,6 +1845,13 @@ func runReviewWithOptions(opts reviewOptions) error {
 			pollFinished = true
 		}
 
+		if pollFinished {
+			if pollFinishedReview(pollErr) {
+				_ = clearPendingReview(reviewID)
+			} else {
+				fmt.Fprintf(os.Stderr, "The review keeps running on the server; reattach with: lrc review --resume %s\n", reviewID)
+			}
+		}
 		if pollFinished {
 			// Prefer a user decision if it arrives within a short grace window after poll finishes
 			select {
@@ -1156,9 +1892,10 @@ func runReviewWithOptions(opts reviewOptions) error {
 					currentReviewState.UpdateFromResult(pollResult)
 				}
 				reviewStateMu.Unlock()
+				keepInHistory(pollResult)
 			}
 			attestationAction = "reviewed"
-			if err := recordCoverageAndAttest("reviewed", diffContent, reviewID, verbose, &attestationWritten); err != nil {
+			if err := recordCoverageAndAttest("reviewed", diffContent, reviewIDs, sessionMeta(), amend, verbose, &attestationWritten); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			}
 		}
@@ -1183,7 +1920,7 @@ func runReviewWithOptions(opts reviewOptions) error {
 				return cli.Exit("", decisionSkipWeb)
 			case decisionVouch:
 				fmt.Println("\n✅ Vouched — proceeding with commit")
-				if err := recordCoverageAndAttest("vouched", diffContent, reviewID, verbose, &attestationWritten); err != nil {
+				if err := recordCoverageAndAttest("vouched", diffContent, reviewIDs, sessionMeta(), amend, verbose, &attestationWritten); err != nil {
 					fmt.Fprintf(os.Stderr, "Error: vouch failed: %v\n", err)
 					return cli.Exit("", decisionAbort)
 				}
@@ -1229,6 +1966,13 @@ func runReviewWithOptions(opts reviewOptions) error {
 		}
 	}
 
+	// Save SARIF output if requested
+	if sarifPath := opts.saveSARIF; sarifPath != "" {
+		if err := saveSARIFOutput(sarifPath, result, verbose); err != nil {
+			return fmt.Errorf("failed to save SARIF output: %w", err)
+		}
+	}
+
 	// Save HTML output if requested
 	// Skip if progressive loading is active - the browser already has the skeleton HTML
 	// and will receive error/completion via the events API
@@ -1275,13 +2019,8 @@ func runReviewWithOptions(opts reviewOptions) error {
 			// If progressive loading was active, the server is already running.
 			// Don't start a new server - wait for decisions from HTTP or terminal.
 			if progressiveLoadingActive {
-				// Progressive loading active - server already running on opts.port
-				fmt.Printf("\n📋 Review complete. Choose action:\n")
-				fmt.Printf("   [Enter]  Continue with commit\n")
-				fmt.Printf("   [Ctrl-C] Abort commit\n")
-				fmt.Printf("   Or use the web UI buttons\n\n")
-
-				// Set up terminal input handlers that call progressiveDecide
+				// Progressive loading active - server already running on opts.port, or the
+				// terminal UI is still open and decides
 				sigChan := make(chan os.Signal, 1)
 				signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -1290,44 +2029,53 @@ func runReviewWithOptions(opts reviewOptions) error {
 					progressiveDecide(decisionAbort, "", false) // abort
 				}()
 
-				go func() {
-					tty, err := openTTY()
-					if err != nil {
-						// Cannot open terminal (e.g. no console attached).
-						// Don't abort — let the web UI buttons be the decision source.
-						return
-					}
-					defer tty.Close()
-
-					// Prompt for commit message if empty
-					msg := initialMsg
-					if strings.TrimSpace(msg) == "" {
-						// Write prompt to stdout so user can see it
-						fmt.Print("Enter commit message: ")
-						os.Stdout.Sync()
-						reader := bufio.NewReader(tty)
-						input, err := reader.ReadString('\n')
+				if !useTUI {
+					fmt.Printf("\n📋 Review complete. Choose action:\n")
+					fmt.Printf("   [Enter]  Continue with commit\n")
+					fmt.Printf("   [Ctrl-C] Abort commit\n")
+					fmt.Printf("   Or use the web UI buttons\n\n")
+
+					// Set up terminal input handlers that call progressiveDecide
+					go func() {
+						tty, err := openTTY()
 						if err != nil {
-							progressiveDecide(decisionAbort, "", false) // abort on read error
+							// Cannot open terminal (e.g. no console attached).
+							// Don't abort — let the web UI buttons be the decision source.
 							return
 						}
-						msg = strings.TrimSpace(input)
-					} else {
-						// Just wait for Enter if we have initial message
-						reader := bufio.NewReader(tty)
-						_, err := reader.ReadString('\n')
-						if err != nil {
-							progressiveDecide(decisionAbort, "", false) // abort on read error
-							return
+						defer tty.Close()
+
+						// Prompt for commit message if empty
+						msg := initialMsg
+						if strings.TrimSpace(msg) == "" {
+							// Write prompt to stdout so user can see it
+							fmt.Print("Enter commit message: ")
+							os.Stdout.Sync()
+							reader := bufio.NewReader(tty)
+							input, err := reader.ReadString('\n')
+							if err != nil {
+								progressiveDecide(decisionAbort, "", false) // abort on read error
+								return
+							}
+							msg = strings.TrimSpace(input)
+						} else {
+							// Just wait for Enter if we have initial message
+							reader := bufio.NewReader(tty)
+							_, err := reader.ReadString('\n')
+							if err != nil {
+								progressiveDecide(decisionAbort, "", false) // abort on read error
+								return
+							}
 						}
-					}
-					progressiveDecide(decisionCommit, msg, false) // commit with entered/initial message
-				}()
+						progressiveDecide(decisionCommit, msg, false) // commit with entered/initial message
+					}()
+				}
 
 				// Wait for decision from either HTTP endpoint or terminal
 				decision := <-progressiveDecisionChan
 
 				if opts.precommit {
+					tuiOutput.close()
 					os.Exit(decision.code)
 				}
 
@@ -1335,6 +2083,9 @@ func runReviewWithOptions(opts reviewOptions) error {
 				case decisionAbort:
 					fmt.Println("\n❌ Commit aborted by user")
 					return cli.Exit("", decision.code)
+				case decisionSkipWeb:
+					fmt.Println("\n⏭️  Skip requested; aborting commit")
+					return cli.Exit("", decision.code)
 				case decisionCommit:
 					finalMsg := strings.TrimSpace(decision.message)
 					if finalMsg == "" {
@@ -1384,6 +2135,7 @@ func runReviewWithOptions(opts reviewOptions) error {
 						_ = clearPushRequest(commitMsgPath)
 					}
 
+					tuiOutput.close()
 					os.Exit(code)
 				}
 
@@ -1436,21 +2188,141 @@ func runReviewWithOptions(opts reviewOptions) error {
 		}
 	}
 
-	// Render result to stdout (skip in interactive mode or when serving - handled by UI)
-	if !useInteractive && !opts.serve {
-		if err := renderResult(result, opts.output); err != nil {
-			return fmt.Errorf("failed to render result: %w", err)
+	// Render result to stdout (skip in interactive mode or when serving - handled by UI)
+	if !useInteractive && !opts.serve {
+		if err := renderResult(result, opts.output); err != nil {
+			return fmt.Errorf("failed to render result: %w", err)
+		}
+	}
+
+	// Only write attestation for pre-commit reviews, not post-commit or gated reviews
+	if recordsAttestation {
+		if err := ensureAttestation(attestationAction, verbose, &attestationWritten); err != nil {
+			return err
+		}
+	}
+
+	return enforceFailOn(result, opts)
+}
+
+// submittedReview is a diff that has been handed to the API as one review, or as
+// one review per chunk.
+type submittedReview struct {
+	diffContent []byte
+	resp        diffReviewCreateResponse
+	reviewIDs   []string
+}
+
+// errReviewSkippedTooLarge means the user chose to skip a diff the API rejected as too
+// large. The skip attestation has already been written.
+var errReviewSkippedTooLarge = errors.New("review skipped: diff too large")
+
+// submitDiffForReview bundles the diff collected for opts and submits it for review,
+// split into chunks when --chunk is set.
+func submitDiffForReview(opts reviewOptions, client *apiClient, repoName string, diffContent []byte, excludedFiles []excludedDiffFile, attestationWritten *bool) (*submittedReview, error) {
+	verbose := opts.verbose
+	var err error
+
+	if len(diffContent) == 0 {
+		return nil, fmt.Errorf("no diff content collected")
+	}
+
+	if verbose {
+		log.Printf("Collected %d bytes of diff content", len(diffContent))
+	}
+
+	// Read full post-images of changed files when context-enriched bundles are requested
+	var bundleCtx *bundleContext
+	if opts.contextFiles {
+		bundleCtx, err = collectBundleContext(opts, repoName, diffContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect context files: %w", err)
+		}
+	}
+
+	// Create ZIP archive
+	zipData, err := createZipArchive(diffContent, bundleCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip archive: %w", err)
+	}
+
+	if verbose {
+		log.Printf("Created ZIP archive: %d bytes", len(zipData))
+	}
+
+	// Base64 encode
+	base64Diff := base64.StdEncoding.EncodeToString(zipData)
+
+	// Save bundle if requested
+	if bundlePath := opts.saveBundle; bundlePath != "" {
+		if err := saveBundleForInspection(bundlePath, diffContent, excludedFiles, bundleCtx, zipData, base64Diff, verbose); err != nil {
+			return nil, fmt.Errorf("failed to save bundle: %w", err)
+		}
+	}
+
+	// Split oversized diffs into file-aligned chunks when --chunk is enabled
+	var chunks []diffChunk
+	if opts.chunk {
+		if chunks, err = splitDiffIntoChunks(diffContent, opts.chunkBytes); err != nil {
+			return nil, fmt.Errorf("cannot split the diff into chunks: %w", err)
 		}
 	}
 
-	// Only write attestation for pre-commit reviews, not post-commit reviews
-	if !isPostCommitReview {
-		if err := ensureAttestation(attestationAction, verbose, &attestationWritten); err != nil {
-			return err
+	// Submit review (one submission per chunk when chunked)
+	var submitResp diffReviewCreateResponse
+	var reviewIDs []string
+	if len(chunks) > 1 {
+		fmt.Printf("Diff is %d bytes; submitting as %d chunks of up to %d bytes\n", len(diffContent), len(chunks), opts.chunkBytes)
+		chunkResps, err := submitDiffChunks(client, repoName, chunks, bundleCtx, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to submit chunked review: %w", err)
+		}
+		submitResp = chunkResps[0]
+		for _, r := range chunkResps {
+			reviewIDs = append(reviewIDs, r.ReviewID)
 		}
+	} else {
+		submitResp, err = submitReview(client, base64Diff, repoName, verbose)
 	}
+	if err != nil {
+		// Handle 413 Request Entity Too Large - prompt user to skip if interactive
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestEntityTooLarge {
+			isInteractive := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+			if isInteractive {
+				fmt.Printf("\n⚠️  Review submission failed: The diff is too large for the API (Status 413).\n")
+				if !opts.chunk {
+					fmt.Printf("   Tip: rerun with --chunk to split the diff into several smaller reviews.\n")
+				}
+				fmt.Print("Do you want to skip the review and proceed with the commit? [y/N]: ")
 
-	return nil
+				reader := bufio.NewReader(os.Stdin)
+				response, rErr := reader.ReadString('\n')
+				if rErr != nil {
+					// Fallback to error if we can't read input
+					return nil, fmt.Errorf("failed to read input during 413 handling: %w (original error: %v)", rErr, err)
+				}
+				response = strings.ToLower(strings.TrimSpace(response))
+
+				if response == "y" || response == "yes" {
+					fmt.Println("Proceeding with skipped review...")
+					if err := ensureAttestation("skipped", verbose, attestationWritten); err != nil {
+						return nil, err
+					}
+					// The caller treats this as success (review skipped, but process continues)
+					return nil, errReviewSkippedTooLarge
+				}
+				// User declined to skip, return specific error without body
+				return nil, fmt.Errorf("review submission aborted by user (diff too large)")
+			}
+		}
+		return nil, fmt.Errorf("failed to submit review: %w", err)
+	}
+
+	if len(reviewIDs) == 0 {
+		reviewIDs = []string{submitResp.ReviewID}
+	}
+	return &submittedReview{diffContent: diffContent, resp: submitResp, reviewIDs: reviewIDs}, nil
 }
 
 func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
@@ -1459,10 +2331,15 @@ func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
 
 	switch diffSource {
 	case "staged":
-		if verbose {
-			log.Println("Collecting staged changes...")
+		diff, err := collectStagedDiff(verbose)
+		if err != nil || !opts.submodules {
+			return diff, err
+		}
+		subDiffs, err := collectSubmoduleDiffs(".", "", verbose)
+		if err != nil {
+			return nil, err
 		}
-		return runGitCommand("git", "diff", "--staged")
+		return append(diff, subDiffs...), nil
 
 	case "working":
 		if verbose {
@@ -1473,7 +2350,7 @@ func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
 	case "commit":
 		commitVal := opts.commitVal
 		if commitVal == "" {
-			return nil, fmt.Errorf("--commit is required when diff-source=commit")
+			return nil, invalidInput("--commit is required when diff-source=commit")
 		}
 		if verbose {
 			log.Printf("Collecting diff for commit: %s", commitVal)
@@ -1489,7 +2366,7 @@ func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
 	case "range":
 		rangeVal := opts.rangeVal
 		if rangeVal == "" {
-			return nil, fmt.Errorf("--range is required when diff-source=range")
+			return nil, invalidInput("--range is required when diff-source=range")
 		}
 		if verbose {
 			log.Printf("Collecting diff for range: %s", rangeVal)
@@ -1499,7 +2376,7 @@ func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
 	case "file":
 		filePath := opts.diffFile
 		if filePath == "" {
-			return nil, fmt.Errorf("--diff-file is required when diff-source=file")
+			return nil, invalidInput("--diff-file is required when diff-source=file")
 		}
 		if verbose {
 			log.Printf("Reading diff from file: %s", filePath)
@@ -1507,8 +2384,24 @@ func collectDiffWithOptions(opts reviewOptions) ([]byte, error) {
 		return os.ReadFile(filePath)
 
 	default:
-		return nil, fmt.Errorf("invalid diff-source: %s (must be staged, working, commit, range, or file)", diffSource)
+		return nil, invalidInput("invalid diff-source: %s (must be staged, working, commit, range, or file)", diffSource)
+	}
+}
+
+// collectStagedDiff returns the staged changes, or only the conflict resolution
+// while a merge is being concluded.
+func collectStagedDiff(verbose bool) ([]byte, error) {
+	diff, err := collectMergeResolution(verbose)
+	if err == nil {
+		return diff, nil
+	}
+	if !errors.Is(err, errNoMergeInProgress) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; reviewing all staged changes of the merge\n", err)
 	}
+	if verbose {
+		log.Println("Collecting staged changes...")
+	}
+	return runGitCommand("git", "diff", "--staged")
 }
 
 func runGitCommand(name string, args ...string) ([]byte, error) {
@@ -1603,6 +2496,33 @@ type attestationPayload struct {
 	Iterations       int     `json:"iterations"`
 	PriorAICovPct    float64 `json:"prior_ai_coverage_pct"`
 	PriorReviewCount int     `json:"prior_review_count"`
+	Chunked          bool    `json:"chunked,omitempty"`
+	ChunkCount       int     `json:"chunk_count,omitempty"`
+	TreeHash         string  `json:"tree_hash,omitempty"`
+	ReviewID         string  `json:"review_id,omitempty"` // comma-separated for chunked reviews
+	// PatchID is the stable patch ID of the staged changes, so the attestation
+	// still applies after they move to another base; see attestation_reuse.go
+	PatchID string `json:"patch_id,omitempty"`
+	// ExcludedFiles lists the changed files when all of them were excluded from review
+	ExcludedFiles []string `json:"excluded_files,omitempty"`
+	// Signature is set when sign_attestations is on; see attestation_signing.go
+	Signature *attestationSignature `json:"signature,omitempty"`
+}
+
+// attestNothingToReview handles a diff whose changed files were all excluded by the
+// path filter (lockfiles, binaries, ...). Nothing is submitted; when record is set the
+// attestation lists the excluded files so the commit can go ahead.
+func attestNothingToReview(action string, allExcluded *allExcludedError, amend *amendBase, record, verbose bool, written *bool) error {
+	paths := allExcluded.paths()
+	shown := paths
+	if len(shown) > 5 {
+		shown = append(shown[:5:5], fmt.Sprintf("and %d more", len(paths)-5))
+	}
+	fmt.Printf("LiveReview: nothing to review; all %d changed file(s) are excluded (%s)\n", len(paths), strings.Join(shown, ", "))
+	if !record {
+		return nil
+	}
+	return ensureAttestationFull(amend.carry(attestationPayload{Action: action, Iterations: 1, ExcludedFiles: paths}), verbose, written)
 }
 
 func ensureAttestation(action string, verbose bool, written *bool) error {
@@ -1611,12 +2531,14 @@ func ensureAttestation(action string, verbose bool, written *bool) error {
 
 // recordCoverageAndAttest parses the diff, records a review session with coverage stats,
 // and writes a full attestation. Used by both the "reviewed" and "vouched" interactive paths.
-func recordCoverageAndAttest(action string, diffContent []byte, reviewID string, verbose bool, attestationWritten *bool) error {
+// More than one review ID means the diff was reviewed in chunks. For an amend, the
+// replaced commit's attestation is folded in.
+func recordCoverageAndAttest(action string, diffContent []byte, reviewIDs []string, meta sessionMetadata, amend *amendBase, verbose bool, attestationWritten *bool) error {
 	parsedFiles, parseErr := parseDiffToFiles(diffContent)
 	if parseErr != nil {
 		return fmt.Errorf("could not parse diff for coverage tracking: %w", parseErr)
 	}
-	cov, covErr := recordAndComputeCoverage(action, parsedFiles, reviewID, verbose)
+	cov, covErr := recordAndComputeCoverage(action, parsedFiles, strings.Join(reviewIDs, ","), meta, verbose)
 	if covErr != nil {
 		return fmt.Errorf("coverage computation failed: %w", covErr)
 	}
@@ -1625,12 +2547,18 @@ func recordCoverageAndAttest(action string, diffContent []byte, reviewID string,
 	if cov.Iterations == 0 {
 		cov.Iterations = 1
 	}
-	return ensureAttestationFull(attestationPayload{
+	payload := attestationPayload{
 		Action:           action,
 		Iterations:       cov.Iterations,
 		PriorAICovPct:    cov.PriorAICovPct,
 		PriorReviewCount: cov.PriorReviewCount,
-	}, verbose, attestationWritten)
+		ReviewID:         strings.Join(reviewIDs, ","),
+	}
+	if len(reviewIDs) > 1 {
+		payload.Chunked = true
+		payload.ChunkCount = len(reviewIDs)
+	}
+	return ensureAttestationFull(amend.carry(payload), verbose, attestationWritten)
 }
 
 func ensureAttestationFull(payload attestationPayload, verbose bool, written *bool) error {
@@ -1640,7 +2568,6 @@ func ensureAttestationFull(payload attestationPayload, verbose bool, written *bo
 	if strings.TrimSpace(payload.Action) == "" {
 		return nil
 	}
-
 	path, err := writeAttestationFullForCurrentTree(payload)
 	if err != nil {
 		return fmt.Errorf("failed to write attestation: %w", err)
@@ -1655,32 +2582,20 @@ func ensureAttestationFull(payload attestationPayload, verbose bool, written *bo
 	return nil
 }
 
-// existingAttestationAction returns the attestation action for the current tree, if present.
+// existingAttestationAction returns the attestation action for the current tree, if
+// present, or else that of an attestation made for the same staged patch.
 func existingAttestationAction() (string, error) {
-	treeHash, err := currentTreeHash()
-	if err != nil {
-		return "", err
-	}
-	if treeHash == "" {
-		return "", nil
-	}
-
-	gitDir, err := resolveGitDir()
-	if err != nil {
-		return "", err
-	}
-
-	attestPath := filepath.Join(gitDir, "lrc", "attestations", fmt.Sprintf("%s.json", treeHash))
-	data, err := os.ReadFile(attestPath)
-	if err != nil {
-		return "", nil // not present
-	}
-
-	var payload attestationPayload
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return "", nil
+	payload, err := readCurrentAttestation()
+	if err != nil || payload == nil {
+		// A malformed attestation counts as none
+		id, idErr := stagedPatchID()
+		if idErr != nil {
+			return "", idErr
+		}
+		if payload, err = attestationForPatch(id); err != nil || payload == nil {
+			return "", err
+		}
 	}
-
 	return strings.TrimSpace(payload.Action), nil
 }
 
@@ -1693,24 +2608,7 @@ func readCurrentAttestation() (*attestationPayload, error) {
 	if treeHash == "" {
 		return nil, nil
 	}
-
-	gitDir, err := resolveGitDir()
-	if err != nil {
-		return nil, err
-	}
-
-	attestPath := filepath.Join(gitDir, "lrc", "attestations", fmt.Sprintf("%s.json", treeHash))
-	data, err := os.ReadFile(attestPath)
-	if err != nil {
-		return nil, nil // not present
-	}
-
-	var payload attestationPayload
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return nil, fmt.Errorf("malformed attestation JSON: %w", err)
-	}
-
-	return &payload, nil
+	return readAttestationForTree(treeHash)
 }
 
 // runAttestationTrailer outputs the formatted commit trailer from the current
@@ -1725,6 +2623,24 @@ func runAttestationTrailer(c *cli.Context) error {
 		return nil // no attestation — hook will fall back to legacy
 	}
 
+	fmt.Printf("LiveReview Pre-Commit Check: %s", formatAttestationTrailer(*payload))
+
+	// A signed attestation travels in a second trailer unless it is kept in a note
+	if payload.Signature != nil {
+		if _, storage, err := signingSettings(); err == nil && storage == signatureStorageTrailer {
+			encoded, err := encodeSignedAttestation(*payload)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\n%s: %s", attestationTrailerKey, encoded)
+		}
+	}
+	return nil
+}
+
+// formatAttestationTrailer returns the value of the "LiveReview Pre-Commit Check"
+// trailer for an attestation, e.g. "ran (iter:2, coverage:40%)".
+func formatAttestationTrailer(payload attestationPayload) string {
 	// Map action to trailer value
 	var trailerVal string
 	switch payload.Action {
@@ -1741,11 +2657,13 @@ func runAttestationTrailer(c *cli.Context) error {
 	// Append iteration and coverage info if available
 	if payload.Iterations > 0 {
 		covPct := int(payload.PriorAICovPct + 0.5) // round to nearest int
-		trailerVal = fmt.Sprintf("%s (iter:%d, coverage:%d%%)", trailerVal, payload.Iterations, covPct)
+		if payload.Chunked {
+			trailerVal = fmt.Sprintf("%s (iter:%d, coverage:%d%%, chunks:%d)", trailerVal, payload.Iterations, covPct, payload.ChunkCount)
+		} else {
+			trailerVal = fmt.Sprintf("%s (iter:%d, coverage:%d%%)", trailerVal, payload.Iterations, covPct)
+		}
 	}
-
-	fmt.Printf("LiveReview Pre-Commit Check: %s", trailerVal)
-	return nil
+	return trailerVal
 }
 
 func writeAttestationForCurrentTree(action string) (string, error) {
@@ -1764,6 +2682,20 @@ func writeAttestationFullForCurrentTree(payload attestationPayload) (string, err
 	if treeHash == "" {
 		return "", fmt.Errorf("empty tree hash")
 	}
+	payload.TreeHash = treeHash
+	if id, err := stagedPatchID(); err == nil {
+		payload.PatchID = id
+	}
+
+	// Signing is best-effort: an unsigned attestation still lets the commit through,
+	// and `lrc verify` reports it as unsigned
+	if sign, _, err := signingSettings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (attestation not signed)\n", err)
+	} else if sign {
+		if err := signAttestation(&payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not sign attestation: %v\n", err)
+		}
+	}
 
 	gitDir, err := resolveGitDir()
 	if err != nil {
@@ -1839,8 +2771,21 @@ func currentTreeHash() (string, error) {
 }
 
 // resolveGitDir returns the absolute path to the repository's .git directory.
+// In a linked worktree this is the worktree's own directory under
+// .git/worktrees/, which holds its HEAD, index and attestations.
 func resolveGitDir() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	return resolveGitPath("--git-dir")
+}
+
+// resolveGitCommonDir returns the absolute path to the .git directory shared by
+// all worktrees of the repository: the review DB, config, policy and the hooks
+// live there. Outside a linked worktree it is the same as resolveGitDir.
+func resolveGitCommonDir() (string, error) {
+	return resolveGitPath("--git-common-dir")
+}
+
+func resolveGitPath(flag string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", flag)
 	out, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("failed to locate git directory: %w", err)
@@ -1863,7 +2808,9 @@ func resolveGitDir() (string, error) {
 	return filepath.Join(cwd, gitDir), nil
 }
 
-func createZipArchive(diffContent []byte) ([]byte, error) {
+// createZipArchive packs the diff as diff.txt, plus manifest.json and full
+// post-image files when a bundle context is given.
+func createZipArchive(diffContent []byte, bundleCtx *bundleContext) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	zipWriter := zip.NewWriter(buf)
 
@@ -1876,6 +2823,12 @@ func createZipArchive(diffContent []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed to write to zip: %w", err)
 	}
 
+	if bundleCtx != nil {
+		if err := bundleCtx.writeTo(zipWriter); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := zipWriter.Close(); err != nil {
 		return nil, fmt.Errorf("failed to close zip writer: %w", err)
 	}
@@ -1905,171 +2858,185 @@ func formatJSONParseError(body []byte, contentType string, parseErr error) error
 		parseErr, contentType, preview)
 }
 
-func submitReview(apiURL, apiKey, base64Diff, repoName string, verbose bool) (diffReviewCreateResponse, error) {
-	endpoint := strings.TrimSuffix(apiURL, "/") + "/api/v1/diff-review"
-
+func submitReview(client *apiClient, base64Diff, repoName string, verbose bool) (diffReviewCreateResponse, error) {
 	payload := diffReviewRequest{
 		DiffZipBase64: base64Diff,
 		RepoName:      repoName,
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return diffReviewCreateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return diffReviewCreateResponse{}, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", apiKey)
-
 	if verbose {
-		log.Printf("POST %s", endpoint)
-	}
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return diffReviewCreateResponse{}, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return diffReviewCreateResponse{}, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	contentType := resp.Header.Get("Content-Type")
-
-	if resp.StatusCode != http.StatusOK {
-		return diffReviewCreateResponse{}, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		log.Printf("POST %s/api/v1/diff-review", client.baseURL)
 	}
 
 	var result diffReviewCreateResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return diffReviewCreateResponse{}, formatJSONParseError(body, contentType, err)
+	if err := client.postJSON(context.Background(), "/api/v1/diff-review", payload, &result); err != nil {
+		return diffReviewCreateResponse{}, err
 	}
 
 	if result.ReviewID == "" {
 		return diffReviewCreateResponse{}, fmt.Errorf("review_id not found in response")
 	}
+	if err := validateReviewID(result.ReviewID); err != nil {
+		return diffReviewCreateResponse{}, fmt.Errorf("unusable review_id in response: %w", err)
+	}
 
 	return result, nil
 }
 
 // trackCLIUsage sends a telemetry ping to the backend to track CLI usage
 // This is a best-effort call and failures are silently ignored
-func trackCLIUsage(apiURL, apiKey string, verbose bool) {
-	endpoint := strings.TrimSuffix(apiURL, "/") + "/api/v1/diff-review/cli-used"
-
-	req, err := http.NewRequest("POST", endpoint, nil)
-	if err != nil {
-		if verbose {
-			log.Printf("Failed to create telemetry request: %v", err)
-		}
-		return
-	}
+func trackCLIUsage(client *apiClient, verbose bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	req.Header.Set("X-API-Key", apiKey)
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	if err := client.postJSON(ctx, "/api/v1/diff-review/cli-used", nil, nil); err != nil {
 		if verbose {
 			log.Printf("Failed to send telemetry: %v", err)
 		}
 		return
 	}
-	defer resp.Body.Close()
 
-	if verbose && resp.StatusCode == http.StatusOK {
+	if verbose {
 		log.Println("CLI usage tracked successfully")
 	}
 }
 
-func pollReview(apiURL, apiKey, reviewID string, pollInterval, timeout time.Duration, verbose bool) (*diffReviewResponse, error) {
-	endpoint := strings.TrimSuffix(apiURL, "/") + "/api/v1/diff-review/" + reviewID
-	deadline := time.Now().Add(timeout)
+func pollReview(client *apiClient, reviewID string, pollInterval, timeout time.Duration, stream, verbose bool) (*diffReviewResponse, error) {
 	start := time.Now()
 	isTTY := term.IsTerminal(int(os.Stdout.Fd()))
 	fmt.Printf("Waiting for review completion (poll every %s, timeout %s)...\n", pollInterval, timeout)
 	os.Stdout.Sync()
 
-	if verbose {
-		log.Printf("Polling for review completion (timeout: %v)...", timeout)
-	}
-
-	for time.Now().Before(deadline) {
-		req, err := http.NewRequest("GET", endpoint, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+	var mu sync.Mutex
+	statusLine := ""
+	// printLine prints a progress line above the live status line on terminals
+	printLine := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if isTTY && statusLine != "" {
+			fmt.Printf("\r%-80s\r%s\n%-80s", "", line, statusLine)
+		} else {
+			fmt.Println(line)
 		}
+		os.Stdout.Sync()
+	}
 
-		req.Header.Set("X-API-Key", apiKey)
-
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send request: %w", err)
+	seenComments := make(map[string]bool)
+	printNewComments := func(r *diffReviewResponse) {
+		fingerprints := commentFingerprinter{}
+		for _, file := range r.Files {
+			for _, comment := range file.Comments {
+				key := fingerprints.next(file.FilePath, comment.Line, comment.Content)
+				if seenComments[key] {
+					continue
+				}
+				seenComments[key] = true
+				printLine(fmt.Sprintf("  [%s] %s:%d %s", strings.ToUpper(normalizeSeverity(comment.Severity)), file.FilePath, comment.Line, firstLine(comment.Content)))
+			}
 		}
+	}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response: %w", err)
-		}
+	result, err := watchReview(client, reviewID, pollInterval, timeout, stream, verbose, reviewWatcher{
+		OnStatus: func(status string) {
+			line := fmt.Sprintf("Status: %s | elapsed: %s", status, time.Since(start).Truncate(time.Second))
+			final := status == "completed" || status == "failed"
+			mu.Lock()
+			if isTTY {
+				statusLine = line
+				fmt.Printf("\r%-80s", line)
+				if final {
+					fmt.Println()
+					statusLine = ""
+				}
+				os.Stdout.Sync() // Force flush for real-time updates and clear prior text
+			} else {
+				fmt.Println(line)
+			}
+			mu.Unlock()
+			if verbose {
+				log.Printf("%s", line)
+			}
+		},
+		OnEvent: func(ev reviewEvent) {
+			// Log lines are chatty; show them only in verbose mode or when they report errors
+			if ev.Type == "log" && !verbose && ev.Level != "error" {
+				return
+			}
+			if msg := ev.message(); msg != "" {
+				printLine("• " + msg)
+			}
+		},
+		OnProgress: func(partial *diffReviewResponse) {
+			printNewComments(partial)
+			applyPartialToReviewState(partial)
+		},
+	})
+	if result == nil && err != nil && isTTY {
+		fmt.Println()
+	}
+	return result, err
+}
 
-		contentType := resp.Header.Get("Content-Type")
+// pollReviewStatus polls a single review until it completes, fails or times out,
+// calling onStatus after every poll. It prints nothing itself, so several
+// reviews can be polled concurrently.
+func pollReviewStatus(client *apiClient, reviewID string, pollInterval, timeout time.Duration, verbose bool, onStatus func(status string)) (*diffReviewResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-		}
+	if verbose {
+		log.Printf("Polling review %s for completion (timeout: %v)...", reviewID, timeout)
+	}
 
-		var result diffReviewResponse
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, formatJSONParseError(body, contentType, err)
+	for {
+		result, err := fetchReviewStatus(ctx, client, reviewID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errReviewTimeout
+			}
+			return nil, err
 		}
 
-		statusLine := fmt.Sprintf("Status: %s | elapsed: %s", result.Status, time.Since(start).Truncate(time.Second))
-		if isTTY {
-			fmt.Printf("\r%-80s", statusLine)
-			os.Stdout.Sync() // Force flush for real-time updates and clear prior text
-		} else {
-			fmt.Println(statusLine)
-		}
-		if verbose {
-			log.Printf("%s", statusLine)
+		if onStatus != nil {
+			onStatus(result.Status)
 		}
 
-		if result.Status == "completed" {
-			if isTTY {
-				fmt.Printf("\r%-80s\n", statusLine)
-			}
-			return &result, nil
+		if done, err := reviewFinished(result); done {
+			return result, err
 		}
 
-		if result.Status == "failed" {
-			if isTTY {
-				fmt.Printf("\r%-80s\n", statusLine)
-			}
-			// Return the result with error info instead of just an error
-			// This allows progressive loading to display error details in the UI
-			reason := strings.TrimSpace(result.Message)
-			if reason == "" {
-				reason = "no additional details provided"
-			}
-			result.Summary = fmt.Sprintf("Review failed: %s", reason)
-			return &result, fmt.Errorf("review failed: %s", reason)
+		if sleepContext(ctx, pollInterval) != nil {
+			return nil, errReviewTimeout
 		}
+	}
+}
 
-		time.Sleep(pollInterval)
+// fetchReviewStatus performs a single GET of the review status and results.
+// Transient failures are retried by the client.
+func fetchReviewStatus(ctx context.Context, client *apiClient, reviewID string) (*diffReviewResponse, error) {
+	var result diffReviewResponse
+	if err := client.getJSON(ctx, "/api/v1/diff-review/"+reviewID, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	fmt.Println()
-	return nil, fmt.Errorf("timeout waiting for review completion")
+// reviewFinished reports whether a polled result is terminal. A failed review returns
+// the result with error info instead of just an error, so progressive loading can
+// display the details in the UI.
+func reviewFinished(result *diffReviewResponse) (bool, error) {
+	switch result.Status {
+	case "completed":
+		return true, nil
+	case "failed":
+		reason := strings.TrimSpace(result.Message)
+		if reason == "" {
+			reason = "no additional details provided"
+		}
+		result.Summary = fmt.Sprintf("Review failed: %s", reason)
+		return true, fmt.Errorf("%w: %s", errReviewFailed, reason)
+	}
+	return false, nil
 }
 
 func renderResult(result *diffReviewResponse, format string) error {
@@ -2082,8 +3049,17 @@ func renderResult(result *diffReviewResponse, format string) error {
 	case "pretty":
 		return renderPretty(result)
 
+	case "sarif":
+		return writeSARIF(os.Stdout, result)
+
+	case "junit":
+		return renderJUnit(os.Stdout, result)
+
+	case "codeclimate":
+		return renderCodeClimate(os.Stdout, result)
+
 	default:
-		return fmt.Errorf("invalid output format: %s (must be json or pretty)", format)
+		return invalidInput("invalid output format: %s (must be pretty, json, sarif, junit or codeclimate)", format)
 	}
 }
 
@@ -2136,6 +3112,7 @@ func renderPretty(result *diffReviewResponse) error {
 
 	fmt.Println("\n" + strings.Repeat("=", 80))
 	fmt.Printf("Review complete: %d total comment(s)\n", countTotalComments(result.Files))
+	fmt.Println(formatSeveritySummary(countSeverities(result.Files)))
 	fmt.Println(strings.Repeat("=", 80) + "\n")
 
 	return nil
@@ -2149,71 +3126,8 @@ func countTotalComments(files []diffReviewFileResult) int {
 	return total
 }
 
-// Config holds the CLI configuration
-type Config struct {
-	APIKey string
-	APIURL string
-}
-
-// loadConfigValues attempts to load configuration from ~/.lrc.toml, then applies CLI/env overrides
-func loadConfigValues(apiKeyOverride, apiURLOverride string, verbose bool) (*Config, error) {
-	config := &Config{}
-
-	// Try to load from config file first
-	homeDir, err := os.UserHomeDir()
-	var k *koanf.Koanf
-	if err == nil {
-		configPath := filepath.Join(homeDir, ".lrc.toml")
-		if _, err := os.Stat(configPath); err == nil {
-			// Config file exists, try to load it
-			k = koanf.New(".")
-			if err := k.Load(file.Provider(configPath), toml.Parser()); err != nil {
-				return nil, fmt.Errorf("failed to load config file %s: %w", configPath, err)
-			}
-			if verbose {
-				log.Printf("Loaded config from: %s", configPath)
-			}
-		}
-	}
-
-	// Load API key: CLI/env overrides config file
-	if apiKeyOverride != "" {
-		config.APIKey = apiKeyOverride
-		if verbose {
-			log.Println("Using API key from CLI flag or environment variable")
-		}
-	} else if k != nil && k.String("api_key") != "" {
-		config.APIKey = k.String("api_key")
-		if verbose {
-			log.Println("Using API key from config file")
-		}
-	} else {
-		return nil, fmt.Errorf("API key not provided. Set via --api-key flag, LRC_API_KEY environment variable, or api_key in ~/.lrc.toml")
-	}
-
-	// Load API URL: CLI/env overrides config file
-	if apiURLOverride != "" && apiURLOverride != defaultAPIURL {
-		config.APIURL = apiURLOverride
-		if verbose {
-			log.Println("Using API URL from CLI flag or environment variable")
-		}
-	} else if k != nil && k.String("api_url") != "" {
-		config.APIURL = k.String("api_url")
-		if verbose {
-			log.Println("Using API URL from config file")
-		}
-	} else {
-		config.APIURL = defaultAPIURL
-		if verbose {
-			log.Printf("Using default API URL: %s", config.APIURL)
-		}
-	}
-
-	return config, nil
-}
-
 // saveBundleForInspection saves the bundle in multiple formats for inspection
-func saveBundleForInspection(path string, diffContent, zipData []byte, base64Diff string, verbose bool) error {
+func saveBundleForInspection(path string, diffContent []byte, excluded []excludedDiffFile, bundleCtx *bundleContext, zipData []byte, base64Diff string, verbose bool) error {
 	// Create a comprehensive bundle file with sections
 	var buf bytes.Buffer
 
@@ -2226,12 +3140,37 @@ func saveBundleForInspection(path string, diffContent, zipData []byte, base64Dif
 	buf.Write(diffContent)
 	buf.WriteString("\n\n")
 
-	buf.WriteString("## SECTION 2: Zip Archive Info\n")
+	buf.WriteString("## SECTION 2: Excluded Files\n")
+	buf.WriteString("## Files dropped by include/exclude rules before zipping\n")
+	buf.WriteString("## " + strings.Repeat("-", 76) + "\n")
+	if len(excluded) == 0 {
+		buf.WriteString("## (none)\n")
+	}
+	for _, ex := range excluded {
+		buf.WriteString(fmt.Sprintf("## %s  [%s]\n", ex.FilePath, ex.Reason))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString("## SECTION 3: Zip Archive Info\n")
 	buf.WriteString("## " + strings.Repeat("-", 76) + "\n")
 	buf.WriteString(fmt.Sprintf("## Zip size: %d bytes\n", len(zipData)))
-	buf.WriteString("## Contains: diff.txt\n\n")
+	buf.WriteString("## Contains: diff.txt\n")
+	for _, name := range bundleCtx.entryNames() {
+		buf.WriteString("##           " + name + "\n")
+	}
+	if bundleCtx != nil {
+		for _, s := range bundleCtx.Manifest.Skipped {
+			buf.WriteString(fmt.Sprintf("## Context skipped: %s  [%s]\n", s.Path, s.Reason))
+		}
+		if manifest, err := json.MarshalIndent(bundleCtx.Manifest, "", "  "); err == nil {
+			buf.WriteString("##\n## " + bundleManifestName + ":\n")
+			buf.Write(manifest)
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("\n")
 
-	buf.WriteString("## SECTION 3: Base64 Encoded Bundle (sent to API)\n")
+	buf.WriteString("## SECTION 4: Base64 Encoded Bundle (sent to API)\n")
 	buf.WriteString("## This is what gets transmitted in the API request\n")
 	buf.WriteString("## " + strings.Repeat("-", 76) + "\n\n")
 	buf.WriteString(base64Diff)
@@ -2998,6 +3937,10 @@ const (
 
 var managedHooks = []string{"pre-commit", "prepare-commit-msg", "commit-msg", "post-commit"}
 
+// optionalHooks are installed only on request (lrc hooks install --pre-push) and
+// kept up to date by later installs once present.
+var optionalHooks = []string{"pre-push"}
+
 type hooksMeta struct {
 	Path     string `json:"path"`
 	PrevPath string `json:"prev_path,omitempty"`
@@ -3097,6 +4040,7 @@ func writeManagedHookScripts(dir string) error {
 		"prepare-commit-msg": generatePrepareCommitMsgHook(),
 		"commit-msg":         generateCommitMsgHook(),
 		"post-commit":        generatePostCommitHook(),
+		"pre-push":           generatePrePushHook(),
 	}
 
 	for name, content := range scripts {
@@ -3112,6 +4056,9 @@ func writeManagedHookScripts(dir string) error {
 
 // runHooksInstall installs dispatchers and managed hook scripts under either global core.hooksPath or the current repo hooks path when --local is used
 func runHooksInstall(c *cli.Context) error {
+	if c.Bool("server") {
+		return installServerHook()
+	}
 	localInstall := c.Bool("local")
 	requestedPath := strings.TrimSpace(c.String("path"))
 	var hooksPath string
@@ -3123,7 +4070,8 @@ func runHooksInstall(c *cli.Context) error {
 			return fmt.Errorf("not in a git repository (no .git directory found)")
 		}
 
-		gitDir, err := resolveGitDir()
+		// Hooks live in the common git dir, so linked worktrees share them
+		gitDir, err := resolveGitCommonDir()
 		if err != nil {
 			return err
 		}
@@ -3181,7 +4129,13 @@ func runHooksInstall(c *cli.Context) error {
 		return err
 	}
 
-	for _, hookName := range managedHooks {
+	hookNames := append([]string{}, managedHooks...)
+	for _, hookName := range optionalHooks {
+		if c.Bool(hookName) || hookHasManagedSection(filepath.Join(absHooksPath, hookName)) {
+			hookNames = append(hookNames, hookName)
+		}
+	}
+	for _, hookName := range hookNames {
 		hookPath := filepath.Join(absHooksPath, hookName)
 		dispatcher := generateDispatcherHook(hookName)
 		if err := installHook(hookPath, dispatcher, hookName, backupDir, true); err != nil {
@@ -3207,6 +4161,9 @@ func runHooksInstall(c *cli.Context) error {
 
 // runHooksUninstall removes lrc-managed sections from dispatchers and managed scripts (global or local)
 func runHooksUninstall(c *cli.Context) error {
+	if c.Bool("server") {
+		return uninstallServerHook()
+	}
 	localUninstall := c.Bool("local")
 	requestedPath := strings.TrimSpace(c.String("path"))
 	var hooksPath string
@@ -3215,7 +4172,8 @@ func runHooksUninstall(c *cli.Context) error {
 		if !isGitRepository() {
 			return fmt.Errorf("not in a git repository (no .git directory found)")
 		}
-		gitDir, err := resolveGitDir()
+		// Hooks live in the common git dir, so linked worktrees share them
+		gitDir, err := resolveGitCommonDir()
 		if err != nil {
 			return err
 		}
@@ -3254,7 +4212,7 @@ func runHooksUninstall(c *cli.Context) error {
 
 	// Remove lrc sections from each managed hook dispatcher
 	removed := 0
-	for _, hookName := range managedHooks {
+	for _, hookName := range append(append([]string{}, managedHooks...), optionalHooks...) {
 		hookPath := filepath.Join(absHooksPath, hookName)
 		if err := uninstallHook(hookPath, hookName); err != nil {
 			fmt.Printf("⚠️  Warning: failed to uninstall %s: %v\n", hookName, err)
@@ -3359,7 +4317,7 @@ func cleanEmptyHooksDir(dir string) {
 }
 
 func runHooksDisable(c *cli.Context) error {
-	gitDir, err := resolveGitDir()
+	gitDir, err := resolveGitCommonDir()
 	if err != nil {
 		return fmt.Errorf("not in a git repository: %w", err)
 	}
@@ -3379,7 +4337,7 @@ func runHooksDisable(c *cli.Context) error {
 }
 
 func runHooksEnable(c *cli.Context) error {
-	gitDir, err := resolveGitDir()
+	gitDir, err := resolveGitCommonDir()
 	if err != nil {
 		return fmt.Errorf("not in a git repository: %w", err)
 	}
@@ -3414,7 +4372,7 @@ func runHooksStatus(c *cli.Context) error {
 		return fmt.Errorf("failed to resolve hooks path: %w", err)
 	}
 
-	gitDir, gitErr := resolveGitDir()
+	gitDir, gitErr := resolveGitCommonDir()
 	repoDisabled := false
 	if gitErr == nil {
 		repoDisabled = fileExists(filepath.Join(gitDir, "lrc", "disabled"))
@@ -3438,7 +4396,7 @@ func runHooksStatus(c *cli.Context) error {
 		fmt.Println("repo: not detected")
 	}
 
-	for _, hookName := range managedHooks {
+	for _, hookName := range append(append([]string{}, managedHooks...), optionalHooks...) {
 		hookPath := filepath.Join(absHooksPath, hookName)
 		fmt.Printf("%s: ", hookName)
 		if hookHasManagedSection(hookPath) {
@@ -3742,6 +4700,15 @@ func generatePostCommitHook() string {
 	})
 }
 
+// generatePrePushHook generates the optional pre-push hook script
+func generatePrePushHook() string {
+	return renderHookTemplate("hooks/pre-push.sh", map[string]string{
+		hookMarkerBeginPlaceholder: lrcMarkerBegin,
+		hookMarkerEndPlaceholder:   lrcMarkerEnd,
+		hookVersionPlaceholder:     version,
+	})
+}
+
 func generateDispatcherHook(hookName string) string {
 	return renderHookTemplate("hooks/dispatcher.sh", map[string]string{
 		hookMarkerBeginPlaceholder: lrcMarkerBegin,
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/knadh/koanf/v2"
+)
+
+// defaultPolicyFile is the review policy looked up at the repository root. It is
+// meant to be committed so everyone audits against the same rules.
+const defaultPolicyFile = ".lrc-policy.toml"
+
+// What lrc enforce does with commits that break a rule.
+const (
+	policyActionReject = "reject"
+	policyActionWarn   = "warn"
+)
+
+// policyRule requires every commit touching Paths to have one of the Allow statuses,
+// and with Signed a verified signed attestation. A rule without paths applies to
+// every commit. Branches and Action only matter to lrc enforce, which checks pushes
+// to matching branches and rejects or warns.
+//
+//	[[rule]]
+//	name     = "payments"
+//	branches = ["main", "release/*"]
+//	paths    = ["src/payments/"]
+//	allow    = ["reviewed"]
+//	signed   = true
+//	action   = "reject"
+type policyRule struct {
+	Name     string   `koanf:"name" json:"name"`
+	Branches []string `koanf:"branches" json:"branches,omitempty"`
+	Paths    []string `koanf:"paths" json:"paths,omitempty"`
+	Allow    []string `koanf:"allow" json:"allow"`
+	Signed   bool     `koanf:"signed" json:"signed,omitempty"`
+	Action   string   `koanf:"action" json:"action"`
+}
+
+// reviewPolicy is a parsed policy file.
+type reviewPolicy struct {
+	Path  string       `json:"path"`
+	Rules []policyRule `json:"rules"`
+}
+
+// policyViolation is one commit that breaks one rule.
+type policyViolation struct {
+	Rule   string   `json:"rule"`
+	Action string   `json:"action"`
+	Commit string   `json:"commit"`
+	Status string   `json:"status"` // the commit's review status, or its signature status for signed rules
+	Files  []string `json:"files"`
+}
+
+// loadReviewPolicy reads a policy file. With explicit false a missing file is not
+// an error and yields a nil policy.
+func loadReviewPolicy(policyPath string, explicit bool) (*reviewPolicy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		if !explicit && os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, invalidInput("cannot read policy file %s: %v", policyPath, err)
+	}
+	return parseReviewPolicy(policyPath, data)
+}
+
+// parseReviewPolicy parses policy TOML; source names it in errors.
+func parseReviewPolicy(source string, data []byte) (*reviewPolicy, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider(data), toml.Parser()); err != nil {
+		return nil, invalidInput("failed to parse policy file %s: %v", source, err)
+	}
+	policy := &reviewPolicy{Path: source}
+	if err := k.Unmarshal("rule", &policy.Rules); err != nil {
+		return nil, invalidInput("invalid rules in policy file %s: %v", source, err)
+	}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if len(rule.Allow) == 0 {
+			rule.Allow = []string{auditReviewed}
+		}
+		for _, status := range rule.Allow {
+			if !isAuditStatus(status) {
+				return nil, invalidInput("invalid status %q in %s rule %q (must be one of: %s)", status, source, rule.Name, strings.Join(auditStatuses, ", "))
+			}
+		}
+		switch rule.Action {
+		case "":
+			rule.Action = policyActionReject
+		case policyActionReject, policyActionWarn:
+		default:
+			return nil, invalidInput("invalid action %q in %s rule %q (must be reject or warn)", rule.Action, source, rule.Name)
+		}
+		for _, pattern := range rule.Branches {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, invalidInput("invalid branch pattern %q in %s rule %q", pattern, source, rule.Name)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// repoPolicyPath returns the default policy file of the current repository.
+func repoPolicyPath() (string, error) {
+	out, err := runGitCommand("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository")
+	}
+	return filepath.Join(strings.TrimSpace(string(out)), defaultPolicyFile), nil
+}
+
+// forBranch returns the rules that apply to pushes to a branch (short name, e.g.
+// "main"), or nil when none do.
+func (p *reviewPolicy) forBranch(branch string) *reviewPolicy {
+	if p == nil {
+		return nil
+	}
+	filtered := &reviewPolicy{Path: p.Path}
+	for _, rule := range p.Rules {
+		if rule.appliesToBranch(branch) {
+			filtered.Rules = append(filtered.Rules, rule)
+		}
+	}
+	if len(filtered.Rules) == 0 {
+		return nil
+	}
+	return filtered
+}
+
+// appliesToBranch reports whether pushes to branch are checked against the rule.
+// A rule without branches applies to every branch.
+func (r policyRule) appliesToBranch(branch string) bool {
+	if len(r.Branches) == 0 {
+		return true
+	}
+	for _, pattern := range r.Branches {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// coversBranch reports whether every rule of p also applies to branch, so commits
+// already on branch were checked against all of them.
+func (p *reviewPolicy) coversBranch(branch string) bool {
+	for _, rule := range p.Rules {
+		if !rule.appliesToBranch(branch) {
+			return false
+		}
+	}
+	return true
+}
+
+// requiresSignature reports whether any rule needs signed attestations.
+func (p *reviewPolicy) requiresSignature() bool {
+	if p == nil {
+		return false
+	}
+	for _, rule := range p.Rules {
+		if rule.Signed {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns the rule violations of a commit. Signed rules compare against the
+// commit's Signature, which the caller fills in with verifyCommit's status.
+func (p *reviewPolicy) check(c auditCommit) []policyViolation {
+	if p == nil {
+		return nil
+	}
+	var violations []policyViolation
+	for _, rule := range p.Rules {
+		var matched []string
+		for _, f := range c.Files {
+			if len(rule.Paths) == 0 || matchAnyGlob(rule.Paths, f) != "" {
+				matched = append(matched, f)
+			}
+		}
+		if len(rule.Paths) > 0 && len(matched) == 0 {
+			continue
+		}
+		allowed := false
+		for _, s := range rule.Allow {
+			if s == c.Status {
+				allowed = true
+				break
+			}
+		}
+		status := c.Status
+		if allowed && rule.Signed && c.Signature != verifyVerified {
+			allowed, status = false, c.Signature
+		}
+		if !allowed {
+			violations = append(violations, policyViolation{Rule: rule.Name, Action: rule.Action, Commit: c.Commit, Status: status, Files: matched})
+		}
+	}
+	return violations
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// emptyTreeHash is git's empty tree, the diff base for a push that starts at a root commit.
+const emptyTreeHash = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// defaultPrePushFailOn is the lowest severity that blocks a push.
+const defaultPrePushFailOn = "error"
+
+// prePushUpdate is one line of pre-push input.
+type prePushUpdate struct {
+	LocalRef, LocalSHA, RemoteRef, RemoteSHA string
+}
+
+// readPrePushUpdates parses pre-push input:
+// "<local ref> <local sha> <remote ref> <remote sha>" per line.
+func readPrePushUpdates(r io.Reader) ([]prePushUpdate, error) {
+	var updates []prePushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, invalidInput("malformed pre-push line %q", scanner.Text())
+		}
+		updates = append(updates, prePushUpdate{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	return updates, scanner.Err()
+}
+
+// pushRange works out what an update sends: the commits (newest first) and the
+// diff range covering them. Commits the remote already has are left out; for a
+// new branch that means every commit on a remote-tracking branch of remote.
+func pushRange(remote string, u prePushUpdate) (diffRange string, commits []auditCommit, err error) {
+	var revs []string
+	if !isZeroOID(u.RemoteSHA) && gitObjectExists(u.RemoteSHA) {
+		revs = []string{u.RemoteSHA + ".." + u.LocalSHA}
+	} else {
+		revs = []string{u.LocalSHA, "--not", "--remotes=" + remote}
+	}
+	commits, err = collectAuditCommits(revs)
+	if err != nil || len(commits) == 0 {
+		return "", nil, err
+	}
+
+	if strings.Contains(revs[0], "..") {
+		return revs[0], commits, nil
+	}
+	out, err := runGitCommand("git", append([]string{"rev-list", "--topo-order", "--reverse"}, revs...)...)
+	if err != nil {
+		return "", nil, err
+	}
+	oldest := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	base := emptyTreeHash
+	if parent, err := runGitCommand("git", "rev-parse", "--verify", "--quiet", oldest+"^1"); err == nil {
+		base = strings.TrimSpace(string(parent))
+	}
+	return base + ".." + u.LocalSHA, commits, nil
+}
+
+func gitObjectExists(oid string) bool {
+	_, err := runGitCommand("git", "cat-file", "-e", oid+"^{commit}")
+	return err == nil
+}
+
+// unattestedCommits returns the commits without a reviewed or vouched attestation,
+// from their trailer or notes.
+func unattestedCommits(commits []auditCommit) []auditCommit {
+	var pending []auditCommit
+	for _, c := range commits {
+		if c.Status != auditReviewed && c.Status != auditVouched {
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+// askTTY asks a yes/no question on the terminal. It returns def when there is no
+// terminal to ask on, as when pushing from an IDE or a script.
+func askTTY(question string, def bool) bool {
+	tty, err := openTTY()
+	if err != nil {
+		return def
+	}
+	defer tty.Close()
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s: ", question, hint)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
+
+// runPrePush implements `lrc pre-push <remote> <url>`, called by the pre-push hook
+// with the pushed refs on stdin. Ranges whose commits all carry a reviewed or
+// vouched attestation pass as they are; others are reviewed headless. Findings at
+// or above pre_push_fail_on block the push, lesser findings ask first, and a
+// review that cannot run asks whether to push without one.
+func runPrePush(c *cli.Context) error {
+	remote := c.Args().First()
+	if remote == "" {
+		return reviewExitError(invalidInput("usage: lrc pre-push <remote> [<url>] (pushed refs are read from stdin)"))
+	}
+	updates, err := readPrePushUpdates(os.Stdin)
+	if err != nil {
+		return reviewExitError(err)
+	}
+
+	cfg, err := loadLayeredConfig(false)
+	if err != nil {
+		return err
+	}
+	failOnValue := c.String("fail-on")
+	if failOnValue == "" {
+		failOnValue, _ = cfg.Effective("pre_push_fail_on")
+	}
+	failOn, err := parseFailOn(failOnValue)
+	if err != nil {
+		return reviewExitError(err)
+	}
+	_, notesRef, err := notesSettings(false)
+	if err != nil {
+		return err
+	}
+	reviewNotes, attestationNotes := readNotesIndex(notesRef), readNotesIndex(attestationNotesRef(notesRef))
+
+	reviewed := map[string]bool{}
+	for _, u := range updates {
+		if isZeroOID(u.LocalSHA) {
+			continue // deleting a remote branch
+		}
+		diffRange, commits, err := pushRange(remote, u)
+		if err != nil {
+			return err
+		}
+		if len(commits) == 0 || reviewed[diffRange] {
+			continue
+		}
+		reviewed[diffRange] = true
+
+		applyNoteStatuses(commits, reviewNotes, attestationNotes)
+		pending := unattestedCommits(commits)
+		if len(pending) == 0 {
+			fmt.Fprintf(os.Stderr, "LiveReview pre-push: %s: all %d commit(s) already reviewed\n", u.RemoteRef, len(commits))
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "LiveReview pre-push: %s: reviewing %s (%d of %d commit(s) without a review)\n", u.RemoteRef, diffRange, len(pending), len(commits))
+
+		if err := reviewPushRange(c, diffRange, failOn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reviewPushRange reviews one pushed range and decides whether the push may go on.
+func reviewPushRange(c *cli.Context, diffRange, failOn string) error {
+	opts, err := buildOptionsFromContext(c, false)
+	if err != nil {
+		return reviewExitError(&invalidInputError{err: err})
+	}
+	resultFile, err := os.CreateTemp("", "lrc-pre-push-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	resultFile.Close()
+	defer os.Remove(resultFile.Name())
+
+	opts.diffSource = "range"
+	opts.rangeVal = diffRange
+	opts.failOn = failOn
+	opts.failOnCategories = nil
+	opts.serve = false
+	opts.precommit = false
+	opts.output = defaultOutputFormat
+	opts.saveJSON = resultFile.Name()
+
+	reviewErr := runReviewWithOptions(opts)
+	var exitErr cli.ExitCoder
+	switch {
+	case errors.As(reviewErr, &exitErr) && exitErr.ExitCode() == exitFindings:
+		fmt.Fprintf(os.Stderr, "LiveReview pre-push: push blocked: %s\n", exitErr.Error())
+		fmt.Fprintln(os.Stderr, "Fix the findings and push again, or bypass with 'git push --no-verify'.")
+		return cli.Exit("", 1)
+	case reviewErr != nil:
+		fmt.Fprintf(os.Stderr, "LiveReview pre-push: review failed: %v\n", reviewErr)
+		if askTTY("Push without a review?", false) {
+			return nil
+		}
+		return cli.Exit("LiveReview pre-push: push aborted", 1)
+	}
+
+	var result diffReviewResponse
+	if data, err := os.ReadFile(resultFile.Name()); err == nil && json.Unmarshal(data, &result) == nil {
+		if n := countTotalComments(result.Files); n > 0 {
+			if !askTTY(fmt.Sprintf("%d finding(s) below %s. Push anyway?", n, failOn), true) {
+				return cli.Exit("LiveReview pre-push: push aborted", 1)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadPrePushUpdates(t *testing.T) {
+	input := "refs/heads/main 1111111111111111111111111111111111111111 refs/heads/main 2222222222222222222222222222222222222222\n" +
+		"(delete) 0000000000000000000000000000000000000000 refs/heads/old 3333333333333333333333333333333333333333\n"
+	updates, err := readPrePushUpdates(strings.NewReader(input))
+	if err != nil || len(updates) != 2 {
+		t.Fatalf("readPrePushUpdates = %+v, %v", updates, err)
+	}
+	if updates[0].RemoteRef != "refs/heads/main" || !isZeroOID(updates[1].LocalSHA) {
+		t.Errorf("unexpected updates: %+v", updates)
+	}
+	if _, err := readPrePushUpdates(strings.NewReader("refs/heads/main abc\n")); err == nil {
+		t.Error("malformed line should be an error")
+	}
+}
+
+func TestPushRange(t *testing.T) {
+	initTestRepo(t)
+	commit := func(file, message string) string {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", file)
+		runGit(t, "commit", "-q", "-m", message)
+		return runGit(t, "rev-parse", "HEAD")
+	}
+	const zero = "0000000000000000000000000000000000000000"
+
+	root := commit("a.txt", "root")
+	pushed := commit("b.txt", "pushed\n\n"+preCommitTrailerKey+": ran")
+	runGit(t, "update-ref", "refs/remotes/origin/main", pushed)
+	reviewed := commit("c.txt", "reviewed\n\n"+preCommitTrailerKey+": ran (iter:1, coverage:0%)")
+	skipped := commit("d.txt", "skipped\n\n"+preCommitTrailerKey+": skipped")
+
+	// Existing remote branch: everything after the remote tip
+	diffRange, commits, err := pushRange("origin", prePushUpdate{LocalSHA: skipped, RemoteSHA: pushed})
+	if err != nil || diffRange != pushed+".."+skipped || len(commits) != 2 {
+		t.Fatalf("pushRange = %q, %d commits, %v", diffRange, len(commits), err)
+	}
+	if pending := unattestedCommits(commits); len(pending) != 1 || pending[0].Commit != skipped {
+		t.Errorf("unattestedCommits = %+v", pending)
+	}
+
+	// New branch: commits not on any origin branch, diffed from the oldest one's parent
+	diffRange, commits, _ = pushRange("origin", prePushUpdate{LocalSHA: reviewed, RemoteSHA: zero})
+	if diffRange != pushed+".."+reviewed || len(commits) != 1 || len(unattestedCommits(commits)) != 0 {
+		t.Errorf("new branch pushRange = %q, %+v", diffRange, commits)
+	}
+
+	// Nothing on the remote yet: the range starts at the empty tree
+	runGit(t, "update-ref", "-d", "refs/remotes/origin/main")
+	diffRange, commits, _ = pushRange("origin", prePushUpdate{LocalSHA: pushed, RemoteSHA: zero})
+	if diffRange != emptyTreeHash+".."+pushed || len(commits) != 2 || commits[1].Commit != root {
+		t.Errorf("first push pushRange = %q, %d commits", diffRange, len(commits))
+	}
+	if out, err := exec.Command("git", "diff", "--name-only", diffRange).Output(); err != nil || strings.Fields(string(out))[0] != "a.txt" {
+		t.Errorf("range %q is not diffable: %s, %v", diffRange, out, err)
+	}
+
+	// Already pushed: nothing to review
+	if _, commits, _ := pushRange("origin", prePushUpdate{LocalSHA: reviewed, RemoteSHA: reviewed}); len(commits) != 0 {
+		t.Errorf("up-to-date push should have no commits, got %d", len(commits))
+	}
+}
+
+func TestDispatcherRepoLocalInstall(t *testing.T) {
+	dir := initTestRepo(t)
+	hooksDir := filepath.Join(dir, ".git", "hooks")
+	if err := writeManagedHookScripts(filepath.Join(hooksDir, "lrc")); err != nil {
+		t.Fatal(err)
+	}
+	// Stand-in for the managed script: record the refs it was given
+	stub := "#!/bin/sh\ncat > \"$PWD/lrc-saw\"\n"
+	if err := os.WriteFile(filepath.Join(hooksDir, "lrc", "pre-push"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A repo-local install puts the dispatcher in .git/hooks itself, ahead of the existing hook
+	existing := "#!/bin/sh\ncat > \"$PWD/local-saw\"\n"
+	hookPath := filepath.Join(hooksDir, "pre-push")
+	os.WriteFile(hookPath, []byte(existing), 0755)
+	if err := installHook(hookPath, generateDispatcherHook("pre-push"), "pre-push", t.TempDir(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, hookPath, "origin", "url")
+	cmd.Stdin = strings.NewReader("refs/heads/main abc refs/heads/main def\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("dispatcher failed: %v: %s", err, out)
+	}
+	for _, name := range []string{"lrc-saw", "local-saw"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !strings.Contains(string(data), "refs/heads/main abc") {
+			t.Errorf("%s did not receive the pushed refs: %q, %v", name, data, err)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// JUnit XML: each file is a testsuite and each comment a failing testcase.
+// Files without comments get one passing testcase so they still show up in CI test tabs.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// buildJUnitReport converts a review result into JUnit testsuites.
+func buildJUnitReport(result *diffReviewResponse) *junitTestSuites {
+	report := &junitTestSuites{Name: "lrc"}
+	for _, file := range result.Files {
+		suite := junitTestSuite{Name: file.FilePath}
+		for _, comment := range file.Comments {
+			name := file.FilePath
+			if comment.Line > 0 {
+				name = fmt.Sprintf("%s:%d", file.FilePath, comment.Line)
+			}
+			if comment.Category != "" {
+				name += " (" + comment.Category + ")"
+			}
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      name,
+				ClassName: file.FilePath,
+				Failure: &junitFailure{
+					Message: firstLine(comment.Content),
+					Type:    normalizeSeverity(comment.Severity),
+					Text:    comment.Content,
+				},
+			})
+			suite.Failures++
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: file.FilePath, ClassName: file.FilePath})
+		}
+		suite.Tests = len(suite.TestCases)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+	return report
+}
+
+// renderJUnit writes the review result as JUnit XML.
+func renderJUnit(w io.Writer, result *diffReviewResponse) error {
+	data, err := xml.MarshalIndent(buildJUnitReport(result), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit XML: %w", err)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Code Climate JSON (also read by GitLab Code Quality widgets).
+
+type codeClimateIssue struct {
+	Type        string              `json:"type"`
+	CheckName   string              `json:"check_name"`
+	Description string              `json:"description"`
+	Content     *codeClimateContent `json:"content,omitempty"`
+	Categories  []string            `json:"categories"`
+	Severity    string              `json:"severity"`
+	Fingerprint string              `json:"fingerprint"`
+	Location    codeClimateLocation `json:"location"`
+}
+
+type codeClimateContent struct {
+	Body string `json:"body"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeClimateSeverity maps a comment severity onto the Code Climate scale.
+func codeClimateSeverity(severity string) string {
+	switch normalizeSeverity(severity) {
+	case "critical":
+		return "critical"
+	case "error":
+		return "major"
+	case "warning":
+		return "minor"
+	default:
+		return "info"
+	}
+}
+
+// codeClimateCategory picks the closest Code Climate category for a comment category.
+func codeClimateCategory(category string) string {
+	c := strings.ToLower(category)
+	switch {
+	case strings.Contains(c, "secur"):
+		return "Security"
+	case strings.Contains(c, "bug"), strings.Contains(c, "error"), strings.Contains(c, "correct"):
+		return "Bug Risk"
+	case strings.Contains(c, "perf"):
+		return "Performance"
+	case strings.Contains(c, "complex"):
+		return "Complexity"
+	case strings.Contains(c, "duplic"):
+		return "Duplication"
+	case strings.Contains(c, "compat"):
+		return "Compatibility"
+	case strings.Contains(c, "style"), strings.Contains(c, "format"), strings.Contains(c, "naming"):
+		return "Style"
+	default:
+		return "Clarity"
+	}
+}
+
+// commentFingerprint identifies a finding across pipeline runs. It hashes the path,
+// line and a hash of the whitespace-normalized content, so re-wrapped text still matches.
+// occurrence numbers identical comments on the same line; the first one (0) is left out
+// of the hash, so fingerprints of unique comments do not depend on it.
+func commentFingerprint(filePath string, line int, content string, occurrence int) string {
+	contentHash := sha256.Sum256([]byte(strings.Join(strings.Fields(content), " ")))
+	key := fmt.Sprintf("%s\x00%d\x00%s", filePath, line, hex.EncodeToString(contentHash[:]))
+	if occurrence > 0 {
+		key += fmt.Sprintf("\x00%d", occurrence)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// commentFingerprinter hands out fingerprints for the comments of one result in order,
+// counting occurrences so duplicate comments on a line get distinct fingerprints.
+type commentFingerprinter map[string]int
+
+func (f commentFingerprinter) next(filePath string, line int, content string) string {
+	first := commentFingerprint(filePath, line, content, 0)
+	occurrence := f[first]
+	f[first]++
+	if occurrence == 0 {
+		return first
+	}
+	return commentFingerprint(filePath, line, content, occurrence)
+}
+
+// buildCodeClimateReport converts a review result into Code Climate issues.
+func buildCodeClimateReport(result *diffReviewResponse) []codeClimateIssue {
+	issues := []codeClimateIssue{}
+	fingerprints := commentFingerprinter{}
+	for _, file := range result.Files {
+		for _, comment := range file.Comments {
+			// Code Climate lines are 1-based; anchor file-level comments to the first line
+			line := comment.Line
+			if line < 1 {
+				line = 1
+			}
+			issue := codeClimateIssue{
+				Type:        "issue",
+				CheckName:   sarifRuleID(comment.Category),
+				Description: strings.Join(strings.Fields(comment.Content), " "),
+				Categories:  []string{codeClimateCategory(comment.Category)},
+				Severity:    codeClimateSeverity(comment.Severity),
+				Fingerprint: fingerprints.next(file.FilePath, comment.Line, comment.Content),
+				Location:    codeClimateLocation{Path: file.FilePath, Lines: codeClimateLines{Begin: line}},
+			}
+			if strings.Contains(strings.TrimSpace(comment.Content), "\n") {
+				issue.Content = &codeClimateContent{Body: comment.Content}
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// renderCodeClimate writes the review result as a Code Climate JSON array.
+func renderCodeClimate(w io.Writer, result *diffReviewResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(buildCodeClimateReport(result))
+}
+
+// firstLine returns the first non-empty line of s, for one-line messages.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+)
+
+func reportTestResult() *diffReviewResponse {
+	return &diffReviewResponse{
+		Status:  "completed",
+		Summary: "Two files need attention.",
+		Files: []diffReviewFileResult{
+			{
+				FilePath: "test/file.go",
+				Comments: []diffReviewComment{
+					{
+						Line:     11,
+						Content:  "This is a test comment with\nmultiple lines & <markup>",
+						Severity: "warning",
+						Category: "style",
+					},
+					{
+						Line:     12,
+						Content:  "Possible SQL injection",
+						Severity: "critical",
+						Category: "security",
+					},
+					{
+						Line:     12,
+						Content:  "Possible SQL injection",
+						Severity: "critical",
+						Category: "security",
+					},
+				},
+			},
+			{
+				FilePath: "test/clean.go",
+				Comments: []diffReviewComment{},
+			},
+			{
+				FilePath: "docs/readme.md",
+				Comments: []diffReviewComment{
+					{
+						Line:     0,
+						Content:  "File-level note without a category",
+						Severity: "",
+					},
+				},
+			},
+		},
+	}
+}
+
+// TestReportFormatsGolden checks the JUnit and Code Climate renderers against golden files
+func TestReportFormatsGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		golden string
+		render func(*bytes.Buffer, *diffReviewResponse) error
+		valid  func([]byte) error
+	}{
+		{
+			name:   "junit",
+			golden: "junit.xml",
+			render: func(b *bytes.Buffer, r *diffReviewResponse) error { return renderJUnit(b, r) },
+			valid:  func(data []byte) error { return xml.Unmarshal(data, &junitTestSuites{}) },
+		},
+		{
+			name:   "codeclimate",
+			golden: "codeclimate.json",
+			render: func(b *bytes.Buffer, r *diffReviewResponse) error { return renderCodeClimate(b, r) },
+			valid:  func(data []byte) error { return json.Unmarshal(data, &[]codeClimateIssue{}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(&buf, reportTestResult()); err != nil {
+				t.Fatalf("Failed to render %s: %v", tt.name, err)
+			}
+			if err := tt.valid(buf.Bytes()); err != nil {
+				t.Fatalf("Generated %s does not parse: %v", tt.name, err)
+			}
+			compareGolden(t, filepath.Join("reports", tt.golden), buf.Bytes())
+		})
+	}
+}
+
+// TestCommentFingerprintStable checks that fingerprints ignore re-wrapping but not moves or edits
+func TestCommentFingerprintStable(t *testing.T) {
+	base := commentFingerprint("a.go", 10, "Check the error\nreturned here", 0)
+
+	if got := commentFingerprint("a.go", 10, "  Check the error returned   here ", 0); got != base {
+		t.Errorf("fingerprint changed on whitespace-only difference: %s != %s", got, base)
+	}
+	for name, fp := range map[string]string{
+		"path":       commentFingerprint("b.go", 10, "Check the error returned here", 0),
+		"line":       commentFingerprint("a.go", 11, "Check the error returned here", 0),
+		"content":    commentFingerprint("a.go", 10, "Check the value returned here", 0),
+		"occurrence": commentFingerprint("a.go", 10, "Check the error returned here", 1),
+	} {
+		if fp == base {
+			t.Errorf("fingerprint did not change when %s changed", name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// defaultChunkBytes is the per-submission diff budget used by --chunk when
+// neither --chunk-bytes nor chunk_bytes in config is set.
+const defaultChunkBytes = 512 * 1024
+
+// diffChunk is a file-aligned slice of a diff that is submitted as its own review.
+type diffChunk struct {
+	Files   []string
+	Content []byte
+}
+
+// splitDiffIntoChunks packs whole file sections into chunks of at most budget bytes.
+// Chunks never split a file, so a single file larger than the budget is an error: it
+// would be submitted as an oversized chunk and rejected just like the whole diff.
+func splitDiffIntoChunks(diffContent []byte, budget int) ([]diffChunk, error) {
+	if budget <= 0 || len(diffContent) <= budget {
+		return []diffChunk{{Files: sectionPaths(splitDiffSections(diffContent)), Content: diffContent}}, nil
+	}
+
+	var chunks []diffChunk
+	var current diffChunk
+	for _, section := range splitDiffSections(diffContent) {
+		if len(section.Content) > budget {
+			name := section.FilePath
+			if name == "" {
+				name = "diff preamble"
+			}
+			return nil, fmt.Errorf("%s alone is %d bytes of diff, over the %d-byte chunk budget; exclude it (--exclude) or raise --chunk-bytes", name, len(section.Content), budget)
+		}
+		if len(current.Content) > 0 && len(current.Content)+len(section.Content) > budget {
+			chunks = append(chunks, current)
+			current = diffChunk{}
+		}
+		current.Content = append(current.Content, section.Content...)
+		if section.FilePath != "" {
+			current.Files = append(current.Files, section.FilePath)
+		}
+	}
+	if len(current.Content) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks, nil
+}
+
+func sectionPaths(sections []diffFileSection) []string {
+	var paths []string
+	for _, s := range sections {
+		if s.FilePath != "" {
+			paths = append(paths, s.FilePath)
+		}
+	}
+	return paths
+}
+
+// submitDiffChunks zips and submits every chunk as a separate review, each with the
+// context files for its own paths. It stops at the first failed submission.
+func submitDiffChunks(client *apiClient, repoName string, chunks []diffChunk, bundleCtx *bundleContext, verbose bool) ([]diffReviewCreateResponse, error) {
+	responses := make([]diffReviewCreateResponse, 0, len(chunks))
+	for i, chunk := range chunks {
+		zipData, err := createZipArchive(chunk.Content, bundleCtx.forFiles(chunk.Files))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zip archive for chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		if verbose {
+			log.Printf("Chunk %d/%d: %d file(s), %d bytes of diff, %d bytes zipped", i+1, len(chunks), len(chunk.Files), len(chunk.Content), len(zipData))
+		}
+
+		resp, err := submitReview(client, base64.StdEncoding.EncodeToString(zipData), repoName, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d/%d (%s): %w", i+1, len(chunks), strings.Join(chunk.Files, ", "), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// pollSubmittedReviews waits for one review, or for every chunk of a chunked review.
+func pollSubmittedReviews(client *apiClient, reviewIDs []string, pollInterval, timeout time.Duration, stream, verbose bool) (*diffReviewResponse, error) {
+	if len(reviewIDs) == 1 {
+		return pollReview(client, reviewIDs[0], pollInterval, timeout, stream, verbose)
+	}
+	return pollReviewChunks(client, reviewIDs, pollInterval, timeout, stream, verbose)
+}
+
+// pollReviewChunks polls all chunk reviews concurrently and merges their results.
+func pollReviewChunks(client *apiClient, reviewIDs []string, pollInterval, timeout time.Duration, stream, verbose bool) (*diffReviewResponse, error) {
+	start := time.Now()
+	isTTY := term.IsTerminal(int(os.Stdout.Fd()))
+	fmt.Printf("Waiting for %d chunk reviews (poll every %s, timeout %s)...\n", len(reviewIDs), pollInterval, timeout)
+	os.Stdout.Sync()
+
+	var mu sync.Mutex
+	statuses := make([]string, len(reviewIDs))
+	printStatus := func() {
+		done := 0
+		for _, st := range statuses {
+			if st == "completed" || st == "failed" {
+				done++
+			}
+		}
+		line := fmt.Sprintf("Chunks done: %d/%d | elapsed: %s", done, len(reviewIDs), time.Since(start).Truncate(time.Second))
+		if isTTY {
+			fmt.Printf("\r%-80s", line)
+			os.Stdout.Sync()
+		} else {
+			fmt.Println(line)
+		}
+	}
+
+	results := make([]*diffReviewResponse, len(reviewIDs))
+	errs := make([]error, len(reviewIDs))
+	var wg sync.WaitGroup
+	for i, id := range reviewIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			results[i], errs[i] = watchReview(client, id, pollInterval, timeout, stream, verbose, reviewWatcher{
+				OnStatus: func(status string) {
+					mu.Lock()
+					defer mu.Unlock()
+					if statuses[i] == status {
+						return
+					}
+					statuses[i] = status
+					printStatus()
+				},
+				OnProgress: applyPartialToReviewState,
+			})
+		}(i, id)
+	}
+	wg.Wait()
+	if isTTY {
+		fmt.Println()
+	}
+
+	merged := mergeReviewResults(results)
+	var failures []string
+	var failureErrs []error
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("chunk %d/%d (%s): %v", i+1, len(reviewIDs), reviewIDs[i], err))
+			failureErrs = append(failureErrs, err)
+		}
+	}
+	if len(failures) > 0 {
+		merged.Status = "failed"
+		merged.Message = strings.Join(failures, "; ")
+		return merged, &chunkedReviewError{message: merged.Message, errs: failureErrs}
+	}
+	return merged, nil
+}
+
+// chunkedReviewError reports every failed chunk while keeping the underlying
+// errors reachable, so exit codes can tell timeouts from API failures.
+type chunkedReviewError struct {
+	message string
+	errs    []error
+}
+
+func (e *chunkedReviewError) Error() string   { return "chunked review incomplete: " + e.message }
+func (e *chunkedReviewError) Unwrap() []error { return e.errs }
+
+// mergeReviewResults combines per-chunk responses into a single response.
+// Summaries are kept per chunk under a heading; files are concatenated in chunk order.
+func mergeReviewResults(results []*diffReviewResponse) *diffReviewResponse {
+	merged := &diffReviewResponse{Status: "completed"}
+	var summaries []string
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+		if merged.FriendlyName == "" {
+			merged.FriendlyName = r.FriendlyName
+		}
+		if r.Status != "completed" {
+			merged.Status = r.Status
+		}
+		if s := strings.TrimSpace(r.Summary); s != "" {
+			summaries = append(summaries, fmt.Sprintf("## Chunk %d/%d\n\n%s", i+1, len(results), s))
+		}
+		merged.Files = append(merged.Files, r.Files...)
+	}
+	merged.Summary = strings.Join(summaries, "\n\n")
+	return merged
+}
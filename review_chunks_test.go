@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDiffSection returns a "diff --git" section for path with lines added lines.
+func testDiffSection(path string, lines int) string {
+	var sb strings.Builder
+	sb.WriteString("diff --git a/" + path + " b/" + path + "\n--- a/" + path + "\n+++ b/" + path + "\n@@ -0,0 +1 @@\n")
+	for i := 0; i < lines; i++ {
+		sb.WriteString("+line\n")
+	}
+	return sb.String()
+}
+
+func TestSplitDiffIntoChunks(t *testing.T) {
+	a, b, c := testDiffSection("a.go", 10), testDiffSection("b.go", 10), testDiffSection("c.go", 10)
+	size := len(a) // every section has the same size
+	big := testDiffSection("big.go", 40)
+
+	tests := []struct {
+		name    string
+		diff    string
+		budget  int
+		want    [][]string // file paths per chunk
+		wantErr string
+	}{
+		{"no budget", a + b + c, 0, [][]string{{"a.go", "b.go", "c.go"}}, ""},
+		{"diff exactly at budget", a + b + c, 3 * size, [][]string{{"a.go", "b.go", "c.go"}}, ""},
+		{"one byte over", a + b + c, 3*size - 1, [][]string{{"a.go", "b.go"}, {"c.go"}}, ""},
+		{"two files exactly at budget", a + b + c, 2 * size, [][]string{{"a.go", "b.go"}, {"c.go"}}, ""},
+		{"one file per chunk", a + b + c, 2*size - 1, [][]string{{"a.go"}, {"b.go"}, {"c.go"}}, ""},
+		{"single file over budget", a + big + c, 2 * size, nil, "big.go alone is"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := splitDiffIntoChunks([]byte(tt.diff), tt.budget)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			var joined strings.Builder
+			for _, chunk := range chunks {
+				got = append(got, chunk.Files)
+				joined.Write(chunk.Content)
+				if tt.budget > 0 && len(chunk.Content) > tt.budget {
+					t.Errorf("chunk %v is %d bytes, over the budget of %d", chunk.Files, len(chunk.Content), tt.budget)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %v, want %v", got, tt.want)
+			}
+			if joined.String() != tt.diff {
+				t.Error("chunks do not add up to the diff")
+			}
+		})
+	}
+}
+
+func TestMergeReviewResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		results     []*diffReviewResponse
+		wantStatus  string
+		wantFiles   []string
+		wantSummary string
+	}{
+		{
+			name: "all completed",
+			results: []*diffReviewResponse{
+				{Status: "completed", FriendlyName: "first", Summary: "one", Files: []diffReviewFileResult{{FilePath: "a.go"}}},
+				{Status: "completed", FriendlyName: "second", Files: []diffReviewFileResult{{FilePath: "b.go"}, {FilePath: "c.go"}}},
+			},
+			wantStatus:  "completed",
+			wantFiles:   []string{"a.go", "b.go", "c.go"},
+			wantSummary: "## Chunk 1/2\n\none",
+		},
+		{
+			name: "a chunk still running, one missing",
+			results: []*diffReviewResponse{
+				{Status: "completed", Summary: "one", Files: []diffReviewFileResult{{FilePath: "a.go"}}},
+				nil,
+				{Status: "processing", Summary: "three", Files: []diffReviewFileResult{{FilePath: "c.go"}}},
+			},
+			wantStatus:  "processing",
+			wantFiles:   []string{"a.go", "c.go"},
+			wantSummary: "## Chunk 1/3\n\none\n\n## Chunk 3/3\n\nthree",
+		},
+		{
+			name: "a failed chunk",
+			results: []*diffReviewResponse{
+				{Status: "failed"},
+				{Status: "completed", Files: []diffReviewFileResult{{FilePath: "b.go"}}},
+			},
+			wantStatus: "failed",
+			wantFiles:  []string{"b.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeReviewResults(tt.results)
+			var files []string
+			for _, f := range merged.Files {
+				files = append(files, f.FilePath)
+			}
+			if merged.Status != tt.wantStatus || !reflect.DeepEqual(files, tt.wantFiles) || merged.Summary != tt.wantSummary {
+				t.Errorf("merged = %q, %v, %q; want %q, %v, %q", merged.Status, files, merged.Summary, tt.wantStatus, tt.wantFiles, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestPollReviewChunks(t *testing.T) {
+	results := map[string]diffReviewResponse{
+		"c1": {Status: "completed", Summary: "first", Files: []diffReviewFileResult{{FilePath: "a.go", Comments: []diffReviewComment{{Line: 1, Content: "x"}}}}},
+		"c2": {Status: "completed", Files: []diffReviewFileResult{{FilePath: "b.go"}}},
+		"c3": {Status: "failed", Message: "boom"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, ok := results[strings.TrimPrefix(r.URL.Path, "/api/v1/diff-review/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer server.Close()
+	client := newAPIClient(server.URL, "key", false)
+
+	merged, err := pollReviewChunks(client, []string{"c1", "c2"}, 10*time.Millisecond, 5*time.Second, false, false)
+	if err != nil || merged.Status != "completed" || len(merged.Files) != 2 || merged.Summary != "## Chunk 1/2\n\nfirst" {
+		t.Errorf("completed chunks = %+v, %v", merged, err)
+	}
+
+	// A failed chunk fails the review, names the chunk and keeps the other chunks' comments
+	merged, err = pollReviewChunks(client, []string{"c1", "c3"}, 10*time.Millisecond, 5*time.Second, false, false)
+	var chunkErr *chunkedReviewError
+	if !errors.As(err, &chunkErr) || !errors.Is(err, errReviewFailed) {
+		t.Fatalf("failed chunk: err = %v", err)
+	}
+	if merged.Status != "failed" || !strings.Contains(merged.Message, "chunk 2/2 (c3)") || len(merged.Files) != 1 {
+		t.Errorf("failed chunk: merged = %+v", merged)
+	}
+}
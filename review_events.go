@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errEventsUnsupported means the server has no events endpoint; callers fall back to polling.
+var errEventsUnsupported = errors.New("review events endpoint not available")
+
+// errSSEUnsupported means the events endpoint exists but does not stream; callers long-poll it.
+var errSSEUnsupported = errors.New("review events endpoint does not support streaming")
+
+// eventsPageLimit matches what the web UI requests from the same endpoint.
+const eventsPageLimit = 1000
+
+// reviewEvent is one entry from /api/v1/diff-review/{id}/events, as consumed by static/app.js.
+type reviewEvent struct {
+	ID      eventID         `json:"id"`
+	Type    string          `json:"type"` // log, batch, status, artifact, completion
+	Time    string          `json:"time"`
+	Level   string          `json:"level"`
+	BatchID string          `json:"batchId"`
+	Data    reviewEventData `json:"data"`
+}
+
+type reviewEventData struct {
+	Status        string `json:"status"`
+	Message       string `json:"message"`
+	FileCount     int    `json:"fileCount"`
+	CommentCount  int    `json:"commentCount"`
+	ResultSummary string `json:"resultSummary"`
+}
+
+type reviewEventsPage struct {
+	Events []reviewEvent `json:"events"`
+}
+
+// eventID accepts both numeric and string ids from the server.
+type eventID string
+
+func (id *eventID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = eventID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid event id %s", data)
+	}
+	*id = eventID(n.String())
+	return nil
+}
+
+// terminal reports whether the event signals the end of the review.
+func (ev reviewEvent) terminal() bool {
+	return ev.Type == "completion" ||
+		(ev.Type == "status" && (ev.Data.Status == "completed" || ev.Data.Status == "failed"))
+}
+
+// batchDone reports whether a batch of files finished, so partial comments may be available.
+func (ev reviewEvent) batchDone() bool {
+	return ev.Type == "batch" && ev.Data.Status == "completed"
+}
+
+// message renders the event the same way the web UI event log does.
+func (ev reviewEvent) message() string {
+	d := ev.Data
+	switch ev.Type {
+	case "log":
+		return d.Message
+	case "batch":
+		batch := ev.BatchID
+		if batch == "" {
+			batch = "unknown"
+		}
+		switch d.Status {
+		case "processing":
+			return fmt.Sprintf("Batch %s started: processing %d file(s)", batch, d.FileCount)
+		case "completed":
+			return fmt.Sprintf("Batch %s completed: generated %d comment(s)", batch, d.CommentCount)
+		default:
+			return fmt.Sprintf("Batch %s: %s", batch, d.Status)
+		}
+	case "status":
+		return "Status: " + d.Status
+	case "completion":
+		if d.ResultSummary != "" {
+			return firstLine(d.ResultSummary)
+		}
+		return fmt.Sprintf("Process completed with %d comment(s)", d.CommentCount)
+	default:
+		return ev.Type
+	}
+}
+
+// reviewWatcher receives progress while a review runs. Any callback may be nil.
+type reviewWatcher struct {
+	OnStatus   func(status string)
+	OnEvent    func(ev reviewEvent)
+	OnProgress func(partial *diffReviewResponse)
+}
+
+// watchReview waits for a review to finish, following its event stream when the server
+// offers one. It tries Server-Sent Events first, then long-polls the events endpoint
+// with a cursor, and falls back to plain status polling when there is no events endpoint.
+func watchReview(client *apiClient, reviewID string, pollInterval, timeout time.Duration, stream, verbose bool, w reviewWatcher) (*diffReviewResponse, error) {
+	if !stream {
+		return pollReviewStatus(client, reviewID, pollInterval, timeout, verbose, w.OnStatus)
+	}
+
+	deadline := time.Now().Add(timeout)
+	ew := &eventWatch{
+		client: client, reviewID: reviewID,
+		pollInterval: pollInterval, deadline: deadline, verbose: verbose,
+		w: w, seen: make(map[eventID]bool),
+	}
+
+	result, done, err := ew.streamSSE()
+	switch {
+	case done:
+		return result, err
+	case errors.Is(err, errEventsUnsupported):
+		if verbose {
+			log.Printf("Review events not available; polling status instead")
+		}
+		return pollReviewStatus(client, reviewID, pollInterval, time.Until(deadline), verbose, w.OnStatus)
+	case err != nil && !errors.Is(err, errSSEUnsupported):
+		return nil, err
+	}
+
+	if verbose {
+		log.Printf("Long-polling review events for %s", reviewID)
+	}
+	return ew.longPoll()
+}
+
+// eventWatch holds the cursor and de-duplication state shared by the SSE and long-poll paths.
+type eventWatch struct {
+	client       *apiClient
+	reviewID     string
+	pollInterval time.Duration
+	deadline     time.Time
+	verbose      bool
+	w            reviewWatcher
+	seen         map[eventID]bool
+	cursor       eventID
+}
+
+func (ew *eventWatch) eventsPath() string {
+	return "/api/v1/diff-review/" + ew.reviewID + "/events"
+}
+
+// handle dispatches a new event and reports whether the results should be refreshed
+// and whether the review has finished. Events already seen are ignored.
+func (ew *eventWatch) handle(ev reviewEvent) (refresh, terminal bool) {
+	if ev.ID != "" {
+		if ew.seen[ev.ID] {
+			return false, false
+		}
+		ew.seen[ev.ID] = true
+		ew.cursor = ev.ID
+	}
+	if ew.w.OnEvent != nil {
+		ew.w.OnEvent(ev)
+	}
+	return ev.batchDone(), ev.terminal()
+}
+
+// refresh fetches the current results, reporting partial comments to OnProgress.
+// It returns done=true once the review has completed or failed.
+func (ew *eventWatch) refresh() (*diffReviewResponse, bool, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), ew.deadline)
+	defer cancel()
+
+	result, err := fetchReviewStatus(ctx, ew.client, ew.reviewID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, true, errReviewTimeout
+		}
+		return nil, true, err
+	}
+	if ew.w.OnStatus != nil {
+		ew.w.OnStatus(result.Status)
+	}
+	if done, err := reviewFinished(result); done {
+		return result, true, err
+	}
+	if ew.w.OnProgress != nil {
+		ew.w.OnProgress(result)
+	}
+	return result, false, nil
+}
+
+// finish waits for the final result after a terminal event; the status endpoint can
+// lag slightly behind the event stream.
+func (ew *eventWatch) finish() (*diffReviewResponse, bool, error) {
+	result, err := pollReviewStatus(ew.client, ew.reviewID, ew.pollInterval, time.Until(ew.deadline), ew.verbose, ew.w.OnStatus)
+	return result, true, err
+}
+
+// streamSSE follows the events endpoint as text/event-stream. It returns done=false with
+// errSSEUnsupported or a nil error when the caller should continue by long-polling.
+func (ew *eventWatch) streamSSE() (*diffReviewResponse, bool, error) {
+	ctx, cancel := context.WithDeadline(context.Background(), ew.deadline)
+	defer cancel()
+
+	resp, err := ew.client.stream(ctx, ew.eventsPath(), http.Header{
+		"Accept":        {"text/event-stream"},
+		"Cache-Control": {"no-cache"},
+	})
+	if err != nil {
+		if eventsUnsupported(err) {
+			return nil, false, errEventsUnsupported
+		}
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return nil, false, errSSEUnsupported
+	}
+
+	if ew.verbose {
+		log.Printf("Streaming review events for %s", ew.reviewID)
+	}
+
+	events := make(chan reviewEvent)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- readSSE(resp.Body, func(ev reviewEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	// Check status now and then in case the server never sends a completion event
+	statusCheck := ew.pollInterval * 5
+	if statusCheck < time.Second {
+		statusCheck = time.Second
+	}
+	ticker := time.NewTicker(statusCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-events:
+			refresh, terminal := ew.handle(ev)
+			if terminal {
+				return ew.finish()
+			}
+			if refresh {
+				if result, done, err := ew.refresh(); done {
+					return result, true, err
+				}
+			}
+		case <-ticker.C:
+			if result, done, err := ew.refresh(); done {
+				return result, true, err
+			}
+		case err := <-streamErr:
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, true, errReviewTimeout
+			}
+			// The stream closed before the review finished; resume by long-polling from the cursor
+			if ew.verbose && err != nil {
+				log.Printf("Review event stream ended: %v", err)
+			}
+			return nil, false, nil
+		}
+	}
+}
+
+// readSSE parses a text/event-stream body, passing each event's JSON data to emit.
+// It stops early when emit returns false.
+func readSSE(body io.Reader, emit func(reviewEvent) bool) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var id, eventType string
+	var data bytes.Buffer
+	dispatch := func() bool {
+		defer func() { id, eventType = "", ""; data.Reset() }()
+		if data.Len() == 0 {
+			return true
+		}
+		var ev reviewEvent
+		if err := json.Unmarshal(data.Bytes(), &ev); err != nil {
+			return true // ignore keep-alives and payloads we don't understand
+		}
+		if ev.ID == "" {
+			ev.ID = eventID(id)
+		}
+		if ev.Type == "" {
+			ev.Type = eventType
+		}
+		return emit(ev)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if !dispatch() {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment / keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			eventType = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	dispatch()
+	return io.EOF
+}
+
+// longPoll fetches new events after the cursor until the review finishes. Servers that
+// ignore the cursor still work because already-seen events are skipped.
+func (ew *eventWatch) longPoll() (*diffReviewResponse, error) {
+	for time.Now().Before(ew.deadline) {
+		page, err := ew.fetchEvents()
+		if errors.Is(err, errEventsUnsupported) {
+			return pollReviewStatus(ew.client, ew.reviewID, ew.pollInterval, time.Until(ew.deadline), ew.verbose, ew.w.OnStatus)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fresh, needRefresh := 0, false
+		for _, ev := range page.Events {
+			if ev.ID != "" && ew.seen[ev.ID] {
+				continue
+			}
+			fresh++
+			refresh, terminal := ew.handle(ev)
+			if terminal {
+				result, _, err := ew.finish()
+				return result, err
+			}
+			needRefresh = needRefresh || refresh
+		}
+
+		// Without new events the server may simply not emit them; check status like plain polling
+		if needRefresh || fresh == 0 {
+			if result, done, err := ew.refresh(); done {
+				return result, err
+			}
+		}
+
+		time.Sleep(ew.pollInterval)
+	}
+	return nil, errReviewTimeout
+}
+
+// fetchEvents requests the events after the current cursor as JSON.
+func (ew *eventWatch) fetchEvents() (*reviewEventsPage, error) {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprint(eventsPageLimit))
+	if ew.cursor != "" {
+		query.Set("after", string(ew.cursor))
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), ew.deadline)
+	defer cancel()
+
+	var page reviewEventsPage
+	if err := ew.client.getJSON(ctx, ew.eventsPath()+"?"+query.Encode(), &page); err != nil {
+		if eventsUnsupported(err) {
+			return nil, errEventsUnsupported
+		}
+		if ctx.Err() != nil {
+			return nil, errReviewTimeout
+		}
+		return nil, err
+	}
+	return &page, nil
+}
+
+// eventsUnsupported reports whether an events request failed because the server
+// has no such endpoint.
+func eventsUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// applyPartialToReviewState merges partial results into the web UI state, if one is active.
+func applyPartialToReviewState(partial *diffReviewResponse) {
+	reviewStateMu.Lock()
+	defer reviewStateMu.Unlock()
+	if currentReviewState != nil {
+		currentReviewState.ApplyPartialResult(partial)
+	}
+}
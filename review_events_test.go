@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeReviewAPI is an httptest stand-in for the LiveReview review endpoints.
+type fakeReviewAPI struct {
+	mu        sync.Mutex
+	events    []string // JSON-encoded events, in order
+	completed bool
+	polls     int32
+	afters    []string
+	sse       bool
+	noEvents  bool
+}
+
+func (f *fakeReviewAPI) partialResult() diffReviewResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := diffReviewResponse{Status: "processing", Files: []diffReviewFileResult{
+		{FilePath: "a.go", Comments: []diffReviewComment{{Line: 3, Content: "early finding", Severity: "warning"}}},
+	}}
+	if f.completed {
+		r.Status = "completed"
+		r.Summary = "done"
+		r.Files = append(r.Files, diffReviewFileResult{FilePath: "b.go", Comments: []diffReviewComment{{Line: 1, Content: "late finding"}}})
+	}
+	return r
+}
+
+func (f *fakeReviewAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != "key" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/api/v1/diff-review/r1":
+		atomic.AddInt32(&f.polls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(f.partialResult())
+
+	case r.URL.Path == "/api/v1/diff-review/r1/events":
+		if f.noEvents {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Accept") == "text/event-stream" {
+			if f.sse {
+				f.serveSSE(w)
+			} else {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"events":[]}`)
+			}
+			return
+		}
+		f.mu.Lock()
+		f.afters = append(f.afters, r.URL.Query().Get("after"))
+		// Reveal one more event per request and ignore the cursor, like a naive server
+		n := len(f.afters)
+		if n > len(f.events) {
+			n = len(f.events)
+		}
+		if n == len(f.events) {
+			f.completed = true
+		}
+		events := "[" + strings.Join(f.events[:n], ",") + "]"
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"events":%s}`, events)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeReviewAPI) serveSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher := w.(http.Flusher)
+	fmt.Fprint(w, ": keep-alive\n\n")
+	flusher.Flush()
+	for i, ev := range f.events {
+		if i == len(f.events)-1 {
+			f.mu.Lock()
+			f.completed = true
+			f.mu.Unlock()
+		}
+		fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", i+1, ev)
+		flusher.Flush()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+var testReviewEvents = []string{
+	`{"id":1,"type":"batch","batchId":"b1","data":{"status":"processing","fileCount":2}}`,
+	`{"id":2,"type":"batch","batchId":"b1","data":{"status":"completed","commentCount":1}}`,
+	`{"id":3,"type":"completion","data":{"commentCount":2}}`,
+}
+
+func watchFake(t *testing.T, api *fakeReviewAPI) (*diffReviewResponse, []reviewEvent, int) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	var events []reviewEvent
+	progress := 0
+	result, err := watchReview(newAPIClient(server.URL, "key", false), "r1", 10*time.Millisecond, 5*time.Second, true, false, reviewWatcher{
+		OnEvent:    func(ev reviewEvent) { events = append(events, ev) },
+		OnProgress: func(partial *diffReviewResponse) { progress++ },
+	})
+	if err != nil {
+		t.Fatalf("watchReview: %v", err)
+	}
+	if result.Status != "completed" || len(result.Files) != 2 {
+		t.Fatalf("unexpected final result: %+v", result)
+	}
+	return result, events, progress
+}
+
+func TestWatchReviewSSE(t *testing.T) {
+	api := &fakeReviewAPI{sse: true, events: testReviewEvents}
+	_, events, progress := watchFake(t, api)
+
+	if len(events) != 3 || events[0].Type != "batch" || events[2].Type != "completion" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if events[0].ID != "1" || events[1].message() != "Batch b1 completed: generated 1 comment(s)" {
+		t.Errorf("event not decoded as expected: %+v / %q", events[0], events[1].message())
+	}
+	if progress == 0 {
+		t.Errorf("expected partial results after the batch completed")
+	}
+}
+
+func TestWatchReviewLongPoll(t *testing.T) {
+	api := &fakeReviewAPI{events: testReviewEvents}
+	_, events, _ := watchFake(t, api)
+
+	if len(events) != 3 {
+		t.Fatalf("expected each event exactly once, got %d: %+v", len(events), events)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.afters) < 2 || api.afters[0] != "" || api.afters[1] != "1" {
+		t.Errorf("expected cursor to advance with each request, got %q", api.afters)
+	}
+}
+
+func TestWatchReviewFallsBackToPolling(t *testing.T) {
+	api := &fakeReviewAPI{noEvents: true}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		api.mu.Lock()
+		api.completed = true
+		api.mu.Unlock()
+	}()
+
+	result, err := watchReview(newAPIClient(server.URL, "key", false), "r1", 10*time.Millisecond, 5*time.Second, true, false, reviewWatcher{})
+	if err != nil {
+		t.Fatalf("watchReview: %v", err)
+	}
+	if result.Status != "completed" || atomic.LoadInt32(&api.polls) < 2 {
+		t.Errorf("expected polling to reach completion, got status %q after %d polls", result.Status, api.polls)
+	}
+}
+
+func TestApplyPartialResultKeepsStatus(t *testing.T) {
+	rs := NewReviewState("r1", []diffReviewFileResult{{FilePath: "a.go"}, {FilePath: "b.go"}}, false, false, "", "")
+	rs.ApplyPartialResult(&diffReviewResponse{Status: "processing", Files: []diffReviewFileResult{
+		{FilePath: "a.go", Comments: []diffReviewComment{{Line: 1, Content: "x"}}},
+	}})
+
+	if rs.Status != "in_progress" || rs.TotalComments != 1 || len(rs.Files[0].Comments) != 1 {
+		t.Errorf("unexpected state after partial update: status=%q comments=%d", rs.Status, rs.TotalComments)
+	}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HexmosTech/git-lrc/internal/naming"
+	"github.com/urfave/cli/v2"
+)
+
+// defaultHistoryLimit is how many reviews `lrc history` lists unless --limit says otherwise.
+const defaultHistoryLimit = 20
+
+// storedReview is a completed review kept in the review_results table.
+type storedReview struct {
+	ID           int64     `json:"-"`
+	ReviewID     string    `json:"review_id"` // comma-separated for chunked reviews
+	FriendlyName string    `json:"friendly_name"`
+	TreeHash     string    `json:"tree_hash,omitempty"`
+	Branch       string    `json:"branch"`
+	RepoName     string    `json:"repo_name,omitempty"`
+	DiffSource   string    `json:"diff_source,omitempty"`
+	Status       string    `json:"status"`
+	Summary      string    `json:"-"`
+	CommentCount int       `json:"comment_count"`
+	Timestamp    time.Time `json:"timestamp"`
+
+	Severities map[string]int      `json:"severities"`
+	Result     *diffReviewResponse `json:"-"`
+	Diff       []byte              `json:"-"`
+}
+
+// reviewResultMeta describes where a completed review came from.
+type reviewResultMeta struct {
+	ReviewIDs    []string
+	FriendlyName string
+	RepoName     string
+	DiffSource   string
+	TreeHash     string
+	Diff         []byte
+}
+
+// storeReviewResult keeps a completed review in the local history. Like coverage
+// tracking, it is best-effort: failures only produce warnings.
+func storeReviewResult(result *diffReviewResponse, meta reviewResultMeta, verbose bool) {
+	if result == nil || result.Status != "completed" {
+		return
+	}
+	db, err := openReviewDB()
+	if err != nil {
+		if verbose {
+			log.Printf("Warning: could not open review DB: %v (review not saved to history)", err)
+		}
+		return
+	}
+	defer db.Close()
+
+	rec := storedReview{
+		ReviewID:     strings.Join(meta.ReviewIDs, ","),
+		FriendlyName: meta.FriendlyName,
+		TreeHash:     meta.TreeHash,
+		Branch:       currentBranch(),
+		RepoName:     meta.RepoName,
+		DiffSource:   meta.DiffSource,
+		Status:       result.Status,
+		Summary:      result.Summary,
+		Timestamp:    time.Now().UTC(),
+		Result:       result,
+		Diff:         meta.Diff,
+	}
+	if rec.FriendlyName == "" {
+		rec.FriendlyName = naming.GenerateFriendlyName()
+	}
+	if err := insertReviewResult(db, rec); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	if verbose {
+		log.Printf("Saved review %s (%s) to history", rec.ReviewID, rec.FriendlyName)
+	}
+}
+
+// reviewedTreeHash returns the tree the review looked at, when there is one: the
+// staged tree, or the tree of the (last) reviewed commit.
+func reviewedTreeHash(opts reviewOptions) string {
+	var rev string
+	switch opts.diffSource {
+	case "staged":
+		tree, err := currentTreeHash()
+		if err != nil {
+			return ""
+		}
+		return tree
+	case "commit":
+		rev = rangeNewSide(opts.commitVal)
+	case "range":
+		rev = rangeNewSide(opts.rangeVal)
+	default:
+		return ""
+	}
+	out, err := runGitCommand("git", "rev-parse", rev+"^{tree}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// insertReviewResult stores a completed review. Resuming a review that is already
+// stored replaces the earlier row.
+func insertReviewResult(db *sql.DB, rec storedReview) error {
+	resultJSON, err := json.Marshal(rec.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review result: %w", err)
+	}
+	maxSeverity := -1
+	for _, file := range rec.Result.Files {
+		for _, comment := range file.Comments {
+			if rank := severityRank(comment.Severity); rank > maxSeverity {
+				maxSeverity = rank
+			}
+		}
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO review_results (review_id, friendly_name, tree_hash, branch, repo_name, diff_source,
+		     status, summary, comment_count, max_severity, result_json, diff, timestamp)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(review_id) DO UPDATE SET
+		     friendly_name = excluded.friendly_name, tree_hash = excluded.tree_hash,
+		     branch = excluded.branch, repo_name = excluded.repo_name, diff_source = excluded.diff_source,
+		     status = excluded.status, summary = excluded.summary, comment_count = excluded.comment_count,
+		     max_severity = excluded.max_severity, result_json = excluded.result_json,
+		     diff = COALESCE(excluded.diff, review_results.diff), timestamp = excluded.timestamp`,
+		rec.ReviewID, rec.FriendlyName, rec.TreeHash, rec.Branch, rec.RepoName, rec.DiffSource,
+		rec.Status, rec.Summary, countTotalComments(rec.Result.Files), maxSeverity, string(resultJSON),
+		nullableText(rec.Diff), rec.Timestamp.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save review result: %w", err)
+	}
+	return nil
+}
+
+func nullableText(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// historyFilter selects reviews for `lrc history`. Zero values match everything.
+type historyFilter struct {
+	Branch      string
+	Since       time.Time
+	Until       time.Time
+	MinSeverity string
+	Limit       int
+}
+
+const storedReviewColumns = `id, review_id, COALESCE(friendly_name, ''), COALESCE(tree_hash, ''), branch,
+	COALESCE(repo_name, ''), COALESCE(diff_source, ''), status, COALESCE(summary, ''), comment_count,
+	result_json, COALESCE(diff, ''), timestamp`
+
+func scanStoredReview(scan func(dest ...interface{}) error) (*storedReview, error) {
+	var rec storedReview
+	var resultJSON, diff, ts string
+	if err := scan(&rec.ID, &rec.ReviewID, &rec.FriendlyName, &rec.TreeHash, &rec.Branch,
+		&rec.RepoName, &rec.DiffSource, &rec.Status, &rec.Summary, &rec.CommentCount,
+		&resultJSON, &diff, &ts); err != nil {
+		return nil, err
+	}
+	rec.Timestamp, _ = time.Parse(time.RFC3339, ts)
+	rec.Result = &diffReviewResponse{}
+	if err := json.Unmarshal([]byte(resultJSON), rec.Result); err != nil {
+		return nil, fmt.Errorf("failed to parse stored review %s: %w", rec.ReviewID, err)
+	}
+	if diff != "" {
+		rec.Diff = []byte(diff)
+	}
+	rec.Severities = countSeverities(rec.Result.Files)
+	return &rec, nil
+}
+
+// queryReviewHistory returns stored reviews matching the filter, newest first.
+func queryReviewHistory(db *sql.DB, f historyFilter) ([]storedReview, error) {
+	query := `SELECT ` + storedReviewColumns + ` FROM review_results WHERE 1=1`
+	var args []interface{}
+	if f.Branch != "" {
+		query += ` AND branch = ?`
+		args = append(args, f.Branch)
+	}
+	if !f.Since.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		query += ` AND timestamp < ?`
+		args = append(args, f.Until.UTC().Format(time.RFC3339))
+	}
+	if f.MinSeverity != "" {
+		query += ` AND max_severity >= ?`
+		args = append(args, severityRank(f.MinSeverity))
+	}
+	query += ` ORDER BY timestamp DESC, id DESC`
+	if f.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, f.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review history: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []storedReview
+	for rows.Next() {
+		rec, err := scanStoredReview(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, *rec)
+	}
+	return recs, rows.Err()
+}
+
+// findStoredReview looks a review up by ID (any chunk ID of a chunked review) or by
+// friendly name, case-insensitively. The newest match wins.
+func findStoredReview(db *sql.DB, ref string) (*storedReview, error) {
+	row := db.QueryRow(`SELECT `+storedReviewColumns+` FROM review_results
+		WHERE instr(',' || review_id || ',', ',' || ? || ',') > 0 OR lower(friendly_name) = lower(?)
+		ORDER BY timestamp DESC, id DESC LIMIT 1`, ref, ref)
+	rec, err := scanStoredReview(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rec, err
+}
+
+// withDiffHunks returns a copy of the result whose files carry the hunks of the
+// stored diff, so renderers that show code context have something to show.
+func withDiffHunks(result *diffReviewResponse, diff []byte) *diffReviewResponse {
+	if len(diff) == 0 {
+		return result
+	}
+	parsed, err := parseDiffToFiles(diff)
+	if err != nil {
+		return result
+	}
+	hunks := make(map[string][]diffReviewHunk, len(parsed))
+	for _, f := range parsed {
+		hunks[f.FilePath] = f.Hunks
+	}
+
+	out := *result
+	out.Files = make([]diffReviewFileResult, len(result.Files))
+	for i, f := range result.Files {
+		if len(f.Hunks) == 0 {
+			f.Hunks = hunks[f.FilePath]
+		}
+		out.Files[i] = f
+	}
+	return &out
+}
+
+// parseHistoryTime accepts a date (2006-01-02), an RFC 3339 time, or an age such as
+// 36h or 7d, meaning that long before now.
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DD, RFC 3339, or an age like 7d or 12h)", value)
+}
+
+// runHistory implements `lrc history`.
+func runHistory(c *cli.Context) error {
+	now := time.Now()
+	filter := historyFilter{Branch: c.String("branch"), Limit: c.Int("limit")}
+	if c.Bool("current-branch") {
+		filter.Branch = currentBranch()
+	}
+	if v := c.String("since"); v != "" {
+		t, err := parseHistoryTime(v, now)
+		if err != nil {
+			return reviewExitError(&invalidInputError{err: err})
+		}
+		filter.Since = t
+	}
+	if v := c.String("until"); v != "" {
+		t, err := parseHistoryTime(v, now)
+		if err != nil {
+			return reviewExitError(&invalidInputError{err: err})
+		}
+		filter.Until = t
+	}
+	if v := c.String("severity"); v != "" {
+		sev := normalizeSeverity(v)
+		if _, ok := severityRanks[sev]; !ok {
+			return reviewExitError(invalidInput("invalid --severity %q (must be one of: critical, error, warning, info)", v))
+		}
+		filter.MinSeverity = sev
+	}
+
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	recs, err := queryReviewHistory(db, filter)
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if recs == nil {
+			recs = []storedReview{}
+		}
+		return encoder.Encode(recs)
+	}
+
+	if len(recs) == 0 {
+		fmt.Println("No reviews found.")
+		return nil
+	}
+	fmt.Printf("%-16s  %-16s  %-20s  %-8s  %-13s  %s\n", "DATE", "REVIEW ID", "BRANCH", "COMMENTS", "C/E/W/I", "TITLE")
+	for _, rec := range recs {
+		s := rec.Severities
+		fmt.Printf("%-16s  %-16s  %-20s  %8d  %-13s  %s\n",
+			rec.Timestamp.Local().Format("2006-01-02 15:04"),
+			truncateField(rec.ReviewID, 16),
+			truncateField(rec.Branch, 20),
+			rec.CommentCount,
+			fmt.Sprintf("%d/%d/%d/%d", s["critical"], s["error"], s["warning"], s["info"]),
+			rec.FriendlyName)
+	}
+	return nil
+}
+
+func truncateField(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:width-1] + "…"
+}
+
+// runShow implements `lrc show <id|friendly-name>`.
+func runShow(c *cli.Context) error {
+	ref := strings.TrimSpace(c.Args().First())
+	if ref == "" || c.NArg() > 1 {
+		if c.NArg() > 1 && strings.HasPrefix(c.Args().Get(1), "-") {
+			return reviewExitError(invalidInput("flags must come before the review: lrc show [flags] <review-id|friendly-name>"))
+		}
+		return reviewExitError(invalidInput("usage: lrc show [flags] <review-id|friendly-name>"))
+	}
+	format := c.String("output")
+	switch format {
+	case "pretty", "json", "sarif", "junit", "codeclimate":
+	default:
+		return reviewExitError(invalidInput("invalid output format: %s (must be pretty, json, sarif, junit or codeclimate)", format))
+	}
+
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rec, err := findStoredReview(db, ref)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		return cli.Exit(fmt.Sprintf("No stored review matches %q; see 'lrc history'", ref), 1)
+	}
+	result := withDiffHunks(rec.Result, rec.Diff)
+
+	if path := c.String("save-html"); path != "" {
+		if err := saveStoredReviewHTML(rec, result, path); err != nil {
+			return err
+		}
+	}
+	if c.Bool("serve") {
+		return serveStoredReview(rec, result, c.Int("port"))
+	}
+	if c.String("save-html") != "" {
+		return nil
+	}
+
+	if format == defaultOutputFormat {
+		fmt.Printf("Review %s (%s)\n", rec.ReviewID, rec.FriendlyName)
+		fmt.Printf("Reviewed %s on branch %s", rec.Timestamp.Local().Format("2006-01-02 15:04"), rec.Branch)
+		if rec.TreeHash != "" {
+			fmt.Printf(", tree %s", shortHash(rec.TreeHash))
+		}
+		fmt.Println()
+	}
+	return renderResult(result, format)
+}
+
+// saveStoredReviewHTML writes a stored review as HTML, like `lrc review --save-html`.
+func saveStoredReviewHTML(rec *storedReview, result *diffReviewResponse, path string) error {
+	data := prepareHTMLData(result, false, true, "", rec.ReviewID, "", "")
+	data.FriendlyName = rec.FriendlyName
+	data.GeneratedTime = rec.Timestamp.Local().Format("2006-01-02 15:04:05 MST")
+	htmlContent, err := renderHTMLTemplate(data)
+	if err != nil {
+		return fmt.Errorf("failed to render HTML template: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(htmlContent), 0644); err != nil {
+		return err
+	}
+	abs, _ := filepath.Abs(path)
+	fmt.Printf("HTML review saved to: %s\n", abs)
+	return nil
+}
+
+// serveStoredReview serves a stored review in the web UI until interrupted. The UI
+// is read-only: there is no commit to decide on.
+func serveStoredReview(rec *storedReview, result *diffReviewResponse, port int) error {
+	state := NewReviewState(rec.ReviewID, result.Files, false, true, "", "")
+	state.FriendlyName = rec.FriendlyName
+	state.GeneratedTime = rec.Timestamp.Local().Format("2006-01-02 15:04:05 MST")
+	state.UpdateFromResult(result)
+	return serveReadOnlyState(state, "review "+rec.FriendlyName, port)
+}
+
+// serveReadOnlyState serves state in the web UI until interrupted; what names the
+// content in the startup message.
+func serveReadOnlyState(state *ReviewState, what string, port int) error {
+	ln, selectedPort, err := pickServePort(port, 10)
+	if err != nil {
+		return fmt.Errorf("failed to find available port: %w", err)
+	}
+	if selectedPort != port {
+		fmt.Printf("Port %d is busy; serving on %d instead.\n", port, selectedPort)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/static/", http.StripPrefix("/static/", getStaticHandler()))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := serveStaticFile(w, r, "index.html"); err != nil {
+			http.Error(w, "Failed to load page", http.StatusInternalServerError)
+		}
+	})
+	mux.Handle("/api/review", state)
+	registerStatsRoutes(mux, statsFilter{})
+
+	serveURL := fmt.Sprintf("http://localhost:%d", selectedPort)
+	fmt.Printf("Serving %s at: %s\n", what, highlightURL(serveURL))
+	fmt.Println("Press Ctrl-C to stop")
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		openURL(serveURL)
+	}()
+
+	server := &http.Server{Handler: mux}
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("server error: %w", err)
+	}
+	return nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+const historyTestDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,3 @@
+ package a
++var x = 1
+ func f() {}
+`
+
+func storeTestReview(t *testing.T, id, name, branch string, ts time.Time, severities ...string) {
+	t.Helper()
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatalf("openReviewDB: %v", err)
+	}
+	defer db.Close()
+
+	file := diffReviewFileResult{FilePath: "a.go"}
+	for i, sev := range severities {
+		file.Comments = append(file.Comments, diffReviewComment{Line: i + 1, Content: "finding", Severity: sev})
+	}
+	rec := storedReview{
+		ReviewID: id, FriendlyName: name, Branch: branch, Status: "completed", Timestamp: ts,
+		Result: &diffReviewResponse{Status: "completed", Summary: "summary of " + id, Files: []diffReviewFileResult{file}},
+		Diff:   []byte(historyTestDiff),
+	}
+	if err := insertReviewResult(db, rec); err != nil {
+		t.Fatalf("insertReviewResult: %v", err)
+	}
+}
+
+func TestReviewHistoryFilters(t *testing.T) {
+	initTestRepo(t)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	storeTestReview(t, "r1", "Brave Otter", "main", base, "info")
+	storeTestReview(t, "r2,r3", "Calm Heron", "feature", base.Add(24*time.Hour), "warning", "critical")
+	storeTestReview(t, "r4", "Quiet Lynx", "main", base.Add(48*time.Hour))
+
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatalf("openReviewDB: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		want   []string
+	}{
+		{"all, newest first", historyFilter{}, []string{"r4", "r2,r3", "r1"}},
+		{"branch", historyFilter{Branch: "main"}, []string{"r4", "r1"}},
+		{"since", historyFilter{Since: base.Add(time.Hour)}, []string{"r4", "r2,r3"}},
+		{"until", historyFilter{Until: base.Add(time.Hour)}, []string{"r1"}},
+		{"severity", historyFilter{MinSeverity: "error"}, []string{"r2,r3"}},
+		{"any comment", historyFilter{MinSeverity: "info"}, []string{"r2,r3", "r1"}},
+		{"limit", historyFilter{Limit: 1}, []string{"r4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := queryReviewHistory(db, tt.filter)
+			if err != nil {
+				t.Fatalf("queryReviewHistory: %v", err)
+			}
+			var got []string
+			for _, rec := range recs {
+				got = append(got, rec.ReviewID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindStoredReview(t *testing.T) {
+	initTestRepo(t)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	storeTestReview(t, "r2,r3", "Calm Heron", "feature", base, "warning")
+	// Storing the same review again (e.g. after --resume) replaces it
+	storeTestReview(t, "r2,r3", "Calm Heron", "feature", base.Add(time.Hour), "warning", "error")
+
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatalf("openReviewDB: %v", err)
+	}
+	defer db.Close()
+
+	for _, ref := range []string{"r2,r3", "r3", "calm heron"} {
+		rec, err := findStoredReview(db, ref)
+		if err != nil || rec == nil {
+			t.Fatalf("findStoredReview(%q) = %v, %v", ref, rec, err)
+		}
+		if rec.ReviewID != "r2,r3" || rec.CommentCount != 2 || rec.Severities["error"] != 1 {
+			t.Errorf("findStoredReview(%q) = %+v", ref, rec)
+		}
+	}
+	if rec, err := findStoredReview(db, "r5"); err != nil || rec != nil {
+		t.Errorf("expected no match, got %+v, %v", rec, err)
+	}
+
+	rec, _ := findStoredReview(db, "r3")
+	shown := withDiffHunks(rec.Result, rec.Diff)
+	if len(shown.Files) != 1 || len(shown.Files[0].Hunks) != 1 || len(rec.Result.Files[0].Hunks) != 0 {
+		t.Errorf("expected hunks from the stored diff on a copy of the result, got %+v", shown.Files)
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"7d", now.AddDate(0, 0, -7)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"2025-06-01T08:00:00Z", time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)},
+		{"2025-06-01", time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseHistoryTime(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseHistoryTime("last week", now); err == nil {
+		t.Errorf("expected an error for an unparseable time")
+	}
+}
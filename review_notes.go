@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// defaultNotesRef is where review notes are kept unless notes_ref says otherwise.
+const defaultNotesRef = "refs/notes/livereview"
+
+// notesSettings reads the notes and notes_ref config keys.
+func notesSettings(verbose bool) (enabled bool, ref string, err error) {
+	cfg, err := loadLayeredConfig(verbose)
+	if err != nil {
+		return false, "", err
+	}
+	raw, origin := cfg.Effective("notes")
+	enabled, err = strconv.ParseBool(raw)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid notes %q in %s: %w", raw, origin, err)
+	}
+	ref, _ = cfg.Effective("notes_ref")
+	return enabled, normalizeNotesRef(ref), nil
+}
+
+// normalizeNotesRef expands a short notes ref ("livereview") to refs/notes/livereview.
+func normalizeNotesRef(ref string) string {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "":
+		return defaultNotesRef
+	case strings.HasPrefix(ref, "refs/"):
+		return ref
+	case strings.HasPrefix(ref, "notes/"):
+		return "refs/" + ref
+	default:
+		return "refs/notes/" + ref
+	}
+}
+
+// remoteNotesRef is where `lrc notes fetch` puts a remote's notes before merging them.
+func remoteNotesRef(remote, ref string) string {
+	return "refs/notes/remotes/" + remote + "/" + strings.TrimPrefix(ref, "refs/notes/")
+}
+
+// reviewForCommit finds the newest stored review of the tree a commit records.
+// Only reviews that know their tree (staged, commit and range reviews) can match.
+func reviewForCommit(db *sql.DB, commit string) (*storedReview, error) {
+	out, err := runGitCommand("git", "rev-parse", "--verify", commit+"^{tree}")
+	if err != nil {
+		return nil, fmt.Errorf("not a commit: %s", commit)
+	}
+	tree := strings.TrimSpace(string(out))
+
+	row := db.QueryRow(`SELECT `+storedReviewColumns+` FROM review_results
+		WHERE tree_hash = ? ORDER BY timestamp DESC, id DESC LIMIT 1`, tree)
+	rec, err := scanStoredReview(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rec, err
+}
+
+// formatReviewNote renders a stored review as the text of a git note. Line numbers
+// refer to the new side of the reviewed diff, which is the annotated commit's tree.
+func formatReviewNote(rec *storedReview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "LiveReview: %s\n", rec.FriendlyName)
+	fmt.Fprintf(&b, "Review-ID: %s\n", rec.ReviewID)
+	fmt.Fprintf(&b, "Reviewed-At: %s\n", rec.Timestamp.UTC().Format("2006-01-02T15:04:05Z"))
+	fmt.Fprintf(&b, "%s\n", formatSeveritySummary(countSeverities(rec.Result.Files)))
+
+	if summary := strings.TrimSpace(rec.Result.Summary); summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", summary)
+	}
+
+	first := true
+	for _, file := range rec.Result.Files {
+		for _, comment := range file.Comments {
+			if first {
+				b.WriteString("\nComments:\n")
+				first = false
+			}
+			label := normalizeSeverity(comment.Severity)
+			if comment.Category != "" {
+				label += "/" + comment.Category
+			}
+			content := strings.ReplaceAll(strings.TrimSpace(comment.Content), "\n", "\n    ")
+			fmt.Fprintf(&b, "- %s:%d [%s] %s\n", file.FilePath, comment.Line, label, content)
+		}
+	}
+	return b.String()
+}
+
+// addReviewNote attaches note to commit, replacing any earlier review note.
+func addReviewNote(ref, commit, note string) error {
+	cmd := exec.Command("git", "notes", "--ref", ref, "add", "-f", "-F", "-", commit)
+	cmd.Stdin = strings.NewReader(note)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git notes add failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// notesIndex maps annotated commits to the note blobs of one notes tree.
+type notesIndex map[string]string
+
+// readNotesIndex lists the notes of a notes ref, or of a notes commit that is not
+// on a ref yet (as in a pre-receive hook). A missing ref yields an empty index.
+func readNotesIndex(rev string) notesIndex {
+	idx := notesIndex{}
+	out, err := exec.Command("git", "ls-tree", "-r", rev).Output()
+	if err != nil {
+		return idx
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		meta, name, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		// Large notes trees fan out as ab/cdef...; the path is the commit hash
+		idx[strings.ReplaceAll(name, "/", "")] = fields[2]
+	}
+	return idx
+}
+
+func (idx notesIndex) has(commit string) bool {
+	_, ok := idx[commit]
+	return ok
+}
+
+// read returns the note text for a commit, or "" when it has none.
+func (idx notesIndex) read(commit string) string {
+	blob, ok := idx[commit]
+	if !ok {
+		return ""
+	}
+	out, err := exec.Command("git", "cat-file", "blob", blob).Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// runNotesAdd implements `lrc notes add`. The post-commit hook runs it with
+// --if-enabled, which does nothing unless notes are turned on and stays quiet
+// when the commit was not reviewed.
+func runNotesAdd(c *cli.Context) error {
+	verbose := c.Bool("verbose")
+	hook := c.Bool("if-enabled")
+	enabled, ref, err := notesSettings(verbose)
+	if err != nil {
+		return err
+	}
+	if hook && !enabled {
+		return nil
+	}
+	commit := c.Args().First()
+	if commit == "" {
+		commit = "HEAD"
+	}
+
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var rec *storedReview
+	if id := c.String("review"); id != "" {
+		rec, err = findStoredReview(db, id)
+	} else {
+		rec, err = reviewForCommit(db, commit)
+	}
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		if hook {
+			return nil
+		}
+		return cli.Exit(fmt.Sprintf("No stored review matches %s; pass --review <id> to pick one from 'lrc history'", commit), 1)
+	}
+
+	if err := addReviewNote(ref, commit, formatReviewNote(rec)); err != nil {
+		return err
+	}
+	sha, _ := runGitCommand("git", "rev-parse", "--short", commit)
+	fmt.Printf("lrc: attached review %s to %s (%s)\n", rec.FriendlyName, strings.TrimSpace(string(sha)), ref)
+	return nil
+}
+
+// runNotesShow implements `lrc notes show`.
+func runNotesShow(c *cli.Context) error {
+	_, ref, err := notesSettings(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	commit := c.Args().First()
+	if commit == "" {
+		commit = "HEAD"
+	}
+	out, err := exec.Command("git", "notes", "--ref", ref, "show", commit).Output()
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("No review note on %s in %s", commit, ref), 1)
+	}
+	os.Stdout.Write(out)
+	return nil
+}
+
+// sharedNotesRefs returns the notes refs `lrc notes push/fetch` exchange, with the
+// merge strategy for each: review notes are text and can be concatenated, signed
+// attestations are JSON and must stay whole.
+func sharedNotesRefs(ref string) [][2]string {
+	return [][2]string{{ref, "union"}, {attestationNotesRef(ref), "ours"}}
+}
+
+// runNotesPush implements `lrc notes push`. Refs that do not exist locally are skipped.
+func runNotesPush(c *cli.Context) error {
+	_, ref, err := notesSettings(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	remote := c.Args().First()
+	if remote == "" {
+		remote = "origin"
+	}
+	args := []string{"push", remote}
+	for _, r := range sharedNotesRefs(ref) {
+		if exec.Command("git", "rev-parse", "--verify", "--quiet", r[0]).Run() == nil {
+			args = append(args, r[0]+":"+r[0])
+		}
+	}
+	if len(args) == 2 {
+		return cli.Exit(fmt.Sprintf("No notes to push under %s", ref), 1)
+	}
+	return runGitPassthrough(args...)
+}
+
+// runNotesFetch implements `lrc notes fetch`. Remote notes land in
+// refs/notes/remotes/<remote>/ first and are then merged into the local ref, so
+// notes written on this machine are never overwritten.
+func runNotesFetch(c *cli.Context) error {
+	_, ref, err := notesSettings(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	remote := c.Args().First()
+	if remote == "" {
+		remote = "origin"
+	}
+	fetched := 0
+	for _, r := range sharedNotesRefs(ref) {
+		if exec.Command("git", "ls-remote", "--exit-code", remote, r[0]).Run() != nil {
+			continue
+		}
+		tracking := remoteNotesRef(remote, r[0])
+		if err := runGitPassthrough("fetch", remote, "+"+r[0]+":"+tracking); err != nil {
+			return err
+		}
+		if err := runGitPassthrough("notes", "--ref", r[0], "merge", "--quiet", "-s", r[1], tracking); err != nil {
+			return err
+		}
+		fetched++
+	}
+	if fetched == 0 {
+		fmt.Printf("%s has no notes under %s\n", remote, ref)
+	}
+	return nil
+}
+
+// runGitPassthrough runs git with the terminal attached, for commands whose
+// progress and errors the user should see as-is.
+func runGitPassthrough(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return nil
+}
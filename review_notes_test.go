@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeNotesRef(t *testing.T) {
+	tests := map[string]string{
+		"":                      defaultNotesRef,
+		"livereview":            "refs/notes/livereview",
+		"notes/team":            "refs/notes/team",
+		"refs/notes/livereview": "refs/notes/livereview",
+	}
+	for in, want := range tests {
+		if got := normalizeNotesRef(in); got != want {
+			t.Errorf("normalizeNotesRef(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := remoteNotesRef("origin", "refs/notes/livereview"); got != "refs/notes/remotes/origin/livereview" {
+		t.Errorf("remoteNotesRef = %q", got)
+	}
+}
+
+func TestFormatReviewNote(t *testing.T) {
+	rec := &storedReview{
+		ReviewID: "r1,r2", FriendlyName: "Brave Otter",
+		Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Result: &diffReviewResponse{Summary: "Looks fine overall.", Files: []diffReviewFileResult{
+			{FilePath: "a.go", Comments: []diffReviewComment{
+				{Line: 3, Severity: "Warning", Category: "bug", Content: "nil check missing\nwhen x is empty"},
+				{Line: 9, Content: "consider renaming"},
+			}},
+		}},
+	}
+	want := `LiveReview: Brave Otter
+Review-ID: r1,r2
+Reviewed-At: 2025-06-01T12:00:00Z
+Findings: 0 critical, 0 error, 1 warning, 1 info
+
+Looks fine overall.
+
+Comments:
+- a.go:3 [warning/bug] nil check missing
+    when x is empty
+- a.go:9 [info] consider renaming
+`
+	if got := formatReviewNote(rec); got != want {
+		t.Errorf("formatReviewNote mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestReviewNoteForCommittedTree(t *testing.T) {
+	initTestRepo(t)
+	if err := os.WriteFile("a.go", []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "add", "a.go")
+	tree := runGit(t, "write-tree")
+	runGit(t, "commit", "-q", "-m", "add a")
+
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatalf("openReviewDB: %v", err)
+	}
+	defer db.Close()
+
+	if rec, err := reviewForCommit(db, "HEAD"); err != nil || rec != nil {
+		t.Fatalf("expected no review before one is stored, got %+v, %v", rec, err)
+	}
+	err = insertReviewResult(db, storedReview{
+		ReviewID: "r1", FriendlyName: "Brave Otter", TreeHash: tree, Branch: "master", Status: "completed", Timestamp: time.Now(),
+		Result: &diffReviewResponse{Status: "completed", Files: []diffReviewFileResult{{FilePath: "a.go", Comments: []diffReviewComment{{Line: 1, Content: "ok"}}}}},
+	})
+	if err != nil {
+		t.Fatalf("insertReviewResult: %v", err)
+	}
+
+	rec, err := reviewForCommit(db, "HEAD")
+	if err != nil || rec == nil || rec.ReviewID != "r1" {
+		t.Fatalf("reviewForCommit = %+v, %v", rec, err)
+	}
+	// Adding twice replaces the note rather than failing
+	for i := 0; i < 2; i++ {
+		if err := addReviewNote(defaultNotesRef, "HEAD", formatReviewNote(rec)); err != nil {
+			t.Fatalf("addReviewNote: %v", err)
+		}
+	}
+	if note := runGit(t, "notes", "--ref", defaultNotesRef, "show", "HEAD"); !strings.Contains(note, "- a.go:1 [info] ok") {
+		t.Errorf("unexpected note:\n%s", note)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pendingReviewsDir holds one record per review that has been submitted but whose
+// result this repository has not seen yet, under .git/lrc/.
+const pendingReviewsDir = "pending"
+
+// maxPendingReviews bounds how many interrupted reviews are remembered.
+const maxPendingReviews = 20
+
+// resumeLast is the --resume value that selects the most recently interrupted review.
+const resumeLast = "last"
+
+// reviewIDPattern is what a review ID must look like before it is used in a file name
+// under .git/lrc/pending: no path separators, and no leading dot (so no "..").
+var reviewIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// validateReviewID rejects review IDs that could escape the pending reviews directory.
+// The IDs come from the API and from --resume, so neither is trusted.
+func validateReviewID(id string) error {
+	if !reviewIDPattern.MatchString(id) || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid review ID %q", id)
+	}
+	return nil
+}
+
+// pendingReview is what `lrc review --resume` needs to reattach to a review
+// that an earlier run submitted. The diff is cached next to it as <id>.diff.
+type pendingReview struct {
+	ReviewIDs    []string  `json:"review_ids"`
+	APIURL       string    `json:"api_url"`
+	RepoName     string    `json:"repo_name"`
+	DiffSource   string    `json:"diff_source"`
+	TreeHash     string    `json:"tree_hash,omitempty"` // staged tree at submission, for staged reviews
+	Branch       string    `json:"branch"`
+	FriendlyName string    `json:"friendly_name,omitempty"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// resumedReview is a pending record together with the diff that was reviewed.
+type resumedReview struct {
+	pendingReview
+	diffContent []byte
+	// files is used when the diff itself is gone but the review DB still knows
+	// which files and line ranges were reviewed
+	files []diffReviewFileResult
+}
+
+func pendingReviewDirPath() (string, error) {
+	gitDir, err := resolveGitDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve git dir: %w", err)
+	}
+	return filepath.Join(gitDir, "lrc", pendingReviewsDir), nil
+}
+
+// savePendingReview records a freshly submitted review and caches its diff, so the
+// review can be resumed if this run is interrupted. Old records are pruned.
+func savePendingReview(rec pendingReview, diffContent []byte) error {
+	dir, err := pendingReviewDirPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create pending review directory: %w", err)
+	}
+
+	for _, id := range rec.ReviewIDs {
+		if err := validateReviewID(id); err != nil {
+			return err
+		}
+	}
+	id := rec.ReviewIDs[0]
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending review: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".diff"), diffContent, 0600); err != nil {
+		return fmt.Errorf("failed to cache diff: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, id+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write pending review: %w", err)
+	}
+
+	return prunePendingReviews(dir, maxPendingReviews)
+}
+
+// clearPendingReview forgets a review once its result has been received.
+func clearPendingReview(reviewID string) error {
+	if err := validateReviewID(reviewID); err != nil {
+		return err
+	}
+	dir, err := pendingReviewDirPath()
+	if err != nil {
+		return err
+	}
+	for _, ext := range []string{".json", ".diff"} {
+		if err := os.Remove(filepath.Join(dir, reviewID+ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// listPendingReviews returns all pending records, newest first.
+func listPendingReviews(dir string) ([]pendingReview, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var recs []pendingReview
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var rec pendingReview
+		if err := json.Unmarshal(data, &rec); err != nil || len(rec.ReviewIDs) == 0 || validateReviewID(rec.ReviewIDs[0]) != nil {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].SubmittedAt.After(recs[j].SubmittedAt) })
+	return recs, nil
+}
+
+func prunePendingReviews(dir string, keep int) error {
+	recs, err := listPendingReviews(dir)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(recs); i++ {
+		id := recs[i].ReviewIDs[0]
+		_ = os.Remove(filepath.Join(dir, id+".json"))
+		_ = os.Remove(filepath.Join(dir, id+".diff"))
+	}
+	return nil
+}
+
+// loadResumedReview finds the review to resume: "last" is the most recently
+// interrupted run, anything else is a review ID (or one chunk's ID). IDs that were
+// never recorded locally are still resumable; the diff then comes from the review
+// DB if the review was recorded there, and is otherwise unavailable.
+func loadResumedReview(target string, verbose bool) (*resumedReview, error) {
+	target = strings.TrimSpace(target)
+	dir, err := pendingReviewDirPath()
+	if err != nil {
+		return nil, err
+	}
+	recs, err := listPendingReviews(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending reviews: %w", err)
+	}
+
+	var rec *pendingReview
+	if target == resumeLast {
+		if len(recs) == 0 {
+			return nil, invalidInput("no interrupted review to resume; pass a review ID to --resume")
+		}
+		rec = &recs[0]
+	} else {
+		if err := validateReviewID(target); err != nil {
+			return nil, invalidInput("%v", err)
+		}
+		for i := range recs {
+			for _, id := range recs[i].ReviewIDs {
+				if id == target {
+					rec = &recs[i]
+				}
+			}
+		}
+	}
+	if rec == nil {
+		rec = &pendingReview{ReviewIDs: []string{target}}
+	}
+
+	resumed := &resumedReview{pendingReview: *rec}
+	if diff, err := os.ReadFile(filepath.Join(dir, rec.ReviewIDs[0]+".diff")); err == nil && len(diff) > 0 {
+		resumed.diffContent = diff
+		if verbose {
+			fmt.Printf("Recovered diff for review %s from local cache (%d bytes)\n", rec.ReviewIDs[0], len(diff))
+		}
+		return resumed, nil
+	}
+
+	files, treeHash, err := reviewedFilesFromDB(rec.ReviewIDs[0])
+	if err != nil && verbose {
+		fmt.Printf("Warning: could not read review DB: %v\n", err)
+	}
+	if len(files) > 0 {
+		resumed.files = files
+		if resumed.TreeHash == "" {
+			resumed.TreeHash = treeHash
+		}
+		if verbose {
+			fmt.Printf("Recovered %d file(s) for review %s from the review DB (hunk contents unavailable)\n", len(files), rec.ReviewIDs[0])
+		}
+	}
+	return resumed, nil
+}
+
+// reviewedFilesFromDB rebuilds the file list of a recorded review session. Only
+// paths and line ranges are stored, so the hunks have no content.
+func reviewedFilesFromDB(reviewID string) ([]diffReviewFileResult, string, error) {
+	db, err := openReviewDB()
+	if err != nil {
+		return nil, "", err
+	}
+	defer db.Close()
+
+	entries, treeHash, err := findSessionFiles(db, reviewID)
+	if err != nil || len(entries) == 0 {
+		return nil, "", err
+	}
+
+	files := make([]diffReviewFileResult, len(entries))
+	for i, e := range entries {
+		hunks := make([]diffReviewHunk, len(e.Hunks))
+		for j, h := range e.Hunks {
+			hunks[j] = diffReviewHunk{
+				OldStartLine: h.OldStartLine,
+				OldLineCount: h.OldLineCount,
+				NewStartLine: h.NewStartLine,
+				NewLineCount: h.NewLineCount,
+			}
+		}
+		files[i] = diffReviewFileResult{FilePath: e.FilePath, Hunks: hunks}
+	}
+	return files, treeHash, nil
+}
+
+// canAttest reports whether finishing this resumed review may write an attestation:
+// it must have been a staged review whose tree is still what is staged now.
+func (r *resumedReview) canAttest() bool {
+	if r.DiffSource != "staged" || r.TreeHash == "" || len(r.diffContent) == 0 {
+		return false
+	}
+	tree, err := currentTreeHash()
+	return err == nil && tree == r.TreeHash
+}
+
+// pollFinishedReview reports whether a poll outcome means the server is done with
+// the review, so there is nothing left to resume.
+func pollFinishedReview(pollErr error) bool {
+	return pollErr == nil || errors.Is(pollErr, errReviewFailed)
+}
+
+// newPendingReview describes a review this run has just submitted.
+func newPendingReview(opts reviewOptions, apiURL, repoName string, resp diffReviewCreateResponse, reviewIDs []string) pendingReview {
+	rec := pendingReview{
+		ReviewIDs:    reviewIDs,
+		APIURL:       apiURL,
+		RepoName:     repoName,
+		DiffSource:   opts.diffSource,
+		Branch:       currentBranch(),
+		FriendlyName: resp.FriendlyName,
+		SubmittedAt:  time.Now().UTC(),
+	}
+	if opts.diffSource == "staged" {
+		if tree, err := currentTreeHash(); err == nil {
+			rec.TreeHash = tree
+		}
+	}
+	return rec
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// initTestRepo creates an empty git repository and makes it the working directory,
+// with a fixed identity for the commits tests make.
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Skipf("git init failed: %v: %s", err, out)
+	}
+	t.Chdir(dir)
+	for _, kv := range [][2]string{{"GIT_AUTHOR_NAME", "t"}, {"GIT_AUTHOR_EMAIL", "t@example.com"}, {"GIT_COMMITTER_NAME", "t"}, {"GIT_COMMITTER_EMAIL", "t@example.com"}} {
+		t.Setenv(kv[0], kv[1])
+	}
+	return dir
+}
+
+// runGit runs git in the working directory, failing the test if it exits non-zero,
+// and returns its trimmed standard output.
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s%s", args, err, out, stderr.String())
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestPendingReviewsResume(t *testing.T) {
+	initTestRepo(t)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, ids := range [][]string{{"old"}, {"chunk-a", "chunk-b"}, {"newest"}} {
+		rec := pendingReview{ReviewIDs: ids, DiffSource: "staged", SubmittedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := savePendingReview(rec, []byte("diff for "+ids[0])); err != nil {
+			t.Fatalf("savePendingReview: %v", err)
+		}
+	}
+
+	last, err := loadResumedReview(resumeLast, false)
+	if err != nil || last.ReviewIDs[0] != "newest" || string(last.diffContent) != "diff for newest" {
+		t.Fatalf("resume last = %+v, %v; want newest with cached diff", last, err)
+	}
+
+	// Any chunk ID finds the whole chunked review
+	chunked, err := loadResumedReview("chunk-b", false)
+	if err != nil || len(chunked.ReviewIDs) != 2 || string(chunked.diffContent) != "diff for chunk-a" {
+		t.Fatalf("resume chunk-b = %+v, %v; want both chunks with cached diff", chunked, err)
+	}
+
+	if err := clearPendingReview("newest"); err != nil {
+		t.Fatalf("clearPendingReview: %v", err)
+	}
+	if last, err = loadResumedReview(resumeLast, false); err != nil || last.ReviewIDs[0] != "chunk-a" {
+		t.Fatalf("after clearing newest, resume last = %+v, %v; want chunk-a", last, err)
+	}
+
+	// Unknown IDs are still resumable, just without a diff
+	unknown, err := loadResumedReview("never-seen", false)
+	if err != nil || unknown.ReviewIDs[0] != "never-seen" || unknown.diffContent != nil || unknown.files != nil {
+		t.Fatalf("resume unknown = %+v, %v", unknown, err)
+	}
+}
+
+func TestResumeLastWithoutPendingReviews(t *testing.T) {
+	initTestRepo(t)
+
+	var inputErr *invalidInputError
+	if _, err := loadResumedReview(resumeLast, false); !errors.As(err, &inputErr) {
+		t.Fatalf("expected invalid input error, got %v", err)
+	}
+}
+
+func TestPendingReviewRejectsUnsafeIDs(t *testing.T) {
+	dir := initTestRepo(t)
+
+	for _, id := range []string{"../../hooks/pre-commit", "a/b", `a\b`, "..", ".hidden", "x..y", "", "id with space"} {
+		if err := savePendingReview(pendingReview{ReviewIDs: []string{id}}, []byte("diff")); err == nil {
+			t.Errorf("savePendingReview(%q) succeeded", id)
+		}
+		var inputErr *invalidInputError
+		if _, err := loadResumedReview(id, false); !errors.As(err, &inputErr) {
+			t.Errorf("loadResumedReview(%q) = %v, want invalid input", id, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git", "hooks", "pre-commit.diff")); !os.IsNotExist(err) {
+		t.Errorf("a review ID wrote outside the pending directory")
+	}
+	for _, id := range []string{"r1", "chunk-a", "42", "a1b2c3d4-e5f6.7890_x"} {
+		if err := validateReviewID(id); err != nil {
+			t.Errorf("validateReviewID(%q) = %v", id, err)
+		}
+	}
+}
+
+func TestPrunePendingReviews(t *testing.T) {
+	initTestRepo(t)
+
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < maxPendingReviews+3; i++ {
+		rec := pendingReview{ReviewIDs: []string{fmt.Sprintf("r%02d", i)}, SubmittedAt: base.Add(time.Duration(i) * time.Second)}
+		if err := savePendingReview(rec, []byte("diff")); err != nil {
+			t.Fatalf("savePendingReview: %v", err)
+		}
+	}
+
+	dir, err := pendingReviewDirPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := listPendingReviews(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != maxPendingReviews || recs[len(recs)-1].ReviewIDs[0] != "r03" {
+		t.Fatalf("kept %d records (oldest %s), want %d with oldest r03", len(recs), recs[len(recs)-1].ReviewIDs[0], maxPendingReviews)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "r00.diff")); !os.IsNotExist(err) {
+		t.Errorf("pruned review's diff was not removed")
+	}
+}
+
+func TestResumeRecoversFilesFromReviewDB(t *testing.T) {
+	initTestRepo(t)
+
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatalf("openReviewDB: %v", err)
+	}
+	files := []attestationFileEntry{{FilePath: "a.go", Hunks: []attestationHunkRange{{NewStartLine: 3, NewLineCount: 2}}}}
+	if err := insertReviewSession(db, "tree1", "main", "reviewed", files, "c1,c2", "", sessionMetadata{}); err != nil {
+		t.Fatalf("insertReviewSession: %v", err)
+	}
+	db.Close()
+
+	resumed, err := loadResumedReview("c2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.files) != 1 || resumed.files[0].FilePath != "a.go" || resumed.files[0].Hunks[0].NewStartLine != 3 {
+		t.Errorf("unexpected files recovered from DB: %+v", resumed.files)
+	}
+	if resumed.TreeHash != "tree1" || resumed.canAttest() {
+		t.Errorf("DB-only resume must not attest (tree %q)", resumed.TreeHash)
+	}
+}
+
+func TestPollFinishedReview(t *testing.T) {
+	for err, want := range map[error]bool{
+		nil:                                     true,
+		fmt.Errorf("%w: boom", errReviewFailed): true,
+		errReviewTimeout:                        false,
+		&APIError{StatusCode: 502}:              false,
+	} {
+		if got := pollFinishedReview(err); got != want {
+			t.Errorf("pollFinishedReview(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
@@ -40,6 +40,9 @@ type ReviewState struct {
 
 	// Error info
 	ErrorSummary string `json:"errorSummary,omitempty"`
+
+	// Coverage turns on the coverage overlay (lrc coverage --serve)
+	Coverage *coverageReport `json:"coverage,omitempty"`
 }
 
 // NewReviewState creates a new ReviewState with initial values
@@ -83,6 +86,29 @@ func (rs *ReviewState) UpdateFromResult(result *diffReviewResponse) {
 	rs.TotalComments = totalComments
 }
 
+// ApplyPartialResult merges comments that are already available while the review
+// is still running. Status is left alone; UpdateFromResult sets the final state.
+func (rs *ReviewState) ApplyPartialResult(partial *diffReviewResponse) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if partial.Summary != "" {
+		rs.Summary = partial.Summary
+	}
+
+	totalComments := 0
+	for i := range rs.Files {
+		for _, resultFile := range partial.Files {
+			if rs.Files[i].FilePath == resultFile.FilePath && len(resultFile.Comments) > 0 {
+				rs.Files[i].Comments = resultFile.Comments
+				break
+			}
+		}
+		totalComments += len(rs.Files[i].Comments)
+	}
+	rs.TotalComments = totalComments
+}
+
 // SetCompleted marks the review as completed
 func (rs *ReviewState) SetCompleted(summary string) {
 	rs.mu.Lock()
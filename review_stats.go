@@ -0,0 +1,645 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// defaultStatsSince is the period `lrc stats` covers unless --since says otherwise.
+const defaultStatsSince = "90d"
+
+// statsFilter selects the sessions `lrc stats` aggregates. Zero values match everything.
+type statsFilter struct {
+	Branch string
+	Author string // case-insensitive substring of the session author
+	Since  time.Time
+	Until  time.Time
+	Bucket string // "day", "week" or "month"
+}
+
+// reviewStats aggregates review sessions for `lrc stats` and the /stats page.
+type reviewStats struct {
+	Branch   string         `json:"branch,omitempty"`
+	Author   string         `json:"author,omitempty"`
+	Since    *time.Time     `json:"since,omitempty"`
+	Until    *time.Time     `json:"until,omitempty"`
+	Bucket   string         `json:"bucket"`
+	Sessions actionCounts   `json:"sessions"`
+	Commits  commitStats    `json:"commits"`
+	Comments commentStats   `json:"comments"`
+	Branches []branchStats  `json:"branches"`
+	Trend    []statsBucket  `json:"trend"`
+	byCommit map[string]int // sessions per archived commit
+}
+
+type actionCounts struct {
+	Total    int `json:"total"`
+	Reviewed int `json:"reviewed"`
+	Vouched  int `json:"vouched"`
+	Skipped  int `json:"skipped"`
+}
+
+func (a *actionCounts) add(action string) {
+	a.Total++
+	switch action {
+	case "reviewed":
+		a.Reviewed++
+	case "vouched":
+		a.Vouched++
+	case "skipped":
+		a.Skipped++
+	}
+}
+
+// commitStats covers the archived sessions, grouped by the commit they led to.
+type commitStats struct {
+	Count                 int     `json:"count"`
+	AvgIterations         float64 `json:"avg_iterations"`
+	AvgSecondsToCommit    float64 `json:"avg_seconds_to_commit"` // first session to commit time
+	MedianSecondsToCommit float64 `json:"median_seconds_to_commit"`
+	AvgReviewSeconds      float64 `json:"avg_review_seconds"` // submit to result, reviews with a recorded duration
+}
+
+// commentStats counts the comments of distinct reviews. Severities and categories
+// come from the stored review results; Total also counts reviews whose result is gone.
+type commentStats struct {
+	Total      int            `json:"total"`
+	Reviews    int            `json:"reviews"`
+	BySeverity map[string]int `json:"by_severity"`
+	ByCategory map[string]int `json:"by_category"`
+}
+
+type branchStats struct {
+	Branch        string       `json:"branch"`
+	Sessions      actionCounts `json:"sessions"`
+	Commits       int          `json:"commits"`
+	AvgIterations float64      `json:"avg_iterations"`
+	commits       map[string]int
+}
+
+type statsBucket struct {
+	Start      time.Time      `json:"start"`
+	Sessions   actionCounts   `json:"sessions"`
+	Comments   int            `json:"comments"`
+	BySeverity map[string]int `json:"by_severity"`
+}
+
+// parseStatsFilter reads since, until, branch, author and bucket through get, which
+// returns "" for unset values. since defaults to defaultStatsSince; "all" lifts it.
+func parseStatsFilter(get func(name string) string, now time.Time) (statsFilter, error) {
+	f := statsFilter{Branch: get("branch"), Author: get("author"), Bucket: get("bucket")}
+	since := get("since")
+	if since == "" {
+		since = defaultStatsSince
+	}
+	if since != "all" {
+		t, err := parseHistoryTime(since, now)
+		if err != nil {
+			return f, err
+		}
+		f.Since = t
+	}
+	if v := get("until"); v != "" {
+		t, err := parseHistoryTime(v, now)
+		if err != nil {
+			return f, err
+		}
+		f.Until = t
+	}
+	switch f.Bucket {
+	case "":
+		f.Bucket = "week"
+	case "day", "week", "month":
+	default:
+		return f, fmt.Errorf("invalid bucket %q (must be day, week or month)", f.Bucket)
+	}
+	return f, nil
+}
+
+// bucketStart returns the start of the day, week (from Monday) or month holding t.
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.Local()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch bucket {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// computeReviewStats aggregates the sessions f selects. commitTime looks up when the
+// archived sessions' commits were made, leaving out commits git no longer has.
+func computeReviewStats(db *sql.DB, f statsFilter, commitTime func(shas []string) map[string]time.Time) (*reviewStats, error) {
+	query := `SELECT s.branch, s.action, s.timestamp, COALESCE(s.review_id, ''), COALESCE(s.comment_count, -1),
+		COALESCE(s.duration_ms, 0), COALESCE(s.commit_sha, ''), COALESCE(r.result_json, '')
+		FROM review_sessions s LEFT JOIN review_results r ON s.review_id != '' AND r.review_id = s.review_id
+		WHERE 1 = 1`
+	var args []interface{}
+	if !f.Since.IsZero() {
+		query += ` AND s.timestamp >= ?`
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		query += ` AND s.timestamp < ?`
+		args = append(args, f.Until.UTC().Format(time.RFC3339))
+	}
+	if f.Branch != "" {
+		query += ` AND s.branch = ?`
+		args = append(args, f.Branch)
+	}
+	if f.Author != "" {
+		query += ` AND instr(lower(COALESCE(s.author, '')), lower(?)) > 0`
+		args = append(args, f.Author)
+	}
+	rows, err := db.Query(query+` ORDER BY s.timestamp, s.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &reviewStats{
+		Branch: f.Branch, Author: f.Author, Bucket: f.Bucket,
+		Comments: commentStats{BySeverity: make(map[string]int), ByCategory: make(map[string]int)},
+		byCommit: make(map[string]int),
+	}
+	if !f.Since.IsZero() {
+		since := f.Since
+		stats.Since = &since
+	}
+	if !f.Until.IsZero() {
+		until := f.Until
+		stats.Until = &until
+	}
+	branches := make(map[string]*branchStats)
+	buckets := make(map[time.Time]*statsBucket)
+	firstSession := make(map[string]time.Time) // per commit
+	seenReviews := make(map[string]bool)
+	var reviewMillis, timedReviews int64
+	var first time.Time
+
+	for rows.Next() {
+		var branch, action, ts, reviewID, commit, resultJSON string
+		var comments int
+		var durationMS int64
+		if err := rows.Scan(&branch, &action, &ts, &reviewID, &comments, &durationMS, &commit, &resultJSON); err != nil {
+			return nil, err
+		}
+		at, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() {
+			first = at
+		}
+		start := bucketStart(at, f.Bucket)
+		bucket := buckets[start]
+		if bucket == nil {
+			bucket = &statsBucket{Start: start, BySeverity: make(map[string]int)}
+			buckets[start] = bucket
+		}
+		b := branches[branch]
+		if b == nil {
+			b = &branchStats{Branch: branch, commits: make(map[string]int)}
+			branches[branch] = b
+		}
+
+		stats.Sessions.add(action)
+		bucket.Sessions.add(action)
+		b.Sessions.add(action)
+		if commit != "" {
+			stats.byCommit[commit]++
+			b.commits[commit]++
+			if t, ok := firstSession[commit]; !ok || at.Before(t) {
+				firstSession[commit] = at
+			}
+		}
+		if durationMS > 0 {
+			reviewMillis += durationMS
+			timedReviews++
+		}
+
+		// A vouch after a review records the same review again; count its comments once
+		if reviewID == "" || seenReviews[reviewID] {
+			continue
+		}
+		seenReviews[reviewID] = true
+		var result diffReviewResponse
+		if resultJSON != "" && json.Unmarshal([]byte(resultJSON), &result) == nil {
+			comments = 0
+			for _, file := range result.Files {
+				for _, comment := range file.Comments {
+					severity := normalizeSeverity(comment.Severity)
+					category := strings.ToLower(strings.TrimSpace(comment.Category))
+					if category == "" {
+						category = "uncategorized"
+					}
+					stats.Comments.BySeverity[severity]++
+					stats.Comments.ByCategory[category]++
+					bucket.BySeverity[severity]++
+					comments++
+				}
+			}
+		}
+		if comments >= 0 {
+			stats.Comments.Total += comments
+			stats.Comments.Reviews++
+			bucket.Comments += comments
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if timedReviews > 0 {
+		stats.Commits.AvgReviewSeconds = float64(reviewMillis) / float64(timedReviews) / 1000
+	}
+	stats.Commits.Count = len(stats.byCommit)
+	if n := len(stats.byCommit); n > 0 {
+		var sessions int
+		shas := make([]string, 0, n)
+		for sha, count := range stats.byCommit {
+			sessions += count
+			shas = append(shas, sha)
+		}
+		stats.Commits.AvgIterations = float64(sessions) / float64(n)
+
+		var waits []float64
+		for sha, committed := range commitTime(shas) {
+			if wait := committed.Sub(firstSession[sha]).Seconds(); wait >= 0 {
+				waits = append(waits, wait)
+			}
+		}
+		if len(waits) > 0 {
+			sort.Float64s(waits)
+			var total float64
+			for _, w := range waits {
+				total += w
+			}
+			stats.Commits.AvgSecondsToCommit = total / float64(len(waits))
+			stats.Commits.MedianSecondsToCommit = waits[len(waits)/2]
+			if len(waits)%2 == 0 {
+				stats.Commits.MedianSecondsToCommit = (waits[len(waits)/2-1] + waits[len(waits)/2]) / 2
+			}
+		}
+	}
+
+	stats.Branches = []branchStats{}
+	for _, b := range branches {
+		b.Commits = len(b.commits)
+		if b.Commits > 0 {
+			var sessions int
+			for _, count := range b.commits {
+				sessions += count
+			}
+			b.AvgIterations = float64(sessions) / float64(b.Commits)
+		}
+		stats.Branches = append(stats.Branches, *b)
+	}
+	sort.Slice(stats.Branches, func(i, j int) bool {
+		if stats.Branches[i].Sessions.Total != stats.Branches[j].Sessions.Total {
+			return stats.Branches[i].Sessions.Total > stats.Branches[j].Sessions.Total
+		}
+		return stats.Branches[i].Branch < stats.Branches[j].Branch
+	})
+
+	// One bucket per period, empty ones included, so trends line up in time
+	stats.Trend = []statsBucket{}
+	if !f.Since.IsZero() {
+		first = f.Since
+	}
+	if !first.IsZero() {
+		end := time.Now()
+		if !f.Until.IsZero() {
+			end = f.Until.Add(-time.Second)
+		}
+		end = bucketStart(end, f.Bucket)
+		for start := bucketStart(first, f.Bucket); !start.After(end); start = nextBucket(start, f.Bucket) {
+			if b := buckets[start]; b != nil {
+				stats.Trend = append(stats.Trend, *b)
+			} else {
+				stats.Trend = append(stats.Trend, statsBucket{Start: start, BySeverity: map[string]int{}})
+			}
+		}
+	}
+	return stats, nil
+}
+
+// gitCommitTimes returns the commit time of each SHA git still has.
+func gitCommitTimes(shas []string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	if len(shas) == 0 {
+		return times
+	}
+	// Drop commits that were rewritten and collected, which would fail git log
+	check := exec.Command("git", "cat-file", "--batch-check=%(objectname) %(objecttype)")
+	check.Stdin = strings.NewReader(strings.Join(shas, "\n") + "\n")
+	out, err := check.Output()
+	if err != nil {
+		return times
+	}
+	var present []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == "commit" {
+			present = append(present, fields[0])
+		}
+	}
+	if len(present) == 0 {
+		return times
+	}
+
+	log := exec.Command("git", "log", "--no-walk=unsorted", "--stdin", "--format=%H %ct")
+	log.Stdin = strings.NewReader(strings.Join(present, "\n") + "\n")
+	if out, err = log.Output(); err != nil {
+		return times
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if sec, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			times[fields[0]] = time.Unix(sec, 0)
+		}
+	}
+	return times
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as block characters scaled to the largest one.
+func sparkline(values []int) string {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		switch {
+		case max == 0 || v == 0:
+			sb.WriteRune(' ')
+		default:
+			sb.WriteRune(sparkBlocks[(v*len(sparkBlocks)+max-1)/max-1])
+		}
+	}
+	return sb.String()
+}
+
+// formatStatsDuration renders seconds as a rough duration: "45s", "12m", "3h20m", "2d4h".
+func formatStatsDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+// formatStatsText renders stats as terminal tables with sparkline trends.
+func formatStatsText(stats *reviewStats) string {
+	var buf bytes.Buffer
+	scope := "all branches"
+	if stats.Branch != "" {
+		scope = "branch " + stats.Branch
+	}
+	if stats.Author != "" {
+		scope += ", author " + stats.Author
+	}
+	period := "all time"
+	if stats.Since != nil {
+		period = "since " + stats.Since.Local().Format("2006-01-02")
+	}
+	if stats.Until != nil {
+		period += ", before " + stats.Until.Local().Format("2006-01-02")
+	}
+	fmt.Fprintf(&buf, "Review stats for %s, %s (trends per %s)\n\n", scope, period, stats.Bucket)
+	if stats.Sessions.Total == 0 {
+		buf.WriteString("No review sessions found.\n")
+		return buf.String()
+	}
+
+	trend := func(value func(b statsBucket) int) string {
+		values := make([]int, len(stats.Trend))
+		for i, b := range stats.Trend {
+			values[i] = value(b)
+		}
+		return sparkline(values)
+	}
+	s := stats.Sessions
+	fmt.Fprintf(&buf, "%-10s %6d        %s\n", "Sessions", s.Total, trend(func(b statsBucket) int { return b.Sessions.Total }))
+	fmt.Fprintf(&buf, "  %-8s %6d %4.0f%%  %s\n", "reviewed", s.Reviewed, percentOf(s.Reviewed, s.Total), trend(func(b statsBucket) int { return b.Sessions.Reviewed }))
+	fmt.Fprintf(&buf, "  %-8s %6d %4.0f%%  %s\n", "vouched", s.Vouched, percentOf(s.Vouched, s.Total), trend(func(b statsBucket) int { return b.Sessions.Vouched }))
+	fmt.Fprintf(&buf, "  %-8s %6d %4.0f%%  %s\n", "skipped", s.Skipped, percentOf(s.Skipped, s.Total), trend(func(b statsBucket) int { return b.Sessions.Skipped }))
+	fmt.Fprintf(&buf, "%-10s %6d        %s\n", "Comments", stats.Comments.Total, trend(func(b statsBucket) int { return b.Comments }))
+	for _, sev := range severityOrder {
+		sev := sev
+		fmt.Fprintf(&buf, "  %-8s %6d        %s\n", sev, stats.Comments.BySeverity[sev], trend(func(b statsBucket) int { return b.BySeverity[sev] }))
+	}
+
+	c := stats.Commits
+	buf.WriteString("\n")
+	if c.Count > 0 {
+		fmt.Fprintf(&buf, "Commits: %d, %.1f iteration(s) on average before committing\n", c.Count, c.AvgIterations)
+		if c.AvgSecondsToCommit > 0 {
+			fmt.Fprintf(&buf, "First review to commit: median %s, average %s\n", formatStatsDuration(c.MedianSecondsToCommit), formatStatsDuration(c.AvgSecondsToCommit))
+		}
+	} else {
+		buf.WriteString("Commits: none yet (sessions are archived under their commit by the post-commit hook)\n")
+	}
+	if c.AvgReviewSeconds > 0 {
+		fmt.Fprintf(&buf, "Review time: %s on average\n", formatStatsDuration(c.AvgReviewSeconds))
+	}
+	if stats.Comments.Reviews > 0 {
+		fmt.Fprintf(&buf, "Comments per review: %.1f over %d review(s)\n", float64(stats.Comments.Total)/float64(stats.Comments.Reviews), stats.Comments.Reviews)
+	}
+
+	if len(stats.Comments.ByCategory) > 0 {
+		type kv struct {
+			k string
+			v int
+		}
+		var cats []kv
+		for k, v := range stats.Comments.ByCategory {
+			cats = append(cats, kv{k, v})
+		}
+		sort.Slice(cats, func(i, j int) bool {
+			if cats[i].v != cats[j].v {
+				return cats[i].v > cats[j].v
+			}
+			return cats[i].k < cats[j].k
+		})
+		fmt.Fprintf(&buf, "\n%-24s  %8s\n", "CATEGORY", "COMMENTS")
+		for _, cat := range cats {
+			fmt.Fprintf(&buf, "%-24s  %8d\n", truncateField(cat.k, 24), cat.v)
+		}
+	}
+
+	fmt.Fprintf(&buf, "\n%-30s  %8s  %8s  %8s  %8s  %7s  %8s\n", "BRANCH", "SESSIONS", "REVIEWED", "VOUCHED", "SKIPPED", "COMMITS", "AVG ITER")
+	for _, b := range stats.Branches {
+		avg := "-"
+		if b.Commits > 0 {
+			avg = fmt.Sprintf("%.1f", b.AvgIterations)
+		}
+		fmt.Fprintf(&buf, "%-30s  %8d  %7.0f%%  %7.0f%%  %7.0f%%  %7d  %8s\n", truncateField(b.Branch, 30), b.Sessions.Total,
+			percentOf(b.Sessions.Reviewed, b.Sessions.Total), percentOf(b.Sessions.Vouched, b.Sessions.Total),
+			percentOf(b.Sessions.Skipped, b.Sessions.Total), b.Commits, avg)
+	}
+	return buf.String()
+}
+
+// runStats implements `lrc stats`.
+func runStats(c *cli.Context) error {
+	if c.NArg() > 0 {
+		return reviewExitError(invalidInput("usage: lrc stats [flags]"))
+	}
+	f, err := parseStatsFilter(c.String, time.Now())
+	if err != nil {
+		return reviewExitError(&invalidInputError{err: err})
+	}
+	if c.Bool("current-branch") {
+		f.Branch = currentBranch()
+	}
+	if c.Bool("mine") {
+		if f.Author = gitAuthor(); f.Author == "" {
+			return reviewExitError(invalidInput("--mine needs a git identity (git config user.name / user.email)"))
+		}
+	}
+
+	if c.Bool("serve") {
+		return serveStats(f, c.Int("port"))
+	}
+
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	stats, err := computeReviewStats(db, f, gitCommitTimes)
+	if err != nil {
+		return fmt.Errorf("failed to read review sessions: %w", err)
+	}
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	fmt.Print(formatStatsText(stats))
+	return nil
+}
+
+// registerStatsRoutes adds the /stats page and its /api/stats data to a web UI mux.
+// The page's query string (since, until, branch, author, bucket) is passed on to
+// /api/stats; base fills in what the query leaves out.
+func registerStatsRoutes(mux *http.ServeMux, base statsFilter) {
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		if err := serveStaticFile(w, r, "stats.html"); err != nil {
+			http.Error(w, "Failed to load page", http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		get := func(name string) string {
+			if v := query.Get(name); v != "" {
+				return v
+			}
+			switch name {
+			case "branch":
+				return base.Branch
+			case "author":
+				return base.Author
+			case "bucket":
+				return base.Bucket
+			}
+			return ""
+		}
+		f, err := parseStatsFilter(get, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if query.Get("since") == "" && !base.Since.IsZero() {
+			f.Since = base.Since
+		}
+
+		db, err := openReviewDB()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer db.Close()
+		stats, err := computeReviewStats(db, f, gitCommitTimes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	})
+}
+
+// serveStats serves the /stats page on its own until interrupted.
+func serveStats(f statsFilter, port int) error {
+	ln, selectedPort, err := pickServePort(port, 10)
+	if err != nil {
+		return fmt.Errorf("failed to find available port: %w", err)
+	}
+	if selectedPort != port {
+		fmt.Printf("Port %d is busy; serving on %d instead.\n", port, selectedPort)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/static/", http.StripPrefix("/static/", getStaticHandler()))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/stats", http.StatusFound)
+	})
+	registerStatsRoutes(mux, f)
+
+	serveURL := fmt.Sprintf("http://localhost:%d/stats", selectedPort)
+	fmt.Printf("Serving review stats at: %s\n", highlightURL(serveURL))
+	fmt.Println("Press Ctrl-C to stop")
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		openURL(serveURL)
+	}()
+
+	server := &http.Server{Handler: mux}
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("server error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeReviewStats(t *testing.T) {
+	initTestRepo(t)
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now().Truncate(time.Second)
+	at := func(hoursAgo int) string {
+		return now.Add(-time.Duration(hoursAgo) * time.Hour).UTC().Format(time.RFC3339)
+	}
+	result := `{"files":[{"file_path":"a.go","comments":[
+		{"line":1,"severity":"error","category":"Bug"},{"line":2,"severity":"warning","category":"style"},{"line":3,"severity":"warning"}]}]}`
+	if _, err := db.Exec(`INSERT INTO review_results (review_id, branch, status, result_json, timestamp) VALUES ('r1', 'main', 'completed', ?, ?)`, result, at(50)); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := []struct {
+		branch, action, author, reviewID string
+		hoursAgo, comments               int
+		commit                           interface{}
+	}{
+		// Reviewed, then vouched with the same review, then committed as c1
+		{"main", "reviewed", "Ann <ann@example.com>", "r1", 50, 3, "c1"},
+		{"main", "vouched", "Ann <ann@example.com>", "r1", 49, 3, "c1"},
+		// A review whose result was not kept
+		{"main", "reviewed", "Bob <bob@example.com>", "r2", 26, 2, "c2"},
+		{"topic", "skipped", "Ann <ann@example.com>", "", 2, -1, nil},
+		// Outside the default period
+		{"main", "reviewed", "Ann <ann@example.com>", "r3", 24 * 200, 5, nil},
+	}
+	for _, s := range sessions {
+		var comments interface{} = s.comments
+		if s.comments < 0 {
+			comments = nil
+		}
+		if _, err := db.Exec(`INSERT INTO review_sessions (tree_hash, branch, action, timestamp, review_id, author, comment_count, commit_sha)
+			VALUES ('t', ?, ?, ?, ?, ?, ?, ?)`, s.branch, s.action, at(s.hoursAgo), s.reviewID, s.author, comments, s.commit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitTimes := func(shas []string) map[string]time.Time {
+		return map[string]time.Time{"c1": now.Add(-48 * time.Hour), "c2": now.Add(-22 * time.Hour)}
+	}
+
+	f, err := parseStatsFilter(func(string) string { return "" }, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := computeReviewStats(db, f, commitTimes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (actionCounts{Total: 4, Reviewed: 2, Vouched: 1, Skipped: 1}); stats.Sessions != want {
+		t.Errorf("sessions = %+v, want %+v", stats.Sessions, want)
+	}
+	// The vouch repeats r1, whose comments count once; r2 only has its recorded count
+	if stats.Comments.Total != 5 || stats.Comments.Reviews != 2 {
+		t.Errorf("comments = %d over %d reviews, want 5 over 2", stats.Comments.Total, stats.Comments.Reviews)
+	}
+	if stats.Comments.BySeverity["warning"] != 2 || stats.Comments.ByCategory["bug"] != 1 || stats.Comments.ByCategory["uncategorized"] != 1 {
+		t.Errorf("severities %v, categories %v", stats.Comments.BySeverity, stats.Comments.ByCategory)
+	}
+	c := stats.Commits
+	if c.Count != 2 || c.AvgIterations != 1.5 {
+		t.Errorf("commits = %+v, want 2 commits at 1.5 iterations", c)
+	}
+	// c1 came 2h after its first session, c2 4h after
+	if c.MedianSecondsToCommit != 3*3600 || c.AvgSecondsToCommit != 3*3600 {
+		t.Errorf("time to commit: median %v, average %v", c.MedianSecondsToCommit, c.AvgSecondsToCommit)
+	}
+	if len(stats.Branches) != 2 || stats.Branches[0].Branch != "main" || stats.Branches[0].Commits != 2 {
+		t.Errorf("branches = %+v", stats.Branches)
+	}
+	if n := len(stats.Trend); n < 13 || n > 15 {
+		t.Errorf("%d weekly buckets over 90 days", n)
+	}
+
+	f.Author, f.Since = "ann@", time.Time{}
+	if stats, err = computeReviewStats(db, f, commitTimes); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Sessions.Total != 4 || stats.Comments.Total != 8 {
+		t.Errorf("all of Ann's sessions: %+v, %d comments", stats.Sessions, stats.Comments.Total)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]int{0, 0}); got != "  " {
+		t.Errorf("sparkline of zeros = %q", got)
+	}
+}
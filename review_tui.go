@@ -0,0 +1,955 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Decision UIs for interactive reviews (--ui)
+const (
+	reviewUIWeb = "web"
+	reviewUITUI = "tui"
+)
+
+const cReverse = "\033[7m"
+
+// tuiDecision is what the user chose in the terminal UI; code is one of the
+// decision constants.
+type tuiDecision struct {
+	code    int
+	message string
+	push    bool
+}
+
+// tuiRow is one line of the diff pane. comment is the index of the comment the
+// row belongs to in the file's comments, or -1.
+type tuiRow struct {
+	text    string
+	style   string
+	comment int
+}
+
+// tuiCommentRef identifies a comment by file and position in that file's comments.
+type tuiCommentRef struct {
+	file, comment int
+}
+
+// tuiPrompt reads a commit message, or a y/n confirmation when confirm is set.
+type tuiPrompt struct {
+	label   string
+	input   []rune
+	confirm bool
+	done    func(input string) *tuiDecision
+}
+
+// reviewTUI is the full-screen terminal review (--ui tui). It shows the same
+// ReviewState as the web UI and offers the same decisions.
+type reviewTUI struct {
+	state      *ReviewState
+	initialMsg string
+	width      int
+	height     int
+
+	// Snapshot of the review state, refreshed before every frame
+	files        []diffReviewFileResult
+	status       string
+	summary      string
+	errorSummary string
+	friendlyName string
+	startedAt    time.Time
+
+	selected  int // entry in the file list; 0 is the summary, i+1 is files[i]
+	listTop   int
+	focusDiff bool
+	scroll    int
+	hidden    map[string]bool // severities hidden by the filter
+	current   tuiCommentRef   // comment reached with n/N; file -1 for none
+	message   string          // feedback shown above the key help
+	prompt    *tuiPrompt
+}
+
+func newReviewTUI(state *ReviewState, initialMsg string) *reviewTUI {
+	t := &reviewTUI{
+		state:      state,
+		initialMsg: initialMsg,
+		width:      80,
+		height:     24,
+		hidden:     make(map[string]bool),
+		current:    tuiCommentRef{file: -1},
+	}
+	t.refresh()
+	if len(t.files) > 0 {
+		t.selected = 1
+	}
+	return t
+}
+
+// refresh copies the parts of the review state the UI shows.
+func (t *reviewTUI) refresh() {
+	t.state.mu.RLock()
+	defer t.state.mu.RUnlock()
+	t.files = append(t.files[:0], t.state.Files...)
+	t.status = t.state.Status
+	t.summary = t.state.Summary
+	t.errorSummary = t.state.ErrorSummary
+	t.friendlyName = t.state.FriendlyName
+	t.startedAt = t.state.StartedAt
+}
+
+func (t *reviewTUI) running() bool {
+	return t.status == "in_progress"
+}
+
+func (t *reviewTUI) visible(c diffReviewComment) bool {
+	return !t.hidden[normalizeSeverity(c.Severity)]
+}
+
+// visibleComments lists the comments the filter shows, by file and line.
+func (t *reviewTUI) visibleComments() []tuiCommentRef {
+	var refs []tuiCommentRef
+	for f, file := range t.files {
+		start := len(refs)
+		for i, c := range file.Comments {
+			if t.visible(c) {
+				refs = append(refs, tuiCommentRef{f, i})
+			}
+		}
+		comments := file.Comments
+		sort.SliceStable(refs[start:], func(i, j int) bool {
+			return comments[refs[start+i].comment].Line < comments[refs[start+j].comment].Line
+		})
+	}
+	return refs
+}
+
+func (t *reviewTUI) listWidth() int {
+	w := t.width / 3
+	if w > 40 {
+		w = 40
+	}
+	if w < 16 {
+		w = 16
+	}
+	return w
+}
+
+func (t *reviewTUI) diffWidth() int {
+	return t.width - t.listWidth() - 1
+}
+
+// bodyHeight is the height of the two panes: the header, the message line and
+// the key help take the rest.
+func (t *reviewTUI) bodyHeight() int {
+	if h := t.height - 3; h > 1 {
+		return h
+	}
+	return 1
+}
+
+// paneRows returns the right pane for the selected entry.
+func (t *reviewTUI) paneRows() []tuiRow {
+	if t.selected == 0 || t.selected > len(t.files) {
+		return t.summaryRows(t.diffWidth())
+	}
+	return t.diffRows(t.files[t.selected-1], t.diffWidth())
+}
+
+func (t *reviewTUI) summaryRows(width int) []tuiRow {
+	var rows []tuiRow
+	add := func(text, style string) {
+		for _, line := range wrapText(text, width) {
+			rows = append(rows, tuiRow{text: line, style: style, comment: -1})
+		}
+	}
+	switch {
+	case t.status == "failed":
+		add("Review failed: "+t.errorSummary, cRed)
+	case t.running() && t.summary == "":
+		add("The review is running; comments appear as they arrive.", cDim)
+	}
+	if t.summary != "" {
+		add(t.summary, "")
+	}
+	return rows
+}
+
+// diffRows renders a file's hunks with the visible comments below the line they
+// are on. Comments on lines outside the hunks come first.
+func (t *reviewTUI) diffRows(file diffReviewFileResult, width int) []tuiRow {
+	byLine := make(map[int][]int)
+	for i, c := range file.Comments {
+		if t.visible(c) {
+			byLine[c.Line] = append(byLine[c.Line], i)
+		}
+	}
+	placed := make(map[int]bool)
+
+	var body []tuiRow
+	for _, hunk := range file.Hunks {
+		body = append(body, tuiRow{
+			text:    fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStartLine, hunk.OldLineCount, hunk.NewStartLine, hunk.NewLineCount),
+			style:   cCyan,
+			comment: -1,
+		})
+		oldLine, newLine := hunk.OldStartLine, hunk.NewStartLine
+		for _, line := range strings.Split(hunk.Content, "\n") {
+			if line == "" || strings.HasPrefix(line, "@@") {
+				continue
+			}
+			var oldNum, newNum, style string
+			commentLine := 0
+			switch line[0] {
+			case '-':
+				oldNum, style = strconv.Itoa(oldLine), cRed
+				oldLine++
+			case '+':
+				newNum, style = strconv.Itoa(newLine), cGreen
+				commentLine = newLine
+				newLine++
+			case '\\': // "\ No newline at end of file"
+				body = append(body, tuiRow{text: strings.Repeat(" ", 12) + line, style: cDim, comment: -1})
+				continue
+			default:
+				if line[0] != ' ' {
+					line = " " + line
+				}
+				oldNum, newNum = strconv.Itoa(oldLine), strconv.Itoa(newLine)
+				commentLine = newLine
+				oldLine++
+				newLine++
+			}
+			body = append(body, tuiRow{text: fmt.Sprintf("%5s %5s %s", oldNum, newNum, line), style: style, comment: -1})
+			if commentLine > 0 && !placed[commentLine] {
+				placed[commentLine] = true
+				for _, i := range byLine[commentLine] {
+					body = append(body, commentRows(file.Comments[i], i, width)...)
+				}
+			}
+		}
+	}
+
+	var rows []tuiRow
+	var lines []int
+	for line := range byLine {
+		if !placed[line] {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		for _, i := range byLine[line] {
+			rows = append(rows, commentRows(file.Comments[i], i, width)...)
+		}
+	}
+	if len(file.Hunks) == 0 && len(rows) == 0 {
+		rows = append(rows, tuiRow{text: "No diff available for this file.", style: cDim, comment: -1})
+	}
+	return append(rows, body...)
+}
+
+// commentRows renders a comment as a header line and its wrapped text.
+func commentRows(c diffReviewComment, index, width int) []tuiRow {
+	severity := normalizeSeverity(c.Severity)
+	style := severityStyle(severity)
+	header := "  ┃ " + strings.ToUpper(severity)
+	if c.Category != "" {
+		header += " · " + c.Category
+	}
+	if c.Line > 0 {
+		header += fmt.Sprintf(" · line %d", c.Line)
+	}
+	rows := []tuiRow{{text: header, style: cBold + style, comment: index}}
+	for _, line := range wrapText(c.Content, width-4) {
+		rows = append(rows, tuiRow{text: "  ┃ " + line, style: style, comment: index})
+	}
+	return rows
+}
+
+func severityStyle(severity string) string {
+	switch severity {
+	case "critical", "error":
+		return cRed
+	case "warning":
+		return cYellow
+	}
+	return cBlue
+}
+
+// wrapText breaks text into lines of at most width runes, at spaces where it can.
+func wrapText(text string, width int) []string {
+	if width < 8 {
+		width = 8
+	}
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		line := []rune(cleanTUIText(para))
+		if len(line) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		for len(line) > width {
+			cut := width
+			for i := width; i > width/2; i-- {
+				if line[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			lines = append(lines, strings.TrimRight(string(line[:cut]), " "))
+			line = []rune(strings.TrimLeft(string(line[cut:]), " "))
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// cleanTUIText expands tabs and drops control characters, which would move the cursor.
+func cleanTUIText(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fitText pads or truncates s to exactly width runes.
+func fitText(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(cleanTUIText(s))
+	if len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return string(r) + strings.Repeat(" ", width-len(r))
+}
+
+// fitPath truncates a path from the left so the file name stays readable.
+func fitPath(p string, width int) string {
+	if r := []rune(p); len(r) > width && width > 1 {
+		p = "…" + string(r[len(r)-width+1:])
+	}
+	return fitText(p, width)
+}
+
+// summaryTitle returns the first markdown heading of the review summary, which the
+// web UI also suggests as the commit message.
+func summaryTitle(summary string) string {
+	for _, line := range strings.Split(summary, "\n") {
+		line = strings.TrimSpace(line)
+		if trimmed := strings.TrimLeft(line, "#"); trimmed != line && strings.HasPrefix(trimmed, " ") {
+			if title := strings.TrimSpace(trimmed); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
+}
+
+// render draws the screen as height lines of width columns.
+func (t *reviewTUI) render() []string {
+	lines := make([]string, 0, t.height)
+	lines = append(lines, t.headerLine())
+
+	listW, diffW := t.listWidth(), t.diffWidth()
+	bodyH := t.bodyHeight()
+	entries := len(t.files) + 1
+	if t.selected < t.listTop {
+		t.listTop = t.selected
+	}
+	if t.selected >= t.listTop+bodyH {
+		t.listTop = t.selected - bodyH + 1
+	}
+
+	rows := t.paneRows()
+	t.clampScroll(len(rows))
+	for i := 0; i < bodyH; i++ {
+		var left string
+		if e := t.listTop + i; e < entries {
+			left = t.listEntry(e, listW)
+		} else {
+			left = strings.Repeat(" ", listW)
+		}
+		right := strings.Repeat(" ", diffW)
+		if r := t.scroll + i; r < len(rows) {
+			row := rows[r]
+			style := row.style
+			if row.comment >= 0 && t.current.file == t.selected-1 && t.current.comment == row.comment {
+				style += cReverse
+			}
+			right = fitText(row.text, diffW)
+			if style != "" {
+				right = style + right + cReset
+			}
+		}
+		lines = append(lines, left+cDim+"│"+cReset+right)
+	}
+
+	switch {
+	case t.prompt != nil:
+		lines = append(lines, cBold+fitText(t.prompt.label+string(t.prompt.input)+"█", t.width)+cReset)
+	default:
+		lines = append(lines, fitText(t.message, t.width))
+	}
+	help := "↑↓ move  Tab pane  n/N comment  1-4 severity  c commit  p commit+push  s skip  v vouch  q abort"
+	if t.prompt != nil && !t.prompt.confirm {
+		help = "Enter confirm  Esc cancel  Ctrl-U clear"
+	}
+	lines = append(lines, cDim+fitText(help, t.width)+cReset)
+	return lines
+}
+
+func (t *reviewTUI) headerLine() string {
+	state := "review complete"
+	switch t.status {
+	case "in_progress":
+		state = "reviewing " + time.Since(t.startedAt).Truncate(time.Second).String()
+	case "failed":
+		state = "review failed"
+	}
+	left := " LiveReview"
+	if t.friendlyName != "" {
+		left += " · " + t.friendlyName
+	}
+	left += " · " + state
+
+	total, shown := 0, len(t.visibleComments())
+	for _, f := range t.files {
+		total += len(f.Comments)
+	}
+	var filter []string
+	for _, sev := range severityOrder {
+		mark := strings.ToUpper(sev[:1])
+		if t.hidden[sev] {
+			mark = "·"
+		}
+		filter = append(filter, mark)
+	}
+	right := fmt.Sprintf("%d files · %d/%d comments · [%s] ", len(t.files), shown, total, strings.Join(filter, ""))
+	gap := t.width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		return cReverse + fitText(left, t.width) + cReset
+	}
+	return cReverse + left + strings.Repeat(" ", gap) + right + cReset
+}
+
+func (t *reviewTUI) listEntry(e, width int) string {
+	marker := "  "
+	if e == t.selected {
+		marker = "▸ "
+	}
+	if e == 0 {
+		text := marker + fitText("Summary", width-2)
+		return t.listStyle(e, text, "")
+	}
+	file := t.files[e-1]
+	count, worst := 0, ""
+	for _, c := range file.Comments {
+		if t.visible(c) {
+			count++
+			if worst == "" || severityRank(c.Severity) > severityRank(worst) {
+				worst = normalizeSeverity(c.Severity)
+			}
+		}
+	}
+	suffix, style := "", ""
+	if count > 0 {
+		suffix = fmt.Sprintf(" %d", count)
+		style = severityStyle(worst)
+	}
+	text := marker + fitPath(file.FilePath, width-2-len(suffix)) + suffix
+	return t.listStyle(e, text, style)
+}
+
+func (t *reviewTUI) listStyle(e int, text, style string) string {
+	if e == t.selected && !t.focusDiff {
+		style += cReverse
+	}
+	if style == "" {
+		return text
+	}
+	return style + text + cReset
+}
+
+func (t *reviewTUI) clampScroll(rows int) {
+	if max := rows - t.bodyHeight(); t.scroll > max {
+		t.scroll = max
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+}
+
+func (t *reviewTUI) selectEntry(e int) {
+	if e < 0 {
+		e = 0
+	}
+	if e > len(t.files) {
+		e = len(t.files)
+	}
+	if e != t.selected {
+		t.selected = e
+		t.scroll = 0
+	}
+}
+
+// jumpComment moves to the next (dir 1) or previous (dir -1) visible comment.
+func (t *reviewTUI) jumpComment(dir int) {
+	refs := t.visibleComments()
+	if len(refs) == 0 {
+		t.message = "No comments to show"
+		return
+	}
+	pos := -1
+	for i, ref := range refs {
+		if ref == t.current {
+			pos = i
+			break
+		}
+	}
+	next := pos + dir
+	if pos < 0 {
+		// Start from the selected file
+		next = 0
+		for i, ref := range refs {
+			if ref.file+1 >= t.selected {
+				next = i
+				break
+			}
+		}
+		if dir < 0 {
+			next--
+		}
+	}
+	if next < 0 || next >= len(refs) {
+		t.message = "No more comments"
+		return
+	}
+	t.current = refs[next]
+	t.selectEntry(t.current.file + 1)
+	t.focusDiff = true
+	for i, row := range t.paneRows() {
+		if row.comment == t.current.comment {
+			t.scroll = i - t.bodyHeight()/3
+			break
+		}
+	}
+	t.message = fmt.Sprintf("Comment %d of %d", next+1, len(refs))
+}
+
+func (t *reviewTUI) toggleSeverity(sev string) {
+	t.hidden[sev] = !t.hidden[sev]
+	if t.current.file >= 0 && !t.visible(t.files[t.current.file].Comments[t.current.comment]) {
+		t.current = tuiCommentRef{file: -1}
+	}
+	var shown []string
+	for _, s := range severityOrder {
+		if !t.hidden[s] {
+			shown = append(shown, s)
+		}
+	}
+	if len(shown) == 0 {
+		t.message = "All severities hidden"
+	} else {
+		t.message = "Showing " + strings.Join(shown, ", ")
+	}
+}
+
+func (t *reviewTUI) confirm(label string, d tuiDecision) {
+	t.prompt = &tuiPrompt{label: label + " [y/N] ", confirm: true, done: func(string) *tuiDecision { return &d }}
+}
+
+// commit asks for the commit message, suggesting the initial message or the
+// summary's title like the web UI does.
+func (t *reviewTUI) commit(push bool) {
+	if t.running() {
+		t.message = "The review is still running: wait for it, or skip (s) or vouch (v)"
+		return
+	}
+	msg := strings.TrimSpace(t.initialMsg)
+	if msg == "" {
+		msg = summaryTitle(t.summary)
+	}
+	label := "Commit message: "
+	if push {
+		label = "Commit message (then push): "
+	}
+	t.prompt = &tuiPrompt{label: label, input: []rune(msg), done: func(input string) *tuiDecision {
+		if strings.TrimSpace(input) == "" {
+			t.message = "Commit message is required"
+			return nil
+		}
+		return &tuiDecision{code: decisionCommit, message: strings.TrimSpace(input), push: push}
+	}}
+}
+
+// handleKey applies a key and returns the decision it completes, if any.
+func (t *reviewTUI) handleKey(key string) *tuiDecision {
+	if key == "ctrl-c" {
+		return &tuiDecision{code: decisionAbort}
+	}
+	if p := t.prompt; p != nil {
+		return t.handlePromptKey(p, key)
+	}
+	t.message = ""
+
+	page := t.bodyHeight() - 1
+	switch key {
+	case "up", "k":
+		if t.focusDiff {
+			t.scroll--
+		} else {
+			t.selectEntry(t.selected - 1)
+		}
+	case "down", "j":
+		if t.focusDiff {
+			t.scroll++
+		} else {
+			t.selectEntry(t.selected + 1)
+		}
+	case "pgup", "ctrl-b", "ctrl-u":
+		t.scroll -= page
+	case "pgdn", "ctrl-f", "ctrl-d", " ":
+		t.scroll += page
+	case "home", "g":
+		t.scroll = 0
+	case "end", "G":
+		t.scroll = len(t.paneRows())
+	case "tab", "btab":
+		t.focusDiff = !t.focusDiff
+	case "left", "h":
+		t.focusDiff = false
+	case "right", "l", "enter":
+		t.focusDiff = true
+	case "[":
+		t.selectEntry(t.selected - 1)
+	case "]":
+		t.selectEntry(t.selected + 1)
+	case "n":
+		t.jumpComment(1)
+	case "N":
+		t.jumpComment(-1)
+	case "1", "2", "3", "4":
+		t.toggleSeverity(severityOrder[key[0]-'1'])
+	case "0":
+		t.hidden = make(map[string]bool)
+		t.message = "Showing all severities"
+	case "c":
+		t.commit(false)
+	case "p":
+		t.commit(true)
+	case "s":
+		if t.running() {
+			t.confirm("Skip the review and commit without it?", tuiDecision{code: decisionSkip})
+		} else {
+			t.confirm("Skip: leave without committing?", tuiDecision{code: decisionSkipWeb})
+		}
+	case "v":
+		if t.running() {
+			t.confirm("Vouch for these changes and commit without waiting for the review?", tuiDecision{code: decisionVouch})
+		} else {
+			t.message = "The review has finished, so the changes count as reviewed: commit (c) instead"
+		}
+	case "q", "a":
+		t.confirm("Abort the commit?", tuiDecision{code: decisionAbort})
+	}
+	return nil
+}
+
+func (t *reviewTUI) handlePromptKey(p *tuiPrompt, key string) *tuiDecision {
+	if p.confirm {
+		t.prompt = nil
+		if key == "y" || key == "Y" {
+			return p.done("")
+		}
+		t.message = "Cancelled"
+		return nil
+	}
+	switch key {
+	case "esc":
+		t.prompt = nil
+		t.message = "Cancelled"
+	case "enter":
+		d := p.done(string(p.input))
+		if d != nil {
+			t.prompt = nil
+		}
+		return d
+	case "backspace":
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	case "ctrl-u":
+		p.input = nil
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			p.input = append(p.input, []rune(key)...)
+		}
+	}
+	return nil
+}
+
+// parseTUIKeys splits terminal input into key names: printable characters as
+// themselves, and "up", "enter", "ctrl-c" and so on for the rest.
+func parseTUIKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			if len(b) == 1 {
+				keys = append(keys, "esc")
+				b = b[1:]
+				continue
+			}
+			if b[1] != '[' && b[1] != 'O' {
+				// Alt+key: treat as Esc followed by the key
+				keys = append(keys, "esc")
+				b = b[1:]
+				continue
+			}
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end == len(b) {
+				return keys
+			}
+			switch string(b[2 : end+1]) {
+			case "A":
+				keys = append(keys, "up")
+			case "B":
+				keys = append(keys, "down")
+			case "C":
+				keys = append(keys, "right")
+			case "D":
+				keys = append(keys, "left")
+			case "H", "1~", "7~":
+				keys = append(keys, "home")
+			case "F", "4~", "8~":
+				keys = append(keys, "end")
+			case "5~":
+				keys = append(keys, "pgup")
+			case "6~":
+				keys = append(keys, "pgdn")
+			case "Z":
+				keys = append(keys, "btab")
+			}
+			b = b[end+1:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, "tab")
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+			b = b[1:]
+		case c < 0x20:
+			keys = append(keys, "ctrl-"+string(rune('a'+c-1)))
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+// openTTYOutput opens the terminal for writing, whatever stdout points at.
+func openTTYOutput() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	}
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
+
+// terminalAvailable reports whether the terminal UI can take over a terminal.
+func terminalAvailable() bool {
+	tty, err := openTTY()
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+	return term.IsTerminal(int(tty.Fd()))
+}
+
+// tuiOutputGate holds back the review's output while the terminal UI owns the
+// screen. The review installs it once, before it starts the terminal UI and the
+// polling goroutines, and the UI only holds and releases it, so no goroutine swaps
+// os.Stdout or os.Stderr while others print through them. While held, stdout is
+// discarded and stderr is kept to be printed on release.
+type tuiOutputGate struct {
+	stdout, stderr *os.File // the real ones
+	outW, errW     *os.File
+	forwarders     sync.WaitGroup
+	closeOnce      sync.Once
+
+	mu      sync.Mutex
+	held    bool
+	heldErr bytes.Buffer
+}
+
+// installTUIOutputGate points stdout, stderr and the log at the gate. It must be
+// called before any other goroutine of the review writes output.
+func installTUIOutputGate() (*tuiOutputGate, error) {
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return nil, err
+	}
+	g := &tuiOutputGate{stdout: os.Stdout, stderr: os.Stderr, outW: outW, errW: errW}
+	g.forwarders.Add(2)
+	go g.forward(outR, g.stdout, false)
+	go g.forward(errR, g.stderr, true)
+	os.Stdout, os.Stderr = outW, errW
+	log.SetOutput(errW)
+	return g, nil
+}
+
+func (g *tuiOutputGate) forward(r, dst *os.File, keepWhileHeld bool) {
+	defer g.forwarders.Done()
+	defer r.Close()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			g.mu.Lock()
+			if !g.held {
+				dst.Write(buf[:n])
+			} else if keepWhileHeld {
+				g.heldErr.Write(buf[:n])
+			}
+			g.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (g *tuiOutputGate) hold() {
+	g.mu.Lock()
+	g.held = true
+	g.mu.Unlock()
+}
+
+// release lets output through again and prints the stderr held back meanwhile.
+func (g *tuiOutputGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.held = false
+	g.stderr.Write(g.heldErr.Bytes())
+	g.heldErr.Reset()
+}
+
+// close releases the gate and waits until everything written so far has reached
+// the real stdout and stderr. A polling goroutine may outlive the review, so stdout
+// and stderr keep pointing at the gate and later writes are dropped; the log goes
+// back to the real stderr.
+func (g *tuiOutputGate) close() {
+	if g == nil {
+		return
+	}
+	g.closeOnce.Do(func() {
+		log.SetOutput(g.stderr)
+		g.release()
+		g.outW.Close()
+		g.errW.Close()
+		g.forwarders.Wait()
+	})
+}
+
+// runReviewTUI shows state full-screen until the user makes a decision. Output of
+// the running review is held back at gate meanwhile; stderr is printed once the
+// terminal is restored.
+func runReviewTUI(state *ReviewState, initialMsg string, gate *tuiOutputGate) (tuiDecision, error) {
+	in, err := openTTY()
+	if err != nil {
+		return tuiDecision{}, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer in.Close()
+	out, err := openTTYOutput()
+	if err != nil {
+		return tuiDecision{}, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	defer out.Close()
+
+	// Deferred in reverse: leave the alternate screen, restore the terminal mode,
+	// then print the held back stderr
+	gate.hold()
+	defer gate.release()
+	fd := int(in.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return tuiDecision{}, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+	out.WriteString("\033[?1049h\033[?25l") // alternate screen, cursor hidden
+	defer out.WriteString("\033[?25h\033[?1049l")
+
+	keys := make(chan string, 32)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, key := range parseTUIKeys(buf[:n]) {
+				select {
+				case keys <- key:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	ui := newReviewTUI(state, initialMsg)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	var last string
+	for {
+		ui.refresh()
+		if w, h, err := term.GetSize(int(out.Fd())); err == nil && w > 0 && h > 0 {
+			ui.width, ui.height = w, h
+		}
+		if frame := "\033[H" + strings.Join(ui.render(), "\033[K\r\n") + "\033[K"; frame != last {
+			out.WriteString(frame)
+			last = frame
+		}
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return tuiDecision{code: decisionAbort}, fmt.Errorf("terminal closed")
+			}
+			if d := ui.handleKey(key); d != nil {
+				return *d, nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
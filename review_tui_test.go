@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testTUIState() *ReviewState {
+	files := []diffReviewFileResult{
+		{
+			FilePath: "a.go",
+			Hunks:    []diffReviewHunk{{OldStartLine: 1, OldLineCount: 2, NewStartLine: 1, NewLineCount: 3, Content: "@@ -1,2 +1,3 @@\n package a\n-var x = 1\n+var x = 2\n+var y = 3\n"}},
+			Comments: []diffReviewComment{
+				{Line: 3, Severity: "warning", Category: "style", Content: "y is unused"},
+				{Line: 2, Severity: "error", Category: "bug", Content: "x changed"},
+			},
+		},
+		{
+			FilePath: "b.go",
+			Hunks:    []diffReviewHunk{{OldStartLine: 0, OldLineCount: 0, NewStartLine: 1, NewLineCount: 1, Content: "+package b\n"}},
+			Comments: []diffReviewComment{{Line: 40, Severity: "info", Content: "outside the diff"}},
+		},
+	}
+	return NewReviewState("r1", files, true, false, "", "")
+}
+
+func TestParseTUIKeys(t *testing.T) {
+	got := parseTUIKeys([]byte("j\x1b[A\x1b[6~\t\r\x7f\x03é\x1b"))
+	want := []string{"j", "up", "pgdn", "tab", "enter", "backspace", "ctrl-c", "é", "esc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTUIKeys = %q, want %q", got, want)
+	}
+}
+
+func TestReviewTUINavigation(t *testing.T) {
+	ui := newReviewTUI(testTUIState(), "")
+	ui.width, ui.height = 100, 20
+
+	rows := ui.paneRows()
+	var texts []string
+	for _, r := range rows {
+		texts = append(texts, strings.TrimSpace(r.text))
+	}
+	joined := strings.Join(texts, "\n")
+	// Comments sit below their lines
+	if !strings.Contains(joined, "var x = 2\n┃ ERROR · bug · line 2\n┃ x changed\n3 +var y = 3\n┃ WARNING") {
+		t.Errorf("diff pane:\n%s", joined)
+	}
+
+	// n walks the comments by file and line
+	var visited []string
+	for i := 0; i < 3; i++ {
+		ui.handleKey("n")
+		c := ui.files[ui.current.file].Comments[ui.current.comment]
+		visited = append(visited, ui.files[ui.current.file].FilePath+":"+c.Content)
+	}
+	want := []string{"a.go:x changed", "a.go:y is unused", "b.go:outside the diff"}
+	if !reflect.DeepEqual(visited, want) || ui.selected != 2 || !ui.focusDiff {
+		t.Errorf("visited %q, selected %d", visited, ui.selected)
+	}
+	ui.handleKey("n")
+	if ui.message != "No more comments" {
+		t.Errorf("past the last comment: %q", ui.message)
+	}
+
+	// Hiding info drops the focused comment and its rows
+	ui.handleKey("4")
+	if ui.current.file != -1 || len(ui.visibleComments()) != 2 {
+		t.Errorf("after hiding info: current %+v, %d visible", ui.current, len(ui.visibleComments()))
+	}
+	for _, r := range ui.paneRows() {
+		if r.comment >= 0 {
+			t.Errorf("hidden comment still shown: %q", r.text)
+		}
+	}
+	if lines := ui.render(); len(lines) != ui.height {
+		t.Errorf("render drew %d lines for height %d", len(lines), ui.height)
+	}
+}
+
+func TestReviewTUIDecisions(t *testing.T) {
+	state := testTUIState()
+	ui := newReviewTUI(state, "")
+	type keys []string
+	press := func(ks keys) *tuiDecision {
+		var d *tuiDecision
+		for _, k := range ks {
+			d = ui.handleKey(k)
+		}
+		return d
+	}
+
+	// While the review runs: no commit, but skip and vouch
+	if d := press(keys{"c"}); d != nil || ui.prompt != nil {
+		t.Errorf("commit while running = %+v", d)
+	}
+	if d := press(keys{"s", "n"}); d != nil {
+		t.Errorf("declined skip = %+v", d)
+	}
+	if d := press(keys{"s", "y"}); d == nil || d.code != decisionSkip {
+		t.Errorf("skip while running = %+v, want decisionSkip", d)
+	}
+	if d := press(keys{"v", "y"}); d == nil || d.code != decisionVouch {
+		t.Errorf("vouch = %+v, want decisionVouch", d)
+	}
+
+	state.SetCompleted("## Bump x\n\nDetails")
+	ui.refresh()
+	if d := press(keys{"s", "y"}); d == nil || d.code != decisionSkipWeb {
+		t.Errorf("skip after review = %+v, want decisionSkipWeb", d)
+	}
+	// The summary's title is suggested as the commit message
+	if d := press(keys{"p", "!", "enter"}); d == nil || *d != (tuiDecision{code: decisionCommit, message: "Bump x!", push: true}) {
+		t.Errorf("commit and push = %+v", d)
+	}
+	if d := press(keys{"c", "ctrl-u", "enter"}); d != nil || ui.message != "Commit message is required" {
+		t.Errorf("empty commit message = %+v, %q", d, ui.message)
+	}
+	if d := press(keys{"esc", "q", "y"}); d == nil || d.code != decisionAbort {
+		t.Errorf("abort = %+v", d)
+	}
+	if d := press(keys{"ctrl-c"}); d == nil || d.code != decisionAbort {
+		t.Errorf("ctrl-c = %+v", d)
+	}
+}
+
+func TestTUIOutputGate(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		log.SetOutput(os.Stderr)
+	})
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outFile, errFile
+	gate, err := installTUIOutputGate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		return string(data)
+	}
+
+	// A polling goroutine keeps printing while the UI holds and releases the gate
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			fmt.Println("progress")
+		}
+	}()
+	gate.hold()
+	fmt.Fprintln(os.Stderr, "held back")
+	log.Print("logged")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		gate.mu.Lock()
+		held := gate.heldErr.String()
+		gate.mu.Unlock()
+		if strings.Contains(held, "held back") && strings.Contains(held, "logged") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stderr was not held back: %q", held)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := read("stderr"); got != "" {
+		t.Errorf("stderr reached the terminal while held: %q", got)
+	}
+	gate.release()
+	if got := read("stderr"); !strings.Contains(got, "held back") || !strings.Contains(got, "logged") {
+		t.Errorf("stderr after release = %q", got)
+	}
+	wg.Wait()
+
+	fmt.Println("after release")
+	gate.close()
+	if got := read("stdout"); !strings.HasSuffix(got, "after release\n") {
+		t.Errorf("stdout after close = %q", got)
+	}
+	// Late writes are dropped, the log goes to the real stderr again
+	fmt.Println("late")
+	log.Print("late log")
+	if got := read("stdout"); strings.Contains(got, "late") {
+		t.Errorf("write after close reached stdout: %q", got)
+	}
+	if got := read("stderr"); !strings.Contains(got, "late log") {
+		t.Errorf("log after close = %q", got)
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,13 +28,36 @@ type reviewSession struct {
 	Timestamp time.Time `json:"timestamp"`
 	DiffFiles string    `json:"diff_files"` // JSON-encoded []attestationFileEntry
 	ReviewID  string    `json:"review_id"`  // API review ID, if applicable
+	PatchID   string    `json:"patch_id"`   // stable patch ID of the staged diff
+	// CommitSHA is the commit the session's changes went into, set by the
+	// post-commit hook; "" while the session is still open
+	CommitSHA string `json:"commit_sha,omitempty"`
+	sessionMetadata
+}
+
+// sessionMetadata is what a session records about who ran it and how the review
+// went. Sessions from before schema version 2 have none of it.
+type sessionMetadata struct {
+	Author       string        `json:"author,omitempty"`   // "Name <email>" of the git author
+	Duration     time.Duration `json:"duration,omitempty"` // from submitting the diff to the result; 0 if unknown
+	CommentCount int           `json:"comment_count"`      // -1 when no review result was seen
+	APIURL       string        `json:"api_url,omitempty"`
+	LrcVersion   string        `json:"lrc_version,omitempty"`
+}
+
+// noReviewMetadata is the metadata of a session that ran no review, such as --skip.
+func noReviewMetadata() sessionMetadata {
+	return sessionMetadata{CommentCount: -1}
 }
 
 // attestationFileEntry is a slim representation of a file diff for storage
 // (no Content field — just line ranges).
 type attestationFileEntry struct {
-	FilePath string                `json:"file_path"`
+	FilePath string                 `json:"file_path"`
 	Hunks    []attestationHunkRange `json:"hunks"`
+	// LineHashes maps each added line (new-side line number) to a hash of its
+	// normalized content, so coverage can follow lines that moved.
+	LineHashes map[int]string `json:"line_hashes,omitempty"`
 }
 
 // attestationHunkRange stores just the line-range info from a hunk.
@@ -50,6 +77,8 @@ type coverageResult struct {
 	PriorReviewCount int     `json:"prior_review_count"` // count of "reviewed" sessions
 }
 
+// reviewDBSchema is the schema of version 1, before any migration added to it;
+// see reviewdb_migrations.go.
 const reviewDBSchema = `
 CREATE TABLE IF NOT EXISTS review_sessions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -58,15 +87,35 @@ CREATE TABLE IF NOT EXISTS review_sessions (
     action TEXT NOT NULL,
     timestamp TEXT NOT NULL,
     diff_files TEXT,
-    review_id TEXT
+    review_id TEXT,
+    patch_id TEXT
 );
 CREATE INDEX IF NOT EXISTS idx_review_sessions_branch ON review_sessions(branch);
 CREATE INDEX IF NOT EXISTS idx_review_sessions_tree ON review_sessions(tree_hash);
+CREATE TABLE IF NOT EXISTS review_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id TEXT NOT NULL,
+    friendly_name TEXT,
+    tree_hash TEXT,
+    branch TEXT NOT NULL,
+    repo_name TEXT,
+    diff_source TEXT,
+    status TEXT NOT NULL,
+    summary TEXT,
+    comment_count INTEGER NOT NULL DEFAULT 0,
+    max_severity INTEGER NOT NULL DEFAULT -1,
+    result_json TEXT NOT NULL,
+    diff TEXT,
+    timestamp TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_review_results_review_id ON review_results(review_id);
+CREATE INDEX IF NOT EXISTS idx_review_results_branch ON review_results(branch);
 `
 
-// reviewDBPath returns the path to the review database under .git/lrc/.
+// reviewDBPath returns the path to the review database under .git/lrc/. All
+// worktrees of a repository share it, so it lives in the common git dir.
 func reviewDBPath() (string, error) {
-	gitDir, err := resolveGitDir()
+	gitDir, err := resolveGitCommonDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve git dir: %w", err)
 	}
@@ -95,14 +144,40 @@ func openReviewDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to open review database: %w", err)
 	}
 
-	if _, err := db.Exec(reviewDBSchema); err != nil {
+	if err := migrateReviewDB(db); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to initialize review database schema: %w", err)
+		return nil, fmt.Errorf("%s: %w", dbPath, err)
 	}
 
 	return db, nil
 }
 
+// ensureColumn adds a column to an existing table unless it is already there.
+func ensureColumn(tx *sql.Tx, table, column, decl string) error {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, decl))
+	return err
+}
+
+// gitAuthor returns "Name <email>" of the author git would record for a commit
+// now, or "" when git has no identity configured.
+func gitAuthor() string {
+	out, err := exec.Command("git", "var", "GIT_AUTHOR_IDENT").Output()
+	if err != nil {
+		return ""
+	}
+	ident := strings.TrimSpace(string(out))
+	// Drop the "<timestamp> <zone>" after the email
+	if end := strings.LastIndex(ident, ">"); end >= 0 {
+		ident = ident[:end+1]
+	}
+	return ident
+}
+
 // currentBranch returns the current git branch name, or "HEAD" if detached.
 func currentBranch() string {
 	out, err := exec.Command("git", "symbolic-ref", "--short", "HEAD").Output()
@@ -128,24 +203,35 @@ func filesToEntries(files []diffReviewFileResult) []attestationFileEntry {
 			}
 		}
 		entries[i] = attestationFileEntry{
-			FilePath: f.FilePath,
-			Hunks:    hunks,
+			FilePath:   f.FilePath,
+			Hunks:      hunks,
+			LineHashes: addedLineHashes(f.Hunks),
 		}
 	}
 	return entries
 }
 
 // insertReviewSession inserts a new review session into the database.
-func insertReviewSession(db *sql.DB, treeHash, branch, action string, files []attestationFileEntry, reviewID string) error {
+// patchID is the stable patch ID of the staged diff, "" if unknown.
+func insertReviewSession(db *sql.DB, treeHash, branch, action string, files []attestationFileEntry, reviewID, patchID string, meta sessionMetadata) error {
 	filesJSON, err := json.Marshal(files)
 	if err != nil {
 		return fmt.Errorf("failed to marshal diff files: %w", err)
 	}
 
+	var durationMS, commentCount interface{}
+	if meta.Duration > 0 {
+		durationMS = meta.Duration.Milliseconds()
+	}
+	if meta.CommentCount >= 0 {
+		commentCount = meta.CommentCount
+	}
 	_, err = db.Exec(
-		`INSERT INTO review_sessions (tree_hash, branch, action, timestamp, diff_files, review_id)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		treeHash, branch, action, time.Now().UTC().Format(time.RFC3339), string(filesJSON), reviewID,
+		`INSERT INTO review_sessions (tree_hash, branch, action, timestamp, diff_files, review_id, patch_id,
+		     author, duration_ms, comment_count, api_url, lrc_version)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		treeHash, branch, action, time.Now().UTC().Format(time.RFC3339), string(filesJSON), reviewID, patchID,
+		nullableText([]byte(meta.Author)), durationMS, commentCount, nullableText([]byte(meta.APIURL)), nullableText([]byte(meta.LrcVersion)),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert review session: %w", err)
@@ -153,20 +239,20 @@ func insertReviewSession(db *sql.DB, treeHash, branch, action string, files []at
 	return nil
 }
 
-// countIterations returns the total number of review sessions for the given branch.
+// countIterations returns the number of open review sessions for the given branch.
 func countIterations(db *sql.DB, branch string) (int, error) {
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM review_sessions WHERE branch = ?`, branch).Scan(&count)
+	err := db.QueryRow(`SELECT COUNT(*) FROM review_sessions WHERE branch = ? AND commit_sha IS NULL`, branch).Scan(&count)
 	return count, err
 }
 
-// getPriorReviewedSessions returns all "reviewed" sessions for the branch,
-// ordered by timestamp ascending.
+// getPriorReviewedSessions returns the open "reviewed" sessions for the branch,
+// ordered by timestamp ascending. Archived sessions belong to earlier commits.
 func getPriorReviewedSessions(db *sql.DB, branch string) ([]reviewSession, error) {
 	rows, err := db.Query(
-		`SELECT id, tree_hash, branch, action, timestamp, diff_files, review_id
+		`SELECT `+reviewSessionColumns+`
 		 FROM review_sessions
-		 WHERE branch = ? AND action = 'reviewed'
+		 WHERE branch = ? AND action = 'reviewed' AND commit_sha IS NULL
 		 ORDER BY timestamp ASC`,
 		branch,
 	)
@@ -177,27 +263,53 @@ func getPriorReviewedSessions(db *sql.DB, branch string) ([]reviewSession, error
 
 	var sessions []reviewSession
 	for rows.Next() {
-		var s reviewSession
-		var ts, diffFiles, reviewID string
-		if err := rows.Scan(&s.ID, &s.TreeHash, &s.Branch, &s.Action, &ts, &diffFiles, &reviewID); err != nil {
+		s, err := scanReviewSession(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
-		parsedTime, parseErr := time.Parse(time.RFC3339, ts)
-		if parseErr != nil {
-			fmt.Fprintf(os.Stderr, "Warning: malformed timestamp %q in review session %d: %v\n", ts, s.ID, parseErr)
-		}
-		s.Timestamp = parsedTime
-		s.DiffFiles = diffFiles
-		s.ReviewID = reviewID
 		sessions = append(sessions, s)
 	}
 	return sessions, rows.Err()
 }
 
-// cleanupReviewSessions deletes all sessions for the given branch.
-// Called after a successful commit to start fresh.
-func cleanupReviewSessions(db *sql.DB, branch string) (int64, error) {
-	result, err := db.Exec(`DELETE FROM review_sessions WHERE branch = ?`, branch)
+const reviewSessionColumns = `id, tree_hash, branch, action, timestamp, COALESCE(diff_files, ''), COALESCE(review_id, ''),
+	COALESCE(patch_id, ''), COALESCE(author, ''), COALESCE(duration_ms, 0), COALESCE(comment_count, -1),
+	COALESCE(api_url, ''), COALESCE(lrc_version, ''), COALESCE(commit_sha, '')`
+
+// scanReviewSession reads a row selected with reviewSessionColumns.
+func scanReviewSession(scan func(dest ...interface{}) error) (reviewSession, error) {
+	var s reviewSession
+	var ts string
+	var durationMS int64
+	if err := scan(&s.ID, &s.TreeHash, &s.Branch, &s.Action, &ts, &s.DiffFiles, &s.ReviewID,
+		&s.PatchID, &s.Author, &durationMS, &s.CommentCount, &s.APIURL, &s.LrcVersion, &s.CommitSHA); err != nil {
+		return s, err
+	}
+	parsedTime, parseErr := time.Parse(time.RFC3339, ts)
+	if parseErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: malformed timestamp %q in review session %d: %v\n", ts, s.ID, parseErr)
+	}
+	s.Timestamp = parsedTime
+	s.Duration = time.Duration(durationMS) * time.Millisecond
+	return s, nil
+}
+
+// patchReviewed reports whether an open "reviewed" session on any branch had the
+// same staged patch, e.g. before the changes were rebased or moved to another branch.
+func patchReviewed(db *sql.DB, patchID string) (bool, error) {
+	if patchID == "" {
+		return false, nil
+	}
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM review_sessions WHERE patch_id = ? AND action = 'reviewed' AND commit_sha IS NULL`, patchID).Scan(&count)
+	return count > 0, err
+}
+
+// archiveReviewSessions links the open sessions of the given branch to the commit
+// they led to. Called after a successful commit, so the next change starts with
+// no prior iterations or coverage while the history stays in the DB.
+func archiveReviewSessions(db *sql.DB, branch, commit string) (int64, error) {
+	result, err := db.Exec(`UPDATE review_sessions SET commit_sha = ? WHERE branch = ? AND commit_sha IS NULL`, commit, branch)
 	if err != nil {
 		return 0, err
 	}
@@ -219,30 +331,55 @@ func cleanupAllSessions(db *sql.DB) (int64, error) {
 // The algorithm:
 //  1. Get all "reviewed" sessions for the current branch
 //  2. For each prior session, compute which of the current diff's new-side lines
-//     were already covered by that review (i.e., lines that haven't changed since)
+//     were already covered by that review (i.e., lines that haven't changed since,
+//     or added lines whose content the review saw, even if they moved or their
+//     file was renamed)
 //  3. Accumulate coverage across all prior sessions (union of covered lines)
 //  4. Return iteration count and coverage percentage
-func computePriorCoverage(db *sql.DB, branch, currentTreeHash string, currentFiles []attestationFileEntry) (coverageResult, error) {
+//
+// A diff whose patch ID matches a reviewed session on any branch is fully covered.
+func computePriorCoverage(db *sql.DB, branch, currentTreeHash, currentPatchID string, currentFiles []attestationFileEntry) (coverageResult, error) {
+	result, _, err := priorCoveredLines(db, branch, currentTreeHash, currentPatchID, currentFiles)
+	return result, err
+}
+
+// priorCoveredLines is computePriorCoverage that also returns the covered lines,
+// keyed "filepath:linenum".
+func priorCoveredLines(db *sql.DB, branch, currentTreeHash, currentPatchID string, currentFiles []attestationFileEntry) (coverageResult, map[string]bool, error) {
 	result := coverageResult{}
 
 	// Count total iterations (all actions)
 	totalIter, err := countIterations(db, branch)
 	if err != nil {
-		return result, err
+		return result, nil, err
 	}
 	result.Iterations = totalIter + 1 // +1 for the current one being recorded
 
 	// Get prior "reviewed" sessions
 	priorSessions, err := getPriorReviewedSessions(db, branch)
 	if err != nil {
-		return result, err
+		return result, nil, err
 	}
 	result.PriorReviewCount = len(priorSessions)
 
+	// The same patch was reviewed before, whatever base it sat on then
+	if samePatch, err := patchReviewed(db, currentPatchID); err != nil {
+		return result, nil, err
+	} else if samePatch {
+		result.TotalLines = countTotalNewLines(currentFiles)
+		result.CoveredLines = result.TotalLines
+		result.PriorAICovPct = 100
+		all := make(map[string]bool)
+		for _, f := range currentFiles {
+			markAllNewLines(all, f)
+		}
+		return result, all, nil
+	}
+
 	if len(priorSessions) == 0 || len(currentFiles) == 0 {
 		// No prior AI reviews or no files in current diff — 0% coverage
 		result.TotalLines = countTotalNewLines(currentFiles)
-		return result, nil
+		return result, nil, nil
 	}
 
 	// Build set of current file paths for quick lookup
@@ -254,7 +391,7 @@ func computePriorCoverage(db *sql.DB, branch, currentTreeHash string, currentFil
 	// Total new-side lines in the current diff
 	result.TotalLines = countTotalNewLines(currentFiles)
 	if result.TotalLines == 0 {
-		return result, nil
+		return result, nil, nil
 	}
 
 	// coveredLines tracks which (file, line) pairs are covered by prior reviews.
@@ -292,19 +429,36 @@ func computePriorCoverage(db *sql.DB, branch, currentTreeHash string, currentFil
 		}
 
 		priorFileMap := make(map[string][]attestationHunkRange)
+		priorFileEntries := make(map[string]attestationFileEntry)
 		for _, pf := range priorFiles {
 			priorFileMap[pf.FilePath] = pf.Hunks
+			priorFileEntries[pf.FilePath] = pf
 		}
 
+		var renames map[string]string // loaded on first use
 		for _, cf := range currentFiles {
 			if !changedFileSet[cf.FilePath] {
 				// File didn't change since prior review — all new-side lines are covered
 				markAllNewLines(coveredLines, cf)
-			} else {
-				// File changed — compute line-level overlap
-				if priorHunks, ok := priorFileMap[cf.FilePath]; ok {
-					markOverlappingLines(coveredLines, cf.FilePath, cf.Hunks, priorHunks, session.TreeHash, currentTreeHash)
+				continue
+			}
+			// File changed — compute line-level overlap
+			if priorHunks, ok := priorFileMap[cf.FilePath]; ok {
+				markOverlappingLines(coveredLines, cf.FilePath, cf.Hunks, priorHunks, session.TreeHash, currentTreeHash)
+			}
+			// Reviewed lines that moved, within the file or along with a rename
+			prior, ok := priorFileEntries[cf.FilePath]
+			if !ok {
+				if renames == nil {
+					if renames, err = renamedFiles(session.TreeHash, currentTreeHash); err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: could not detect renames between trees %s..%s: %v\n", session.TreeHash, currentTreeHash, err)
+						renames = map[string]string{}
+					}
 				}
+				prior, ok = priorFileEntries[renames[cf.FilePath]]
+			}
+			if ok {
+				markMatchingLines(coveredLines, cf, prior)
 			}
 		}
 	}
@@ -314,7 +468,7 @@ func computePriorCoverage(db *sql.DB, branch, currentTreeHash string, currentFil
 		result.PriorAICovPct = float64(result.CoveredLines) / float64(result.TotalLines) * 100
 	}
 
-	return result, nil
+	return result, coveredLines, nil
 }
 
 // countTotalNewLines returns the sum of all new-side line counts across all hunks.
@@ -337,6 +491,90 @@ func markAllNewLines(covered map[string]bool, f attestationFileEntry) {
 	}
 }
 
+// normalizedLineHash hashes a line with its whitespace normalized, so re-indented
+// lines still match.
+func normalizedLineHash(line string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(line), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// addedLineHashes hashes the added lines of a file's hunks by new-side line number.
+func addedLineHashes(hunks []diffReviewHunk) map[int]string {
+	hashes := make(map[int]string)
+	for _, h := range hunks {
+		line := h.NewStartLine
+		for _, text := range strings.Split(h.Content, "\n") {
+			switch {
+			case strings.HasPrefix(text, "@@"), strings.HasPrefix(text, "\\"):
+			case strings.HasPrefix(text, "+"):
+				hashes[line] = normalizedLineHash(text[1:])
+				line++
+			case strings.HasPrefix(text, "-"):
+			default:
+				line++
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return hashes
+}
+
+// markMatchingLines marks the added lines of the current file whose content matches
+// a line added in the prior review, wherever that line sits now. Each prior line
+// covers at most one current line.
+func markMatchingLines(covered map[string]bool, current, prior attestationFileEntry) {
+	if len(current.LineHashes) == 0 || len(prior.LineHashes) == 0 {
+		return
+	}
+	available := make(map[string]int)
+	for _, hash := range prior.LineHashes {
+		available[hash]++
+	}
+	lines := make([]int, 0, len(current.LineHashes))
+	for line := range current.LineHashes {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	// Lines already covered where they are use up their match first
+	for _, line := range lines {
+		if covered[fmt.Sprintf("%s:%d", current.FilePath, line)] {
+			available[current.LineHashes[line]]--
+		}
+	}
+	for _, line := range lines {
+		key := fmt.Sprintf("%s:%d", current.FilePath, line)
+		if hash := current.LineHashes[line]; !covered[key] && available[hash] > 0 {
+			available[hash]--
+			covered[key] = true
+		}
+	}
+}
+
+// renamedFiles maps the new path of each file renamed between two trees to its old path.
+func renamedFiles(tree1, tree2 string) (map[string]string, error) {
+	out, err := exec.Command("git", "diff-tree", "-r", "-M", "--name-status", "-z", tree1, tree2).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff-tree failed: %w", err)
+	}
+	renames := make(map[string]string)
+	// Entries are "<status>\0<path>\0", with a second path for renames and copies
+	fields := strings.Split(strings.TrimRight(string(out), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch fields[i][0] {
+		case 'R':
+			if i+2 < len(fields) {
+				renames[fields[i+2]] = fields[i+1]
+			}
+			i++
+		case 'C':
+			i++
+		}
+	}
+	return renames, nil
+}
+
 // diffTreeFiles returns the list of file paths that changed between two tree objects.
 func diffTreeFiles(tree1, tree2 string) ([]string, error) {
 	out, err := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", tree1, tree2).Output()
@@ -399,7 +637,8 @@ func lineInRanges(line int, ranges []lineRange) bool {
 // diffTreeFileHunks returns parsed hunk ranges for changes in a specific file
 // between two tree objects.
 func diffTreeFileHunks(tree1, tree2, filePath string) ([]attestationHunkRange, error) {
-	out, err := exec.Command("git", "diff", tree1, tree2, "--", filePath).Output()
+	// No context lines: only lines that really changed lose their coverage
+	out, err := exec.Command("git", "diff", "-U0", tree1, tree2, "--", filePath).Output()
 	if err != nil {
 		return nil, fmt.Errorf("git diff %s %s -- %s failed: %w", tree1, tree2, filePath, err)
 	}
@@ -455,7 +694,8 @@ func parseHunkRangesFromDiff(diffStr string) []attestationHunkRange {
 // recordAndComputeCoverage is a convenience function that opens the DB,
 // records the session, computes coverage, and returns the result.
 // It is the main entry point for all review actions (reviewed/skipped/vouched).
-func recordAndComputeCoverage(action string, parsedFiles []diffReviewFileResult, reviewID string, verbose bool) (coverageResult, error) {
+// The author and lrc version are filled into meta here.
+func recordAndComputeCoverage(action string, parsedFiles []diffReviewFileResult, reviewID string, meta sessionMetadata, verbose bool) (coverageResult, error) {
 	db, err := openReviewDB()
 	if err != nil {
 		if verbose {
@@ -473,9 +713,13 @@ func recordAndComputeCoverage(action string, parsedFiles []diffReviewFileResult,
 
 	branch := currentBranch()
 	entries := filesToEntries(parsedFiles)
+	patchID, err := stagedPatchID()
+	if err != nil && verbose {
+		fmt.Printf("Warning: could not compute patch ID: %v\n", err)
+	}
 
 	// Compute coverage BEFORE inserting current session
-	cov, err := computePriorCoverage(db, branch, treeHash, entries)
+	cov, err := computePriorCoverage(db, branch, treeHash, patchID, entries)
 	if err != nil {
 		if verbose {
 			fmt.Printf("Warning: coverage computation failed: %v\n", err)
@@ -488,7 +732,9 @@ func recordAndComputeCoverage(action string, parsedFiles []diffReviewFileResult,
 	// (not including the current one)
 
 	// Insert the current session
-	if err := insertReviewSession(db, treeHash, branch, action, entries, reviewID); err != nil {
+	meta.Author = gitAuthor()
+	meta.LrcVersion = version
+	if err := insertReviewSession(db, treeHash, branch, action, entries, reviewID, patchID, meta); err != nil {
 		if verbose {
 			fmt.Printf("Warning: failed to record review session: %v\n", err)
 		}
@@ -497,7 +743,33 @@ func recordAndComputeCoverage(action string, parsedFiles []diffReviewFileResult,
 	return cov, nil
 }
 
-// runReviewDBCleanup deletes all review sessions for the current branch.
+// findSessionFiles returns the diff files and tree of the latest session recorded for
+// reviewID. Chunked reviews store their IDs comma-separated, so any chunk ID matches.
+func findSessionFiles(db *sql.DB, reviewID string) ([]attestationFileEntry, string, error) {
+	var diffFiles, treeHash string
+	err := db.QueryRow(
+		`SELECT COALESCE(diff_files, ''), tree_hash FROM review_sessions
+		 WHERE instr(',' || review_id || ',', ',' || ? || ',') > 0
+		 ORDER BY id DESC LIMIT 1`, reviewID,
+	).Scan(&diffFiles, &treeHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var entries []attestationFileEntry
+	if diffFiles != "" {
+		if err := json.Unmarshal([]byte(diffFiles), &entries); err != nil {
+			return nil, "", fmt.Errorf("failed to parse stored diff files: %w", err)
+		}
+	}
+	return entries, treeHash, nil
+}
+
+// runReviewDBCleanup archives the open review sessions of the current branch
+// under HEAD, then applies the retention policy to archived sessions.
 // Called from the post-commit hook via "lrc review-cleanup".
 func runReviewDBCleanup(verbose bool) error {
 	db, err := openReviewDB()
@@ -509,13 +781,33 @@ func runReviewDBCleanup(verbose bool) error {
 	}
 	defer db.Close()
 
+	out, err := runGitCommand("git", "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to resolve the new commit: %w", err)
+	}
+	commit := strings.TrimSpace(string(out))
 	branch := currentBranch()
-	affected, err := cleanupReviewSessions(db, branch)
+	affected, err := archiveReviewSessions(db, branch, commit)
 	if err != nil {
-		return fmt.Errorf("failed to clean up review sessions: %w", err)
+		return fmt.Errorf("failed to archive review sessions: %w", err)
 	}
 	if verbose && affected > 0 {
-		fmt.Printf("lrc: cleaned up %d review session(s) for branch %s\n", affected, branch)
+		fmt.Printf("lrc: archived %d review session(s) for branch %s under %s\n", affected, branch, shortHash(commit))
+	}
+
+	// Retention is best-effort: a bad setting must not fail the commit's hook
+	cfg, err := loadLayeredConfig(verbose)
+	if err == nil {
+		var policy retentionPolicy
+		if policy, err = loadRetentionPolicy(cfg, time.Now()); err == nil {
+			var pruned prunedCounts
+			if pruned, err = pruneReviewDB(db, policy, false); err == nil && verbose && (pruned.Sessions > 0 || pruned.Reviews > 0) {
+				fmt.Printf("lrc: pruned %d archived review session(s) and %d stored review(s)\n", pruned.Sessions, pruned.Reviews)
+			}
+		}
+	}
+	if err != nil && verbose {
+		fmt.Printf("Warning: review DB retention skipped: %v\n", err)
 	}
 	return nil
 }
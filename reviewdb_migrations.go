@@ -0,0 +1,117 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// reviewDBMigration upgrades the review DB from one schema version to the next.
+type reviewDBMigration struct {
+	Description string
+	Apply       func(tx *sql.Tx) error
+}
+
+// reviewDBMigrations take the review DB forward one version at a time: entry i
+// upgrades a DB at version i (PRAGMA user_version) to version i+1. Append new
+// migrations at the end and never change one that has been released, since
+// existing DBs already recorded it as applied.
+var reviewDBMigrations = []reviewDBMigration{
+	{
+		// DBs from before versioning are at version 0 with any earlier schema: created
+		// empty, without review_results, or without the patch_id column
+		Description: "review sessions and results",
+		Apply: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(reviewDBSchema); err != nil {
+				return err
+			}
+			if err := ensureColumn(tx, "review_sessions", "patch_id", "TEXT"); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_review_sessions_patch ON review_sessions(patch_id)`)
+			return err
+		},
+	},
+	{
+		Description: "session author, duration, comment count, API URL and lrc version",
+		Apply: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+ALTER TABLE review_sessions ADD COLUMN author TEXT;
+ALTER TABLE review_sessions ADD COLUMN duration_ms INTEGER;
+ALTER TABLE review_sessions ADD COLUMN comment_count INTEGER;
+ALTER TABLE review_sessions ADD COLUMN api_url TEXT;
+ALTER TABLE review_sessions ADD COLUMN lrc_version TEXT;
+`)
+			return err
+		},
+	},
+	{
+		Description: "archive sessions under the commit they led to",
+		Apply: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`
+ALTER TABLE review_sessions ADD COLUMN commit_sha TEXT;
+CREATE INDEX idx_review_sessions_commit ON review_sessions(commit_sha);
+`)
+			return err
+		},
+	},
+}
+
+// reviewDBVersion is the schema version this lrc writes.
+func reviewDBVersion() int {
+	return len(reviewDBMigrations)
+}
+
+// schemaVersion reads PRAGMA user_version.
+func schemaVersion(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}) (int, error) {
+	var version int
+	err := q.QueryRow(`PRAGMA user_version`).Scan(&version)
+	return version, err
+}
+
+// migrateReviewDB brings db up to reviewDBVersion, applying each pending migration
+// in its own transaction together with the version bump. It refuses DBs written by
+// a newer lrc, whose schema this one does not know.
+func migrateReviewDB(db *sql.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("failed to read review database version: %w", err)
+	}
+	if version > reviewDBVersion() {
+		return fmt.Errorf("review database has schema version %d, but this lrc only knows up to version %d; upgrade lrc (lrc self-update) to use it", version, reviewDBVersion())
+	}
+
+	for ; version < reviewDBVersion(); version++ {
+		if err := applyReviewDBMigration(db, version); err != nil {
+			return fmt.Errorf("failed to migrate review database to version %d (%s): %w",
+				version+1, reviewDBMigrations[version].Description, err)
+		}
+	}
+	return nil
+}
+
+func applyReviewDBMigration(db *sql.DB, from int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Another lrc process may have migrated the DB since it was checked
+	current, err := schemaVersion(tx)
+	if err != nil {
+		return err
+	}
+	if current > from {
+		return nil
+	}
+	if err := reviewDBMigrations[from].Apply(tx); err != nil {
+		return err
+	}
+	// PRAGMA takes no bound parameters; the version is an int
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, from+1)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateFixtureDBs(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		sessions int
+		patchID  string // of the first session
+		results  bool
+	}{
+		{"unversioned-initial.sql", 2, "", false},
+		{"unversioned-patch-id.sql", 2, "3333333333333333333333333333333333333333", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			initFixtureRepo(t, tt.fixture)
+
+			db, err := openReviewDB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if v, _ := schemaVersion(db); v != reviewDBVersion() {
+				t.Errorf("user_version = %d, want %d", v, reviewDBVersion())
+			}
+
+			sessions, err := getPriorReviewedSessions(db, "main")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n, _ := countIterations(db, "main"); n != tt.sessions || len(sessions) != 1 {
+				t.Fatalf("%d session(s), %d reviewed, want %d and 1", n, len(sessions), tt.sessions)
+			}
+			s := sessions[0]
+			if s.ReviewID != "rev-1" || s.PatchID != tt.patchID || !strings.Contains(s.DiffFiles, `"a.go"`) {
+				t.Errorf("migrated session = %+v", s)
+			}
+			if s.sessionMetadata != noReviewMetadata() {
+				t.Errorf("old session metadata = %+v, want none", s.sessionMetadata)
+			}
+			rec, err := findStoredReview(db, "rev-1")
+			if err != nil || (rec != nil) != tt.results {
+				t.Errorf("findStoredReview = %+v, %v", rec, err)
+			}
+
+			// The migrated DB takes sessions with metadata
+			meta := sessionMetadata{Author: "A <a@example.com>", Duration: 1500 * time.Millisecond, CommentCount: 3, APIURL: "https://lr.example.com", LrcVersion: "v9"}
+			if err := insertReviewSession(db, "tree3", "main", "reviewed", nil, "rev-3", "", meta); err != nil {
+				t.Fatal(err)
+			}
+			sessions, _ = getPriorReviewedSessions(db, "main")
+			if got := sessions[len(sessions)-1].sessionMetadata; got != meta {
+				t.Errorf("session metadata = %+v, want %+v", got, meta)
+			}
+
+			// Opening again finds nothing to migrate
+			db.Close()
+			reopened, err := openReviewDB()
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			reopened.Close()
+		})
+	}
+}
+
+func TestReviewDBFromNewerLrc(t *testing.T) {
+	initTestRepo(t)
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 999`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if db, err := openReviewDB(); err == nil {
+		db.Close()
+		t.Fatal("opened a review DB with a newer schema version")
+	} else if !strings.Contains(err.Error(), "schema version 999") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+// initFixtureRepo creates a test repo whose review DB is loaded from a SQL dump
+// in testdata/reviewdb.
+func initFixtureRepo(t *testing.T, name string) {
+	t.Helper()
+	dump, err := os.ReadFile(filepath.Join("testdata", "reviewdb", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	initTestRepo(t)
+	path, err := reviewDBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(dump)); err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+}
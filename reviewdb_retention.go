@@ -0,0 +1,366 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Default retention of archived review sessions and stored reviews.
+const (
+	defaultDBMaxAge      = "180d"
+	defaultDBMaxSessions = 10000
+	defaultDBMaxReviews  = 1000
+)
+
+// retentionPolicy limits how many archived sessions and stored reviews the review
+// DB keeps. Open sessions, which the next commit's coverage is computed from, are
+// never pruned, and neither are the stored reviews they refer to.
+type retentionPolicy struct {
+	Before      time.Time // prune archived sessions and stored reviews older than this; zero keeps all
+	MaxSessions int       // keep at most this many archived sessions, newest first; 0 keeps all
+	MaxReviews  int       // keep at most this many stored reviews, newest first; 0 keeps all
+}
+
+func (p retentionPolicy) limited() bool {
+	return !p.Before.IsZero() || p.MaxSessions > 0 || p.MaxReviews > 0
+}
+
+// loadRetentionPolicy reads db_max_age, db_max_sessions and db_max_reviews. An age
+// of "" or "0" and a count of 0 turn that limit off.
+func loadRetentionPolicy(cfg *layeredConfig, now time.Time) (retentionPolicy, error) {
+	var policy retentionPolicy
+	age, origin := cfg.Effective("db_max_age")
+	if err := policy.setMaxAge(age, now); err != nil {
+		return policy, fmt.Errorf("invalid db_max_age in %s: %w", origin, err)
+	}
+	raw, origin := cfg.Effective("db_max_sessions")
+	if err := policy.setMaxSessions(raw); err != nil {
+		return policy, fmt.Errorf("invalid db_max_sessions in %s: %w", origin, err)
+	}
+	raw, origin = cfg.Effective("db_max_reviews")
+	if err := policy.setMaxReviews(raw); err != nil {
+		return policy, fmt.Errorf("invalid db_max_reviews in %s: %w", origin, err)
+	}
+	return policy, nil
+}
+
+func (p *retentionPolicy) setMaxAge(value string, now time.Time) error {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		p.Before = time.Time{}
+		return nil
+	}
+	before, err := parseHistoryTime(value, now)
+	if err != nil {
+		return err
+	}
+	p.Before = before
+	return nil
+}
+
+func (p *retentionPolicy) setMaxSessions(value string) error {
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d", &n); err != nil || n < 0 {
+		return fmt.Errorf("%q is not a session count", value)
+	}
+	p.MaxSessions = n
+	return nil
+}
+
+func (p *retentionPolicy) setMaxReviews(value string) error {
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d", &n); err != nil || n < 0 {
+		return fmt.Errorf("%q is not a review count", value)
+	}
+	p.MaxReviews = n
+	return nil
+}
+
+// prunedCounts is how many rows a retention pass removed, or would remove.
+type prunedCounts struct {
+	Sessions int64
+	Reviews  int64
+}
+
+// pruneReviewDB applies the policy to archived sessions and stored reviews.
+func pruneReviewDB(db *sql.DB, policy retentionPolicy, dryRun bool) (prunedCounts, error) {
+	var counts prunedCounts
+	var err error
+	if counts.Sessions, err = pruneArchivedSessions(db, policy, dryRun); err != nil {
+		return counts, fmt.Errorf("failed to prune review sessions: %w", err)
+	}
+	if counts.Reviews, err = pruneStoredReviews(db, policy, dryRun); err != nil {
+		return counts, fmt.Errorf("failed to prune stored reviews: %w", err)
+	}
+	return counts, nil
+}
+
+// pruneArchivedSessions deletes the archived sessions the policy does not keep and
+// returns how many there were. With dryRun it only counts them.
+func pruneArchivedSessions(db *sql.DB, policy retentionPolicy, dryRun bool) (int64, error) {
+	if policy.Before.IsZero() && policy.MaxSessions == 0 {
+		return 0, nil
+	}
+	var conds []string
+	var args []interface{}
+	if !policy.Before.IsZero() {
+		conds = append(conds, "timestamp < ?")
+		args = append(args, policy.Before.UTC().Format(time.RFC3339))
+	}
+	if policy.MaxSessions > 0 {
+		conds = append(conds, `id NOT IN (SELECT id FROM review_sessions WHERE commit_sha IS NOT NULL
+			ORDER BY timestamp DESC, id DESC LIMIT ?)`)
+		args = append(args, policy.MaxSessions)
+	}
+	where := "commit_sha IS NOT NULL AND (" + strings.Join(conds, " OR ") + ")"
+
+	if dryRun {
+		var count int64
+		err := db.QueryRow(`SELECT COUNT(*) FROM review_sessions WHERE `+where, args...).Scan(&count)
+		return count, err
+	}
+	result, err := db.Exec(`DELETE FROM review_sessions WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// pruneStoredReviews deletes the stored reviews (results and diffs, by far the
+// largest rows) the policy does not keep and returns how many there were. Reviews
+// of open sessions are kept; a chunked session lists several review IDs.
+func pruneStoredReviews(db *sql.DB, policy retentionPolicy, dryRun bool) (int64, error) {
+	if policy.Before.IsZero() && policy.MaxReviews == 0 {
+		return 0, nil
+	}
+	var conds []string
+	var args []interface{}
+	if !policy.Before.IsZero() {
+		conds = append(conds, "timestamp < ?")
+		args = append(args, policy.Before.UTC().Format(time.RFC3339))
+	}
+	if policy.MaxReviews > 0 {
+		conds = append(conds, `id NOT IN (SELECT id FROM review_results ORDER BY timestamp DESC, id DESC LIMIT ?)`)
+		args = append(args, policy.MaxReviews)
+	}
+	where := `(` + strings.Join(conds, " OR ") + `) AND NOT EXISTS (SELECT 1 FROM review_sessions s
+		WHERE s.commit_sha IS NULL AND ',' || s.review_id || ',' LIKE '%,' || review_results.review_id || ',%')`
+
+	if dryRun {
+		var count int64
+		err := db.QueryRow(`SELECT COUNT(*) FROM review_results WHERE `+where, args...).Scan(&count)
+		return count, err
+	}
+	result, err := db.Exec(`DELETE FROM review_results WHERE `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// reviewDBStats summarizes what the review DB holds, for `lrc db stats`.
+type reviewDBStats struct {
+	Path             string               `json:"path"`
+	SizeBytes        int64                `json:"size_bytes"`
+	SchemaVersion    int                  `json:"schema_version"`
+	OpenSessions     int                  `json:"open_sessions"`
+	ArchivedSessions int                  `json:"archived_sessions"`
+	ArchivedCommits  int                  `json:"archived_commits"`
+	StoredReviews    int                  `json:"stored_reviews"`
+	StoredBytes      int64                `json:"stored_review_bytes"`
+	OldestReview     string               `json:"oldest_review,omitempty"`
+	OldestSession    string               `json:"oldest_session,omitempty"`
+	NewestSession    string               `json:"newest_session,omitempty"`
+	Actions          map[string]int       `json:"actions"`
+	Branches         []branchSessionStats `json:"branches"`
+	MaxAge           string               `json:"max_age"`
+	MaxSessions      string               `json:"max_sessions"`
+	MaxReviews       string               `json:"max_reviews"`
+}
+
+type branchSessionStats struct {
+	Branch   string `json:"branch"`
+	Open     int    `json:"open"`
+	Archived int    `json:"archived"`
+}
+
+func collectReviewDBStats(db *sql.DB) (reviewDBStats, error) {
+	stats := reviewDBStats{Actions: make(map[string]int)}
+	var err error
+	if stats.SchemaVersion, err = schemaVersion(db); err != nil {
+		return stats, err
+	}
+	err = db.QueryRow(`SELECT COUNT(*) FILTER (WHERE commit_sha IS NULL), COUNT(*) FILTER (WHERE commit_sha IS NOT NULL),
+		COUNT(DISTINCT commit_sha), COALESCE(MIN(timestamp), ''), COALESCE(MAX(timestamp), '') FROM review_sessions`).
+		Scan(&stats.OpenSessions, &stats.ArchivedSessions, &stats.ArchivedCommits, &stats.OldestSession, &stats.NewestSession)
+	if err != nil {
+		return stats, err
+	}
+	err = db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(LENGTH(result_json) + COALESCE(LENGTH(diff), 0)), 0),
+		COALESCE(MIN(timestamp), '') FROM review_results`).Scan(&stats.StoredReviews, &stats.StoredBytes, &stats.OldestReview)
+	if err != nil {
+		return stats, err
+	}
+
+	rows, err := db.Query(`SELECT action, COUNT(*) FROM review_sessions GROUP BY action`)
+	if err != nil {
+		return stats, err
+	}
+	for rows.Next() {
+		var action string
+		var n int
+		if err := rows.Scan(&action, &n); err != nil {
+			rows.Close()
+			return stats, err
+		}
+		stats.Actions[action] = n
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT branch, COUNT(*) FILTER (WHERE commit_sha IS NULL), COUNT(*) FILTER (WHERE commit_sha IS NOT NULL)
+		FROM review_sessions GROUP BY branch ORDER BY branch`)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b branchSessionStats
+		if err := rows.Scan(&b.Branch, &b.Open, &b.Archived); err != nil {
+			return stats, err
+		}
+		stats.Branches = append(stats.Branches, b)
+	}
+	return stats, rows.Err()
+}
+
+// runDBStats implements `lrc db stats`.
+func runDBStats(c *cli.Context) error {
+	cfg, err := loadLayeredConfig(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	stats, err := collectReviewDBStats(db)
+	if err != nil {
+		return fmt.Errorf("failed to read review DB: %w", err)
+	}
+	stats.Path, _ = reviewDBPath()
+	if info, err := os.Stat(stats.Path); err == nil {
+		stats.SizeBytes = info.Size()
+	}
+	stats.MaxAge, _ = cfg.Effective("db_max_age")
+	stats.MaxSessions, _ = cfg.Effective("db_max_sessions")
+	stats.MaxReviews, _ = cfg.Effective("db_max_reviews")
+
+	if c.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	fmt.Printf("Review DB:          %s (%.1f KiB, schema version %d)\n", stats.Path, float64(stats.SizeBytes)/1024, stats.SchemaVersion)
+	fmt.Printf("Open sessions:      %d\n", stats.OpenSessions)
+	fmt.Printf("Archived sessions:  %d across %d commit(s)\n", stats.ArchivedSessions, stats.ArchivedCommits)
+	fmt.Printf("Stored reviews:     %d (%.1f KiB of results and diffs)\n", stats.StoredReviews, float64(stats.StoredBytes)/1024)
+	if stats.OldestReview != "" {
+		fmt.Printf("Oldest review:      %s\n", stats.OldestReview)
+	}
+	if stats.OldestSession != "" {
+		fmt.Printf("Sessions recorded:  %s to %s\n", stats.OldestSession, stats.NewestSession)
+	}
+	if len(stats.Actions) > 0 {
+		var parts []string
+		for _, action := range []string{"reviewed", "vouched", "skipped"} {
+			if n := stats.Actions[action]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n, action))
+			}
+		}
+		fmt.Printf("Actions:            %s\n", strings.Join(parts, ", "))
+	}
+	maxAge, maxSessions, maxReviews := stats.MaxAge, stats.MaxSessions, stats.MaxReviews
+	if maxAge == "" || maxAge == "0" {
+		maxAge = "no limit"
+	}
+	if maxSessions == "0" {
+		maxSessions = "no limit"
+	}
+	if maxReviews == "0" {
+		maxReviews = "no limit"
+	}
+	fmt.Printf("Retention:          archived sessions up to %s old, at most %s\n", maxAge, maxSessions)
+	fmt.Printf("                    stored reviews up to %s old, at most %s\n", maxAge, maxReviews)
+
+	if len(stats.Branches) > 0 {
+		fmt.Printf("\n%-40s  %6s  %8s\n", "BRANCH", "OPEN", "ARCHIVED")
+		for _, b := range stats.Branches {
+			fmt.Printf("%-40s  %6d  %8d\n", truncateField(b.Branch, 40), b.Open, b.Archived)
+		}
+	}
+	return nil
+}
+
+// runDBPrune implements `lrc db prune`: it applies the retention policy now, with
+// --max-age, --max-sessions and --max-reviews overriding the configured limits.
+func runDBPrune(c *cli.Context) error {
+	cfg, err := loadLayeredConfig(c.Bool("verbose"))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	policy, err := loadRetentionPolicy(cfg, now)
+	if err == nil && c.IsSet("max-age") {
+		if err = policy.setMaxAge(c.String("max-age"), now); err != nil {
+			err = fmt.Errorf("invalid --max-age: %w", err)
+		}
+	}
+	if err == nil && c.IsSet("max-sessions") {
+		if err = policy.setMaxSessions(fmt.Sprint(c.Int("max-sessions"))); err != nil {
+			err = fmt.Errorf("invalid --max-sessions: %w", err)
+		}
+	}
+	if err == nil && c.IsSet("max-reviews") {
+		if err = policy.setMaxReviews(fmt.Sprint(c.Int("max-reviews"))); err != nil {
+			err = fmt.Errorf("invalid --max-reviews: %w", err)
+		}
+	}
+	if err != nil {
+		return reviewExitError(&invalidInputError{err: err})
+	}
+	if !policy.limited() {
+		fmt.Println("No retention limit is set; nothing to prune.")
+		return nil
+	}
+
+	db, err := openReviewDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	dryRun := c.Bool("dry-run")
+	n, err := pruneReviewDB(db, policy, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Printf("Would prune %d archived review session(s) and %d stored review(s).\n", n.Sessions, n.Reviews)
+		return nil
+	}
+	if n.Sessions > 0 || n.Reviews > 0 {
+		// Give the freed pages back to the file system
+		if _, err := db.Exec(`VACUUM`); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not compact the review DB: %v\n", err)
+		}
+	}
+	fmt.Printf("Pruned %d archived review session(s) and %d stored review(s).\n", n.Sessions, n.Reviews)
+	return nil
+}
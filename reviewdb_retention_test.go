@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchivedSessionsLeaveCoverage(t *testing.T) {
+	initTestRepo(t)
+	db := stageAndRecordReview(t, map[string]string{"a.go": "package a\n"})
+	defer db.Close()
+	tree, entries := stageFiles(t, map[string]string{"a.go": "package a\n"})
+
+	if n, err := archiveReviewSessions(db, "main", "c0ffee"); err != nil || n != 1 {
+		t.Fatalf("archiveReviewSessions = %d, %v", n, err)
+	}
+	// The next change on the branch starts over
+	cov, err := computePriorCoverage(db, "main", tree, "", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cov.Iterations != 1 || cov.PriorReviewCount != 0 || cov.CoveredLines != 0 {
+		t.Errorf("coverage after commit = %+v, want a first iteration with no prior reviews", cov)
+	}
+	if n, _ := archiveReviewSessions(db, "main", "beef"); n != 0 {
+		t.Errorf("archived sessions were archived again under another commit")
+	}
+
+	stats, err := collectReviewDBStats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.OpenSessions != 0 || stats.ArchivedSessions != 1 || stats.ArchivedCommits != 1 || stats.Actions["reviewed"] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestPruneArchivedSessions(t *testing.T) {
+	initTestRepo(t)
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Four archived sessions a day apart, oldest first, and one open session
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		ts := now.AddDate(0, 0, i-5).UTC().Format(time.RFC3339)
+		var commit interface{} = "c" + string(rune('0'+i))
+		if i == 4 {
+			commit = nil
+		}
+		if _, err := db.Exec(`INSERT INTO review_sessions (tree_hash, branch, action, timestamp, commit_sha) VALUES ('t', 'main', 'reviewed', ?, ?)`, ts, commit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy retentionPolicy
+		pruned int64
+	}{
+		{"no limits", retentionPolicy{}, 0},
+		{"max sessions", retentionPolicy{MaxSessions: 3}, 1},
+		{"max age", retentionPolicy{Before: now.AddDate(0, 0, -3)}, 2},
+		{"either limit", retentionPolicy{Before: now.AddDate(0, 0, -4), MaxSessions: 2}, 2},
+	}
+	for _, tt := range tests {
+		if n, err := pruneArchivedSessions(db, tt.policy, true); err != nil || n != tt.pruned {
+			t.Errorf("%s: dry run would prune %d (%v), want %d", tt.name, n, err, tt.pruned)
+		}
+	}
+
+	if n, err := pruneArchivedSessions(db, retentionPolicy{MaxSessions: 1}, false); err != nil || n != 3 {
+		t.Fatalf("prune to 1 archived session = %d, %v", n, err)
+	}
+	var open, archived int
+	db.QueryRow(`SELECT COUNT(*) FILTER (WHERE commit_sha IS NULL), COUNT(*) FILTER (WHERE commit_sha = 'c3') FROM review_sessions`).Scan(&open, &archived)
+	if open != 1 || archived != 1 {
+		t.Errorf("after prune: %d open, newest archived kept = %v", open, archived == 1)
+	}
+}
+
+func TestPruneStoredReviews(t *testing.T) {
+	initTestRepo(t)
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Five stored reviews a day apart, oldest first; the oldest is a chunk of the open session
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		ts := now.AddDate(0, 0, i-5).UTC().Format(time.RFC3339)
+		if _, err := db.Exec(`INSERT INTO review_results (review_id, branch, status, result_json, diff, timestamp) VALUES (?, 'main', 'completed', '{}', 'diff', ?)`, "r"+string(rune('0'+i)), ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO review_sessions (tree_hash, branch, action, timestamp, review_id) VALUES ('t', 'main', 'reviewed', ?, 'r0,r4')`, now.UTC().Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		policy retentionPolicy
+		pruned int64
+	}{
+		{"no limits", retentionPolicy{}, 0},
+		{"session limit only", retentionPolicy{MaxSessions: 1}, 0},
+		{"max reviews", retentionPolicy{MaxReviews: 2}, 2},
+		{"max age", retentionPolicy{Before: now.AddDate(0, 0, -3)}, 1},
+	}
+	for _, tt := range tests {
+		if n, err := pruneStoredReviews(db, tt.policy, true); err != nil || n != tt.pruned {
+			t.Errorf("%s: dry run would prune %d (%v), want %d", tt.name, n, err, tt.pruned)
+		}
+	}
+
+	counts, err := pruneReviewDB(db, retentionPolicy{MaxReviews: 1}, false)
+	if err != nil || counts.Reviews != 3 || counts.Sessions != 0 {
+		t.Fatalf("prune to 1 stored review = %+v, %v", counts, err)
+	}
+	stats, err := collectReviewDBStats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.StoredReviews != 2 || stats.StoredBytes != int64(2*len("{}diff")) {
+		t.Errorf("after prune: %d stored reviews, %d bytes; want the open session's two", stats.StoredReviews, stats.StoredBytes)
+	}
+}
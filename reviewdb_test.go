@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoverageFollowsMovedLines(t *testing.T) {
+	reviewed := map[string]string{"a.go": "func a() {\n\tx := 1\n\ty := 2\n\treturn\n}\n"}
+
+	tests := []struct {
+		name           string
+		current        map[string]string
+		covered, total int
+	}{
+		{"unchanged", reviewed, 5, 5},
+		{"insertion above", map[string]string{"a.go": "// a does things\n// twice\nfunc a() {\n\tx := 1\n\ty := 2\n\treturn\n}\n"}, 5, 7},
+		{"insertion inside", map[string]string{"a.go": "func a() {\n\tx := 1\n\tz := 3\n\ty := 2\n\treturn\n}\n"}, 5, 6},
+		{"deletion", map[string]string{"a.go": "func a() {\n\tx := 1\n\treturn\n}\n"}, 4, 4},
+		{"edited line", map[string]string{"a.go": "func a() {\n\tx := 10\n\ty := 2\n\treturn\n}\n"}, 4, 5},
+		{"reindented", map[string]string{"a.go": "func a() {\n    x := 1\n    y := 2\n    return\n}\n"}, 5, 5},
+		{"duplicated line counts once", map[string]string{"a.go": "func a() {\n\tx := 1\n\tx := 1\n\ty := 2\n\treturn\n}\n"}, 5, 6},
+		{"renamed", map[string]string{"b.go": reviewed["a.go"]}, 5, 5},
+		{"renamed and edited", map[string]string{"b.go": "func b() {\n\tx := 1\n\ty := 2\n\treturn\n}\n"}, 4, 5},
+		{"new file", map[string]string{"a.go": reviewed["a.go"], "c.go": "package c\n"}, 5, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initTestRepo(t)
+			db := stageAndRecordReview(t, reviewed)
+			defer db.Close()
+
+			tree, entries := stageFiles(t, tt.current)
+			cov, err := computePriorCoverage(db, "main", tree, "", entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cov.CoveredLines != tt.covered || cov.TotalLines != tt.total {
+				t.Errorf("coverage = %d/%d lines, want %d/%d", cov.CoveredLines, cov.TotalLines, tt.covered, tt.total)
+			}
+		})
+	}
+}
+
+func TestAddedLineHashes(t *testing.T) {
+	hunks := []diffReviewHunk{{NewStartLine: 4, Content: "@@ -4,3 +4,3 @@\n context\n-old\n+new\n+  spaced   out\n\\ No newline at end of file"}}
+	hashes := addedLineHashes(hunks)
+	if len(hashes) != 2 || hashes[5] != normalizedLineHash("new") || hashes[6] != normalizedLineHash("spaced out") {
+		t.Errorf("addedLineHashes = %v", hashes)
+	}
+}
+
+func TestWorktreesShareReviewDB(t *testing.T) {
+	initTestRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	db := stageAndRecordReview(t, map[string]string{"a.go": "package a\n"})
+	db.Close()
+	mainDB, _ := reviewDBPath()
+	if _, err := writeAttestationFullForCurrentTree(attestationPayload{Action: "reviewed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	wt := filepath.Join(t.TempDir(), "wt")
+	runGit(t, "worktree", "add", "-q", wt)
+	t.Chdir(wt)
+	gitDir, _ := resolveGitDir()
+	commonDir, _ := resolveGitCommonDir()
+	if gitDir == commonDir {
+		t.Fatalf("linked worktree git dir %s should differ from the common dir", gitDir)
+	}
+	if wtDB, _ := reviewDBPath(); wtDB != mainDB {
+		t.Errorf("worktree review DB = %s, want the shared %s", wtDB, mainDB)
+	}
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if n, err := countIterations(db, "main"); err != nil || n != 1 {
+		t.Errorf("worktree sees %d session(s) of the main checkout (%v), want 1", n, err)
+	}
+
+	// The same staged tree has no attestation here: each worktree attests its own commits
+	os.WriteFile("a.go", []byte("package a\n"), 0644)
+	runGit(t, "add", "a.go")
+	if payload, err := readCurrentAttestation(); err != nil || payload != nil {
+		t.Errorf("worktree attestation = %+v, %v, want none", payload, err)
+	}
+}
+
+// stageAndRecordReview stages files in a repo with one empty commit and records a
+// reviewed session for them on branch main.
+func stageAndRecordReview(t *testing.T, files map[string]string) *sql.DB {
+	t.Helper()
+	runGit(t, "commit", "-q", "--allow-empty", "-m", "base")
+	tree, entries := stageFiles(t, files)
+	db, err := openReviewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := insertReviewSession(db, tree, "main", "reviewed", entries, "r1", "", sessionMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// stageFiles replaces the staged files with files and returns the staged tree and
+// its diff entries.
+func stageFiles(t *testing.T, files map[string]string) (string, []attestationFileEntry) {
+	t.Helper()
+	runGit(t, "rm", "-q", "-r", "--cached", "--ignore-unmatch", ".")
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, "add", name)
+	}
+	diff, err := runGitCommand("git", "diff", "--staged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseDiffToFiles(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runGit(t, "write-tree"), filesToEntries(parsed)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion   = "2.1.0"
+	sarifSchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI   = "https://github.com/HexmosTech/git-lrc"
+	// sarifDefaultRuleID is used for comments that have no category.
+	sarifDefaultRuleID = "general"
+)
+
+// The types below cover the subset of SARIF 2.1.0 that lrc emits.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevel maps a comment severity onto a SARIF result level.
+func sarifLevel(severity string) string {
+	switch normalizeSeverity(severity) {
+	case "critical", "error":
+		return "error"
+	case "warning":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifRuleID derives a rule id from a comment category ("Best Practices" -> "best-practices").
+func sarifRuleID(category string) string {
+	id := strings.Join(strings.Fields(strings.ToLower(category)), "-")
+	if id == "" {
+		return sarifDefaultRuleID
+	}
+	return id
+}
+
+// sarifURI turns a repo-relative path into a URI reference relative to %SRCROOT%.
+func sarifURI(filePath string) string {
+	return (&url.URL{Path: strings.TrimPrefix(filePath, "/")}).String()
+}
+
+// buildSARIFLog converts a review result into a SARIF log with one run.
+// Rules are sorted by id so the output is stable across runs.
+func buildSARIFLog(result *diffReviewResponse, toolVersion string) *sarifLog {
+	ruleSet := make(map[string]bool)
+	for _, file := range result.Files {
+		for _, comment := range file.Comments {
+			ruleSet[sarifRuleID(comment.Category)] = true
+		}
+	}
+	ruleIDs := make([]string, 0, len(ruleSet))
+	for id := range ruleSet {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+
+	rules := make([]sarifRule, len(ruleIDs))
+	ruleIndex := make(map[string]int, len(ruleIDs))
+	for i, id := range ruleIDs {
+		rules[i] = sarifRule{ID: id, Name: id, ShortDescription: sarifMessage{Text: "LiveReview " + id + " finding"}}
+		ruleIndex[id] = i
+	}
+
+	results := []sarifResult{}
+	for _, file := range result.Files {
+		for _, comment := range file.Comments {
+			ruleID := sarifRuleID(comment.Category)
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(file.FilePath), URIBaseID: "%SRCROOT%"},
+			}
+			// SARIF lines are 1-based; file-level comments carry no region
+			if comment.Line > 0 {
+				location.Region = &sarifRegion{StartLine: comment.Line}
+			}
+			results = append(results, sarifResult{
+				RuleID:    ruleID,
+				RuleIndex: ruleIndex[ruleID],
+				Level:     sarifLevel(comment.Severity),
+				Message:   sarifMessage{Text: comment.Content},
+				Locations: []sarifLocation{{PhysicalLocation: location}},
+			})
+		}
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "lrc",
+			InformationURI: sarifToolURI,
+			Version:        toolVersion,
+			Rules:          rules,
+		}},
+		Results: results,
+	}
+	if summary := strings.TrimSpace(result.Summary); summary != "" {
+		run.Properties = map[string]string{"summary": summary}
+	}
+
+	return &sarifLog{Schema: sarifSchemaURI, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// writeSARIF encodes the review result as indented SARIF JSON.
+func writeSARIF(w io.Writer, result *diffReviewResponse) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(buildSARIFLog(result, version))
+}
+
+// saveSARIFOutput saves the review result as a SARIF 2.1.0 log
+func saveSARIFOutput(path string, result *diffReviewResponse, verbose bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeSARIF(f, result); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write SARIF: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if verbose {
+		log.Printf("SARIF output saved to: %s", path)
+	}
+
+	return nil
+}
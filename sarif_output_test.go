@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata/ with the current output")
+
+// compareGolden checks got against testdata/<name>, rewriting it when -update is set.
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	goldenPath := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0755); err != nil {
+			t.Fatalf("Failed to create golden dir: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Failed to read golden file %s (run go test -run %s -update to create it): %v", goldenPath, t.Name(), err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Output does not match %s (run go test -run %s -update to accept):\n--- got ---\n%s\n--- want ---\n%s", goldenPath, t.Name(), got, want)
+	}
+}
+
+// TestSARIFOutputGolden checks the SARIF log against golden files
+func TestSARIFOutputGolden(t *testing.T) {
+	// Pin the tool version so golden files survive version bumps
+	savedVersion := version
+	version = "test"
+	defer func() { version = savedVersion }()
+
+	tests := []struct {
+		name   string
+		result *diffReviewResponse
+	}{
+		{
+			name: "comments",
+			result: &diffReviewResponse{
+				Status:  "completed",
+				Summary: "# Test Summary\n\nTwo files need attention.",
+				Files: []diffReviewFileResult{
+					{
+						FilePath: "test/file.go",
+						Comments: []diffReviewComment{
+							{
+								Line:     11,
+								Content:  "This is a test comment with\nmultiple lines",
+								Severity: "warning",
+								Category: "style",
+							},
+							{
+								Line:     12,
+								Content:  "Another comment",
+								Severity: "critical",
+								Category: "Best Practices",
+							},
+						},
+					},
+					{
+						FilePath: "docs/read me.md",
+						Comments: []diffReviewComment{
+							{
+								Line:     0,
+								Content:  "File-level note without a category",
+								Severity: "",
+							},
+							{
+								Line:     2,
+								Content:  "Possible nil dereference",
+								Severity: "error",
+								Category: "bug",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "no-comments",
+			result: &diffReviewResponse{
+				Status: "completed",
+				Files: []diffReviewFileResult{
+					{FilePath: "test.go", Comments: []diffReviewComment{}},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputPath := filepath.Join(tmpDir, tt.name+".sarif")
+			if err := saveSARIFOutput(outputPath, tt.result, false); err != nil {
+				t.Fatalf("Failed to generate SARIF: %v", err)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Failed to read generated SARIF: %v", err)
+			}
+
+			var parsed sarifLog
+			if err := json.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("Generated SARIF is not valid JSON: %v", err)
+			}
+			if parsed.Version != sarifVersion || len(parsed.Runs) != 1 {
+				t.Errorf("Unexpected SARIF envelope: version=%q runs=%d", parsed.Version, len(parsed.Runs))
+			}
+
+			compareGolden(t, filepath.Join("sarif", tt.name+".sarif"), content)
+		})
+	}
+}
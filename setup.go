@@ -2,11 +2,10 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -470,41 +469,19 @@ func provisionLiveReviewUser(cbData *hexmosCallbackData, slog *setupLog) (*setup
 		Source:    "git-lrc",
 	}
 
-	bodyJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", cloudAPIURL+"/api/v1/auth/ensure-cloud-user", bytes.NewReader(bodyJSON))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+cbData.Result.JWT)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	var ensureResp ensureCloudUserResponse
+	client := newBearerAPIClient(cloudAPIURL, cbData.Result.JWT, false)
+	if err := client.postJSON(context.Background(), "/api/v1/auth/ensure-cloud-user", reqBody, &ensureResp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			slog.write("ensure-cloud-user failed: status=%d body=%s", apiErr.StatusCode, apiErr.Body)
+			return nil, fmt.Errorf("ensure-cloud-user failed: %w", err)
+		}
+		slog.write("ensure-cloud-user error: %v", err)
 		return nil, fmt.Errorf("failed to contact LiveReview API: %w", err)
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read ensure-cloud-user response: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		slog.write("ensure-cloud-user failed: status=%d body=%s", resp.StatusCode, string(respBody))
-		return nil, fmt.Errorf("ensure-cloud-user returned %d: %s", resp.StatusCode, string(respBody))
-	}
 
-	slog.write("ensure-cloud-user: status=%d", resp.StatusCode)
-
-	var ensureResp ensureCloudUserResponse
-	if err := json.Unmarshal(respBody, &ensureResp); err != nil {
-		slog.write("ensure-cloud-user parse error: %v  body=%s", err, string(respBody))
-		return nil, fmt.Errorf("failed to parse ensure-cloud-user response: %w", err)
-	}
+	slog.write("ensure-cloud-user: ok")
 
 	result := &setupResult{
 		Email:        ensureResp.Email,
@@ -527,41 +504,20 @@ func provisionLiveReviewUser(cbData *hexmosCallbackData, slog *setupLog) (*setup
 
 	// Step 2: create API key
 	apiKeyReq := createAPIKeyRequest{Label: "LRC CLI Key"}
-	apiKeyJSON, err := json.Marshal(apiKeyReq)
-	if err != nil {
-		return nil, err
-	}
-
-	apiKeyURL := fmt.Sprintf("%s/api/v1/orgs/%s/api-keys", cloudAPIURL, result.OrgID)
-	slog.write("creating API key: POST %s", apiKeyURL)
-	req2, err := http.NewRequest("POST", apiKeyURL, bytes.NewReader(apiKeyJSON))
-	if err != nil {
-		return nil, err
-	}
-	req2.Header.Set("Content-Type", "application/json")
-	req2.Header.Set("Authorization", "Bearer "+result.AccessToken)
+	apiKeyPath := fmt.Sprintf("/api/v1/orgs/%s/api-keys", result.OrgID)
+	slog.write("creating API key: POST %s%s", cloudAPIURL, apiKeyPath)
 
-	resp2, err := client.Do(req2)
-	if err != nil {
+	var apiKeyResp createAPIKeyResponse
+	client = newBearerAPIClient(cloudAPIURL, result.AccessToken, false)
+	if err := client.postJSON(context.Background(), apiKeyPath, apiKeyReq, &apiKeyResp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			slog.write("create API key failed: status=%d body=%s", apiErr.StatusCode, apiErr.Body)
+		}
 		return nil, fmt.Errorf("failed to create API key: %w", err)
 	}
-	defer resp2.Body.Close()
-
-	respBody2, err := io.ReadAll(resp2.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read API key response: %w", err)
-	}
-	if resp2.StatusCode != http.StatusCreated && resp2.StatusCode != http.StatusOK {
-		slog.write("create API key failed: status=%d body=%s", resp2.StatusCode, string(respBody2))
-		return nil, fmt.Errorf("create API key returned %d: %s", resp2.StatusCode, string(respBody2))
-	}
 
-	slog.write("API key created: status=%d", resp2.StatusCode)
-
-	var apiKeyResp createAPIKeyResponse
-	if err := json.Unmarshal(respBody2, &apiKeyResp); err != nil {
-		return nil, fmt.Errorf("failed to parse API key response: %w", err)
-	}
+	slog.write("API key created")
 
 	result.PlainAPIKey = apiKeyResp.PlainKey
 	return result, nil
@@ -622,38 +578,9 @@ func validateGeminiKey(result *setupResult, geminiKey string) (bool, string, err
 		Model:    defaultGeminiModel,
 	}
 
-	bodyJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return false, "", err
-	}
-
-	req, err := http.NewRequest("POST", cloudAPIURL+"/api/v1/aiconnectors/validate-key",
-		bytes.NewReader(bodyJSON))
-	if err != nil {
-		return false, "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+result.AccessToken)
-	req.Header.Set("X-Org-Context", result.OrgID)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, "", fmt.Errorf("failed to validate key: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, "", fmt.Errorf("failed to read validation response: %w", err)
-	}
-	if resp.StatusCode != http.StatusOK {
-		return false, "", fmt.Errorf("validate-key returned %d: %s", resp.StatusCode, string(body))
-	}
-
 	var valResp validateKeyResponse
-	if err := json.Unmarshal(body, &valResp); err != nil {
-		return false, "", fmt.Errorf("failed to parse validation response: %w", err)
+	if err := orgAPIClient(result).postJSON(context.Background(), "/api/v1/aiconnectors/validate-key", reqBody, &valResp); err != nil {
+		return false, "", fmt.Errorf("failed to validate key: %w", err)
 	}
 
 	return valResp.Valid, valResp.Message, nil
@@ -669,38 +596,20 @@ func createGeminiConnector(result *setupResult, geminiKey string) error {
 		DisplayOrder:  0,
 	}
 
-	bodyJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", cloudAPIURL+"/api/v1/aiconnectors",
-		bytes.NewReader(bodyJSON))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+result.AccessToken)
-	req.Header.Set("X-Org-Context", result.OrgID)
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	if err := orgAPIClient(result).postJSON(context.Background(), "/api/v1/aiconnectors", reqBody, nil); err != nil {
 		return fmt.Errorf("failed to create connector: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read connector response: %w", err)
-	}
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("create connector returned %d: %s", resp.StatusCode, string(body))
-	}
 
 	return nil
 }
 
+// orgAPIClient returns a client for org-scoped calls made with the new user's session.
+func orgAPIClient(result *setupResult) *apiClient {
+	client := newBearerAPIClient(cloudAPIURL, result.AccessToken, false)
+	client.header.Set("X-Org-Context", result.OrgID)
+	return client
+}
+
 // writeConfig writes the setup results to ~/.lrc.toml.
 func writeConfig(result *setupResult) error {
 	homeDir, err := os.UserHomeDir()
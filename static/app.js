@@ -25,8 +25,20 @@ function countCommentsFromFiles(files) {
     }, 0);
 }
 
-function convertFilesToUIFormat(files) {
+// Build lookup of added-line coverage by file path (lrc coverage --serve)
+function coverageByFile(coverage) {
+    const byFile = {};
+    ((coverage && coverage.files) || []).forEach(file => {
+        const lines = {};
+        (file.lines || []).forEach(l => { lines[l.line] = l.covered; });
+        byFile[file.file_path] = lines;
+    });
+    return byFile;
+}
+
+function convertFilesToUIFormat(files, coverage) {
     if (!files) return [];
+    const coverageLines = coverageByFile(coverage);
     
     return files.map(file => {
         // Handle snake_case from backend
@@ -35,6 +47,7 @@ function convertFilesToUIFormat(files) {
         const fileId = 'file_' + filePath.replace(/[^a-zA-Z0-9]/g, '_');
         const comments = file.comments || file.Comments || [];
         const hunks = file.hunks || file.Hunks || [];
+        const fileCoverage = coverageLines[filePath];
         
         // Build comment lookup by line
         const commentsByLine = {};
@@ -112,6 +125,9 @@ function convertFilesToUIFormat(files) {
                         IsComment: lineComments.length > 0,
                         Comments: lineComments
                     };
+                    if (fileCoverage) {
+                        lineData.Coverage = fileCoverage[newLine] ? 'covered' : 'uncovered';
+                    }
                     newLine++;
                 } else {
                     lineData = {
@@ -141,6 +157,10 @@ function convertFilesToUIFormat(files) {
     });
 }
 
+function hasUncoveredLines(file) {
+    return file.Hunks.some(hunk => hunk.Lines.some(line => line.Coverage === 'uncovered'));
+}
+
 async function initApp() {
     const { h, render, useState, useEffect, useCallback, useRef, html } = await waitForPreact();
     
@@ -171,6 +191,7 @@ async function initApp() {
         const [events, setEvents] = useState([]);
         const [newEventCount, setNewEventCount] = useState(0);
         const [isTailing, setIsTailing] = useState(false);
+        const [showCoverage, setShowCoverage] = useState(true);
         
         const pollingRef = useRef(null);
         const eventsPollingRef = useRef(null);
@@ -187,7 +208,7 @@ async function initApp() {
                 const data = await response.json();
                 
                 // Convert files to UI format
-                const uiFiles = convertFilesToUIFormat(data.files);
+                const uiFiles = convertFilesToUIFormat(data.files, data.coverage);
                 
                 // Calculate actual comment count from files (don't trust API counter)
                 const actualCommentCount = countCommentsFromFiles(data.files);
@@ -198,9 +219,10 @@ async function initApp() {
                     // On updates: also expand any NEW files that have comments
                     if (!prev) {
                         // First load - expand all files with comments
+                        // (or, in a coverage report, with uncovered lines)
                         const expanded = new Set();
                         uiFiles.forEach(file => {
-                            if (file.HasComments) {
+                            if (file.HasComments || (data.coverage && hasUncoveredLines(file))) {
                                 expanded.add(file.ID);
                             }
                         });
@@ -594,6 +616,7 @@ async function initApp() {
                     <${Stats} 
                         totalFiles=${files.length}
                         totalComments=${totalComments}
+                        coverage=${reviewData?.coverage}
                     />
                     
                     <${PrecommitBar}
@@ -615,6 +638,9 @@ async function initApp() {
                         isTailing=${isTailing}
                         onCopyLogs=${handleCopyLogs}
                         logsCopied=${logsCopied}
+                        hasCoverage=${!!reviewData?.coverage}
+                        showCoverage=${showCoverage}
+                        onToggleCoverage=${() => setShowCoverage(prev => !prev)}
                     />
                     
                     <${SeverityFilter}
@@ -634,6 +660,7 @@ async function initApp() {
                                     expanded=${expandedFiles.has(file.ID)}
                                     onToggle=${toggleFile}
                                     visibleSeverities=${visibleSeverities}
+                                    showCoverage=${showCoverage}
                                 />
                             `)
                             : html`
@@ -6,7 +6,7 @@ export async function createDiffTable() {
     const { html } = await waitForPreact();
     const Comment = await getComment();
     
-    return function DiffTable({ hunks, filePath, fileId, visibleSeverities }) {
+    return function DiffTable({ hunks, filePath, fileId, visibleSeverities, showCoverage }) {
         if (!hunks || hunks.length === 0) {
             return html`
                 <div style="padding: 20px; text-align: center; color: #57606a;">
@@ -29,8 +29,11 @@ export async function createDiffTable() {
                         const prevLine = idx > 0 ? hunk.Lines[idx - 1] : null;
                         const codeExcerpt = prevLine ? prevLine.Content : '';
                         
+                        // Coverage overlay: added lines no prior review covered stand out
+                        const coverageClass = showCoverage && line.Coverage ? `coverage-${line.Coverage}` : '';
+                        
                         return html`
-                            <tr class="diff-line ${line.Class}">
+                            <tr class="diff-line ${line.Class} ${coverageClass}" title=${coverageClass ? (line.Coverage === 'covered' ? 'Covered by a prior review' : 'Not covered by any prior review') : undefined}>
                                 <td class="line-num">${line.OldNum}</td>
                                 <td class="line-num">${line.NewNum}</td>
                                 <td class="line-content">${line.Content}</td>
@@ -6,7 +6,7 @@ export async function createFileBlock() {
     const { html } = await waitForPreact();
     const DiffTable = await getDiffTable();
     
-    return function FileBlock({ file, expanded, onToggle, visibleSeverities }) {
+    return function FileBlock({ file, expanded, onToggle, visibleSeverities, showCoverage }) {
         // Use file.ID if available (set by convertFilesToUIFormat), otherwise generate
         const fileId = file.ID || filePathToId(file.FilePath);
         
@@ -27,7 +27,7 @@ export async function createFileBlock() {
                     `}
                 </div>
                 <div class="file-content">
-                    <${DiffTable} hunks=${file.Hunks} filePath=${file.FilePath} fileId=${fileId} visibleSeverities=${visibleSeverities} />
+                    <${DiffTable} hunks=${file.Hunks} filePath=${file.FilePath} fileId=${fileId} visibleSeverities=${visibleSeverities} showCoverage=${showCoverage} />
                 </div>
             </div>
         `;
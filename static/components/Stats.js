@@ -4,11 +4,14 @@ import { waitForPreact } from './utils.js';
 export async function createStats() {
     const { html } = await waitForPreact();
     
-    return function Stats({ totalFiles, totalComments }) {
+    return function Stats({ totalFiles, totalComments, coverage }) {
         return html`
             <div class="stats">
                 <div class="stat">Files: <span class="count">${totalFiles}</span></div>
                 <div class="stat">Comments: <span class="count">${totalComments}</span></div>
+                ${coverage && html`
+                    <div class="stat">Covered: <span class="count">${coverage.covered_lines}/${coverage.total_lines}</span> (${Math.round(coverage.coverage_pct)}%)</div>
+                `}
             </div>
         `;
     };
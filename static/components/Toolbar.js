@@ -14,7 +14,10 @@ export async function createToolbar() {
         onTailLog,
         isTailing,
         onCopyLogs,
-        logsCopied
+        logsCopied,
+        hasCoverage,
+        showCoverage,
+        onToggleCoverage
     }) {
         return html`
             <div class="toolbar-row">
@@ -55,6 +58,14 @@ export async function createToolbar() {
                             </svg>
                             ${allExpanded ? 'Collapse All' : 'Expand All'}
                         </button>
+                        ${hasCoverage && html`
+                            <button class="action-btn ${showCoverage ? 'active' : ''}" onClick=${onToggleCoverage} title="Highlight added lines no prior review covered">
+                                <svg width="14" height="14" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                                    <path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="M9 12l2 2 4-4m6 2a9 9 0 11-18 0 9 9 0 0118 0z" />
+                                </svg>
+                                Coverage
+                            </button>
+                        `}
                     </div>
                 `}
                 
@@ -0,0 +1,210 @@
+// LiveReview Stats - review habits from the review DB
+// Fetches aggregates from /api/stats; the page's query string (since, until,
+// branch, author, bucket) is passed through as the filter
+
+import { waitForPreact, getBadgeClass, LOGO_DATA_URI } from './components/utils.js';
+
+const SEVERITIES = ['critical', 'error', 'warning', 'info'];
+
+function percent(n, total) {
+    return total ? Math.round(n / total * 100) : 0;
+}
+
+// Rough duration for seconds: 45s, 12m, 3h20m, 2d4h
+function formatDuration(seconds) {
+    if (!seconds) return '-';
+    if (seconds < 60) return `${Math.round(seconds)}s`;
+    if (seconds < 3600) return `${Math.floor(seconds / 60)}m`;
+    if (seconds < 86400) return `${Math.floor(seconds / 3600)}h${String(Math.floor(seconds % 3600 / 60)).padStart(2, '0')}m`;
+    return `${Math.floor(seconds / 86400)}d${Math.floor(seconds % 86400 / 3600)}h`;
+}
+
+function formatBucket(start, bucket) {
+    const d = new Date(start);
+    if (bucket === 'month') return d.toLocaleDateString(undefined, { year: 'numeric', month: 'short' });
+    return d.toLocaleDateString();
+}
+
+async function initStats() {
+    const { html, render, useState, useEffect } = await waitForPreact();
+
+    // Bar sparkline of one value per trend bucket
+    function Sparkline({ trend, bucket, value, color }) {
+        const values = trend.map(value);
+        const max = Math.max(1, ...values);
+        const width = 4, gap = 1, height = 24;
+        return html`
+            <svg class="sparkline" width=${values.length * (width + gap)} height=${height}>
+                ${values.map((v, i) => html`
+                    <rect x=${i * (width + gap)} y=${height - Math.max(v ? 2 : 0, v / max * height)}
+                        width=${width} height=${Math.max(v ? 2 : 0, v / max * height)} fill=${color}>
+                        <title>${formatBucket(trend[i].start, bucket)}: ${v}</title>
+                    </rect>
+                `)}
+            </svg>
+        `;
+    }
+
+    function TrendRow({ label, count, total, stats, value, color }) {
+        return html`
+            <tr>
+                <td>${label}</td>
+                <td class="num">${count}</td>
+                <td class="num">${total !== undefined ? `${percent(count, total)}%` : ''}</td>
+                <td><${Sparkline} trend=${stats.trend} bucket=${stats.bucket} value=${value} color=${color} /></td>
+            </tr>
+        `;
+    }
+
+    function Filters({ query, onChange }) {
+        const set = (name) => (e) => onChange({ ...query, [name]: e.target.value });
+        return html`
+            <div class="stats-filters">
+                <label>Since
+                    <select value=${query.since || '90d'} onChange=${set('since')}>
+                        <option value="7d">7 days</option>
+                        <option value="30d">30 days</option>
+                        <option value="90d">90 days</option>
+                        <option value="365d">1 year</option>
+                        <option value="all">All time</option>
+                    </select>
+                </label>
+                <label>Trend per
+                    <select value=${query.bucket || 'week'} onChange=${set('bucket')}>
+                        <option value="day">day</option>
+                        <option value="week">week</option>
+                        <option value="month">month</option>
+                    </select>
+                </label>
+                <label>Branch <input type="text" value=${query.branch || ''} placeholder="all" onChange=${set('branch')} /></label>
+                <label>Author <input type="text" value=${query.author || ''} placeholder="anyone" onChange=${set('author')} /></label>
+            </div>
+        `;
+    }
+
+    function StatsPage({ stats }) {
+        const s = stats.sessions;
+        const c = stats.commits;
+        const categories = Object.entries(stats.comments.by_category || {}).sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]));
+
+        return html`
+            <div class="stats-cards">
+                <div class="stats-card"><div class="value">${s.total}</div><div class="label">sessions</div></div>
+                <div class="stats-card"><div class="value">${c.count}</div><div class="label">commits</div></div>
+                <div class="stats-card"><div class="value">${c.count ? c.avg_iterations.toFixed(1) : '-'}</div><div class="label">iterations per commit</div></div>
+                <div class="stats-card"><div class="value">${formatDuration(c.median_seconds_to_commit)}</div><div class="label">first review to commit (median)</div></div>
+                <div class="stats-card"><div class="value">${formatDuration(c.avg_review_seconds)}</div><div class="label">review time (average)</div></div>
+                <div class="stats-card"><div class="value">${stats.comments.reviews ? (stats.comments.total / stats.comments.reviews).toFixed(1) : '-'}</div><div class="label">comments per review</div></div>
+            </div>
+
+            <div class="stats-section">
+                <h2>Trends per ${stats.bucket}</h2>
+                <table class="stats-table">
+                    <tbody>
+                        <${TrendRow} label="Sessions" count=${s.total} stats=${stats} value=${b => b.sessions.total} color="var(--accent-blue)" />
+                        <${TrendRow} label="Reviewed" count=${s.reviewed} total=${s.total} stats=${stats} value=${b => b.sessions.reviewed} color="var(--accent-green)" />
+                        <${TrendRow} label="Vouched" count=${s.vouched} total=${s.total} stats=${stats} value=${b => b.sessions.vouched} color="var(--accent-teal)" />
+                        <${TrendRow} label="Skipped" count=${s.skipped} total=${s.total} stats=${stats} value=${b => b.sessions.skipped} color="var(--text-muted)" />
+                        <${TrendRow} label="Comments" count=${stats.comments.total} stats=${stats} value=${b => b.comments} color="var(--accent-blue-light)" />
+                        ${SEVERITIES.map(sev => html`
+                            <${TrendRow}
+                                label=${html`<span class="comment-badge ${getBadgeClass(sev)}">${sev}</span>`}
+                                count=${stats.comments.by_severity[sev] || 0}
+                                stats=${stats}
+                                value=${b => (b.by_severity && b.by_severity[sev]) || 0}
+                                color="var(--text-secondary)"
+                            />
+                        `)}
+                    </tbody>
+                </table>
+            </div>
+
+            ${categories.length > 0 && html`
+                <div class="stats-section">
+                    <h2>Comments by category</h2>
+                    <table class="stats-table">
+                        <thead><tr><th>Category</th><th class="num">Comments</th></tr></thead>
+                        <tbody>
+                            ${categories.map(([cat, n]) => html`<tr><td>${cat}</td><td class="num">${n}</td></tr>`)}
+                        </tbody>
+                    </table>
+                </div>
+            `}
+
+            <div class="stats-section">
+                <h2>Branches</h2>
+                <table class="stats-table">
+                    <thead>
+                        <tr>
+                            <th>Branch</th><th class="num">Sessions</th><th class="num">Reviewed</th><th class="num">Vouched</th>
+                            <th class="num">Skipped</th><th class="num">Commits</th><th class="num">Iterations</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        ${stats.branches.map(b => html`
+                            <tr>
+                                <td>${b.branch}</td>
+                                <td class="num">${b.sessions.total}</td>
+                                <td class="num">${percent(b.sessions.reviewed, b.sessions.total)}%</td>
+                                <td class="num">${percent(b.sessions.vouched, b.sessions.total)}%</td>
+                                <td class="num">${percent(b.sessions.skipped, b.sessions.total)}%</td>
+                                <td class="num">${b.commits}</td>
+                                <td class="num">${b.commits ? b.avg_iterations.toFixed(1) : '-'}</td>
+                            </tr>
+                        `)}
+                    </tbody>
+                </table>
+            </div>
+        `;
+    }
+
+    function App() {
+        const [query, setQuery] = useState(() => Object.fromEntries(new URLSearchParams(window.location.search)));
+        const [stats, setStats] = useState(null);
+        const [error, setError] = useState(null);
+
+        useEffect(() => {
+            const params = new URLSearchParams(Object.entries(query).filter(([, v]) => v));
+            const search = params.toString();
+            window.history.replaceState(null, '', search ? `?${search}` : window.location.pathname);
+            fetch(`/api/stats?${search}`)
+                .then(async res => {
+                    if (!res.ok) throw new Error((await res.text()).trim() || res.statusText);
+                    return res.json();
+                })
+                .then(data => { setStats(data); setError(null); })
+                .catch(err => setError(err.message));
+        }, [query]);
+
+        return html`
+            <div class="main-content">
+                <div class="container">
+                    <div class="header">
+                        <div class="brand">
+                            <div class="logo-wrap">
+                                <img alt="LiveReview" src="${LOGO_DATA_URI}" />
+                            </div>
+                            <div class="brand-text">
+                                <h1>LiveReview Stats</h1>
+                                <div class="meta">Review sessions recorded in this repository's review DB</div>
+                            </div>
+                        </div>
+                    </div>
+                    <${Filters} query=${query} onChange=${setQuery} />
+                    ${error && html`<div class="stats-empty">Failed to load stats: ${error}</div>`}
+                    ${!error && !stats && html`<div class="stats-empty">Loading...</div>`}
+                    ${!error && stats && stats.sessions.total === 0 && html`<div class="stats-empty">No review sessions found.</div>`}
+                    ${!error && stats && stats.sessions.total > 0 && html`<${StatsPage} stats=${stats} />`}
+                </div>
+            </div>
+        `;
+    }
+
+    render(html`<${App} />`, document.getElementById('app'));
+}
+
+if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', initStats);
+} else {
+    initStats();
+}
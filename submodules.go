@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// checkedOutSubmodules lists the submodule paths recorded in dir's index (gitlink
+// entries) that are checked out, relative to dir.
+func checkedOutSubmodules(dir string) ([]string, error) {
+	out, err := runGitCommand("git", "-C", dir, "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// <mode> <object> <stage>\t<path>
+		if !strings.HasPrefix(entry, "160000 ") {
+			continue
+		}
+		tab := strings.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+		p := entry[tab+1:]
+		if fileExists(filepath.Join(dir, filepath.FromSlash(p), ".git")) {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// collectSubmoduleDiffs returns the staged changes inside every checked-out
+// submodule below dir, nested ones included, as one diff whose paths are prefixed
+// with the submodule path so they read as files of the parent repository.
+// Renames are reported as a delete and an add: the "rename from/to" lines of
+// git's diff would not carry the prefix.
+func collectSubmoduleDiffs(dir, prefix string, verbose bool) ([]byte, error) {
+	submodules, err := checkedOutSubmodules(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list submodules: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, sub := range submodules {
+		subDir := filepath.Join(dir, filepath.FromSlash(sub))
+		subPath := path.Join(prefix, sub)
+		diff, err := runGitCommand("git", "-C", subDir, "diff", "--staged", "--no-renames",
+			"--src-prefix=a/"+subPath+"/", "--dst-prefix=b/"+subPath+"/")
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff submodule %s: %w", subPath, err)
+		}
+		if verbose && len(diff) > 0 {
+			log.Printf("Including staged changes of submodule %s", subPath)
+		}
+		buf.Write(diff)
+
+		nested, err := collectSubmoduleDiffs(subDir, subPath, verbose)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(nested)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectSubmoduleDiffs(t *testing.T) {
+	parent := initTestRepo(t)
+	git := func(dir string, args ...string) {
+		t.Helper()
+		runGit(t, append([]string{"-C", dir, "-c", "protocol.file.allow=always"}, args...)...)
+	}
+	newRepo := func(file string) string {
+		t.Helper()
+		dir := t.TempDir()
+		git(dir, "init", "-q")
+		os.WriteFile(filepath.Join(dir, file), []byte("one\n"), 0644)
+		git(dir, "add", file)
+		git(dir, "commit", "-q", "-m", "init")
+		return dir
+	}
+
+	inner := newRepo("inner.txt")
+	lib := newRepo("lib.txt")
+	git(lib, "submodule", "add", "-q", inner, "deps/inner")
+	git(lib, "commit", "-q", "-m", "add inner")
+	git(parent, "submodule", "add", "-q", lib, "lib")
+	git(parent, "submodule", "update", "-q", "--init", "--recursive")
+	git(parent, "commit", "-q", "-m", "add lib")
+
+	if diff, err := collectSubmoduleDiffs(".", "", false); err != nil || len(diff) != 0 {
+		t.Fatalf("nothing staged: diff = %q, %v", diff, err)
+	}
+
+	// Staged changes two levels down, an unstaged one, and a rename
+	os.WriteFile("lib/lib.txt", []byte("one\ntwo\n"), 0644)
+	git("lib", "add", "lib.txt")
+	os.WriteFile("lib/deps/inner/inner.txt", []byte("changed\n"), 0644)
+	git("lib/deps/inner", "add", "inner.txt")
+	os.WriteFile("lib/unstaged.txt", []byte("not staged\n"), 0644)
+	git("lib/deps/inner", "mv", "inner.txt", "moved.txt")
+
+	diff, err := collectSubmoduleDiffs(".", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := parseDiffToFiles(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.FilePath)
+	}
+	want := "lib/lib.txt lib/deps/inner/inner.txt lib/deps/inner/moved.txt"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("submodule diff files = %q, want %q\n%s", got, want, diff)
+	}
+}
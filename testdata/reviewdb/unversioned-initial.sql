@@ -0,0 +1,19 @@
+-- A review DB written by the first lrc releases: review sessions only, no
+-- patch_id column, no review_results table, user_version 0.
+CREATE TABLE review_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tree_hash TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    action TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    diff_files TEXT,
+    review_id TEXT
+);
+CREATE INDEX idx_review_sessions_branch ON review_sessions(branch);
+CREATE INDEX idx_review_sessions_tree ON review_sessions(tree_hash);
+
+INSERT INTO review_sessions (tree_hash, branch, action, timestamp, diff_files, review_id) VALUES
+    ('1111111111111111111111111111111111111111', 'main', 'reviewed', '2025-05-01T10:00:00Z',
+     '[{"file_path":"a.go","hunks":[{"old_start_line":0,"old_line_count":0,"new_start_line":1,"new_line_count":3}]}]', 'rev-1'),
+    ('2222222222222222222222222222222222222222', 'main', 'skipped', '2025-05-01T11:00:00Z',
+     '[{"file_path":"b.go","hunks":[{"old_start_line":0,"old_line_count":0,"new_start_line":1,"new_line_count":1}]}]', '');
@@ -0,0 +1,46 @@
+-- A review DB written just before schema versioning: review sessions with
+-- patch IDs and line hashes, stored review results, user_version 0.
+CREATE TABLE review_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tree_hash TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    action TEXT NOT NULL,
+    timestamp TEXT NOT NULL,
+    diff_files TEXT,
+    review_id TEXT,
+    patch_id TEXT
+);
+CREATE INDEX idx_review_sessions_branch ON review_sessions(branch);
+CREATE INDEX idx_review_sessions_tree ON review_sessions(tree_hash);
+CREATE INDEX idx_review_sessions_patch ON review_sessions(patch_id);
+CREATE TABLE review_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    review_id TEXT NOT NULL,
+    friendly_name TEXT,
+    tree_hash TEXT,
+    branch TEXT NOT NULL,
+    repo_name TEXT,
+    diff_source TEXT,
+    status TEXT NOT NULL,
+    summary TEXT,
+    comment_count INTEGER NOT NULL DEFAULT 0,
+    max_severity INTEGER NOT NULL DEFAULT -1,
+    result_json TEXT NOT NULL,
+    diff TEXT,
+    timestamp TEXT NOT NULL
+);
+CREATE UNIQUE INDEX idx_review_results_review_id ON review_results(review_id);
+CREATE INDEX idx_review_results_branch ON review_results(branch);
+
+INSERT INTO review_sessions (tree_hash, branch, action, timestamp, diff_files, review_id, patch_id) VALUES
+    ('1111111111111111111111111111111111111111', 'main', 'reviewed', '2025-09-01T10:00:00Z',
+     '[{"file_path":"a.go","hunks":[{"old_start_line":0,"old_line_count":0,"new_start_line":1,"new_line_count":3}],"line_hashes":{"1":"5f1e2b8a9c3d4e7f","2":"0a1b2c3d4e5f6a7b","3":"8c9d0e1f2a3b4c5d"}}]',
+     'rev-1', '3333333333333333333333333333333333333333'),
+    ('2222222222222222222222222222222222222222', 'main', 'vouched', '2025-09-01T11:00:00Z',
+     '[{"file_path":"b.go","hunks":[{"old_start_line":0,"old_line_count":0,"new_start_line":1,"new_line_count":1}]}]', '', NULL);
+
+INSERT INTO review_results (review_id, friendly_name, tree_hash, branch, repo_name, diff_source, status, summary,
+    comment_count, max_severity, result_json, diff, timestamp) VALUES
+    ('rev-1', 'brave-otter', '1111111111111111111111111111111111111111', 'main', 'demo', 'staged', 'completed',
+     'Looks fine', 1, 2, '{"status":"completed","summary":"Looks fine","files":[{"file_path":"a.go","hunks":[],"comments":[{"line":2,"content":"Check the error","severity":"warning"}]}]}',
+     NULL, '2025-09-01T10:00:00Z');